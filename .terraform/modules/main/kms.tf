@@ -0,0 +1,10 @@
+resource "aws_kms_key" "users_data_key" {
+  description             = "Wraps the data keys of the users service"
+  deletion_window_in_days = 7
+  enable_key_rotation     = true
+}
+
+resource "aws_kms_alias" "users_data_key" {
+  name          = "alias/${local.resource_prefix}-users-data-key"
+  target_key_id = aws_kms_key.users_data_key.key_id
+}
@@ -26,6 +26,7 @@ provider "aws" {
       firehose       = var.aws_endpoint
       iam            = var.aws_endpoint
       kinesis        = var.aws_endpoint
+      kms            = var.aws_endpoint
       lambda         = var.aws_endpoint
       route53        = var.aws_endpoint
       redshift       = var.aws_endpoint
@@ -0,0 +1,248 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
+)
+
+const (
+	HeaderAPIKey   = "X-API-Key"
+	HeaderAdminKey = "X-Admin-Key"
+
+	DefaultTimeout   = 10 * time.Second
+	DefaultRetries   = 2
+	DefaultBaseDelay = 100 * time.Millisecond
+	DefaultMaxDelay  = 2 * time.Second
+)
+
+// Config sets where the users service is and how calls are retried. Calls
+// are retried up to Retries times waiting, with jitter, twice as long as the
+// previous time from BaseDelay up to MaxDelay, or as long as the Retry-After
+// of the answer. Zero values take the defaults, a negative Retries disables
+// the retries.
+type Config struct {
+	BaseURL string
+	// HTTPClient sends the calls, its transport is wrapped to propagate the
+	// correlation id of the call context.
+	HTTPClient *http.Client
+	// APIKey identifies the calling service, e.g. to the rate limits.
+	APIKey string
+	// AdminKey is required by the admin calls only.
+	AdminKey  string
+	Retries   int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	config     Config
+}
+
+// New fails when the base URL is not an absolute URL.
+func New(config Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("users client base url %q must be an absolute url", config.BaseURL)
+	}
+
+	httpClient := &http.Client{Timeout: DefaultTimeout}
+	if config.HTTPClient != nil {
+		copied := *config.HTTPClient
+		httpClient = &copied
+	}
+	httpClient.Transport = correlation.NewTransport(httpClient.Transport)
+
+	switch {
+	case config.Retries == 0:
+		config.Retries = DefaultRetries
+	case config.Retries < 0:
+		config.Retries = 0
+	}
+
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = DefaultBaseDelay
+	}
+
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = DefaultMaxDelay
+	}
+
+	return &Client{baseURL: baseURL, httpClient: httpClient, config: config}, nil
+}
+
+// call is a request to the service. Only idempotent calls are retried.
+type call struct {
+	method     string
+	path       string
+	query      url.Values
+	headers    map[string]string
+	body       any
+	output     any
+	idempotent bool
+}
+
+// do sends the call, retrying idempotent calls that failed to reach the
+// service or were answered with a status worth retrying.
+func (cl *Client) do(ctx context.Context, c *call) error {
+	var payload []byte
+	if c.body != nil {
+		marshaled, err := json.Marshal(c.body)
+		if err != nil {
+			return err
+		}
+		payload = marshaled
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, retry, err := cl.attempt(ctx, c, payload)
+		if err == nil || !retry || !c.idempotent || attempt >= cl.config.Retries {
+			return err
+		}
+
+		delay := cl.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > cl.config.MaxDelay {
+				return err
+			}
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt tells, along with its error, whether the call is worth retrying
+// and how long the service asked to wait before it.
+func (cl *Client) attempt(ctx context.Context, c *call, payload []byte) (time.Duration, bool, error) {
+	endpoint := cl.baseURL.JoinPath(c.path)
+	endpoint.RawQuery = c.query.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, c.method, endpoint.String(), body)
+	if err != nil {
+		return 0, false, err
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if cl.config.APIKey != "" {
+		request.Header.Set(HeaderAPIKey, cl.config.APIKey)
+	}
+
+	for name, value := range c.headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := cl.httpClient.Do(request)
+	if err != nil {
+		return 0, ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		err := decodeError(response)
+		return retryAfter(response), retryable(err), err
+	}
+
+	if c.output == nil || response.StatusCode == http.StatusNoContent {
+		return 0, false, nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(c.output); err != nil {
+		return 0, false, fmt.Errorf("users client failed to decode %s %s: %w", c.method, c.path, err)
+	}
+
+	return 0, false, nil
+}
+
+// backoff is the delay before the retry following the attempt, with full
+// jitter so the retries of many calls do not hit the service at once.
+func (cl *Client) backoff(attempt int) time.Duration {
+	delay := cl.config.MaxDelay
+	if attempt < 32 {
+		delay = min(cl.config.BaseDelay<<attempt, cl.config.MaxDelay)
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// decodeError returns the exception.Error of the HTTPException answered, so
+// it can be compared with the codes through errors.Is, or an EHTTP error when
+// the answer is not one.
+func decodeError(response *http.Response) error {
+	var answered exception.HTTPException
+
+	content, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err := json.Unmarshal(content, &answered); err != nil || answered.ErrorCode == "" {
+		return &exception.Error{
+			Code:    exception.CodeHttp,
+			Message: http.StatusText(response.StatusCode),
+			Status:  response.StatusCode,
+		}
+	}
+
+	return &exception.Error{
+		Code:    answered.ErrorCode,
+		Message: answered.ErrorMessage,
+		Status:  answered.StatusCode,
+		Details: answered.Details,
+	}
+}
+
+// retryable tells the errors of a service temporarily unable to answer,
+// which a retry may get through.
+func retryable(err error) bool {
+	var answered *exception.Error
+	if !errors.As(err, &answered) {
+		return false
+	}
+
+	switch {
+	case answered.Code == idempotency.CodeRequestInFlight:
+		return true
+	case answered.Status == http.StatusTooManyRequests:
+		return true
+	case answered.Status >= http.StatusInternalServerError && answered.Status != http.StatusNotImplemented:
+		return true
+	}
+
+	return false
+}
+
+func retryAfter(response *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,238 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/clients/users"
+	"github.com/italoservio/braz_ecommerce/clients/users/userstest"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	MOCK_EMAIL    = "goo@gle.com"
+	MOCK_PASSWORD = "12345678"
+)
+
+var mockUser = &app.CreateUserInput{
+	FirstName: "Italo",
+	LastName:  "Servio",
+	Email:     MOCK_EMAIL,
+	Type:      "customer",
+	Password:  MOCK_PASSWORD,
+}
+
+type TestingDependencies_TestClient struct {
+	ctx    context.Context
+	server *userstest.Server
+	client *users.Client
+}
+
+func BeforeEach_TestClient(t *testing.T) *TestingDependencies_TestClient {
+	server := userstest.NewServer()
+	t.Cleanup(server.Close)
+
+	return &TestingDependencies_TestClient{
+		ctx:    context.TODO(),
+		server: server,
+		client: server.Client(users.Config{APIKey: "service-key", AdminKey: "admin-key"}),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should fail when the base url is not absolute", func(t *testing.T) {
+		_, err := users.New(users.Config{BaseURL: "/api"})
+		assert.NotNil(t, err, "should return error")
+	})
+}
+
+func TestClient_Users(t *testing.T) {
+	t.Run("should create, get, update and delete users", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+
+		created, err := deps.client.CreateUser(deps.ctx, mockUser)
+		assert.Nil(t, err, "should not return error")
+		assert.NotEmpty(t, created.Id, "should return the id")
+
+		user, err := deps.client.GetUserById(deps.ctx, &app.GetUserByIdInput{Id: created.Id})
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, MOCK_EMAIL, user.Email, "should return the user")
+
+		updated, err := deps.client.UpdateUserById(deps.ctx, created.Id, &app.UpdateUserByIdInput{FirstName: "Italo2"})
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "Italo2", updated.FirstName, "should return the updated user")
+
+		err = deps.client.DeleteUserById(deps.ctx, created.Id)
+		assert.Nil(t, err, "should not return error")
+
+		_, err = deps.client.GetUserById(deps.ctx, &app.GetUserByIdInput{Id: created.Id})
+		assert.ErrorIs(t, err, exception.ErrNotFound, "should not return deleted users")
+
+		deleted, err := deps.client.GetUserById(deps.ctx, &app.GetUserByIdInput{Id: created.Id, Deleted: true})
+		assert.Nil(t, err, "should not return error")
+		assert.NotNil(t, deleted.DeletedAt, "should return deleted users when asked for")
+	})
+
+	t.Run("should list users by page and filters", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		first := deps.server.AddUser(mockUser)
+		deps.server.AddUser(&app.CreateUserInput{Email: "other@gle.com", FirstName: "Other"})
+
+		page, err := deps.client.ListUsers(deps.ctx, &app.GetUserPaginatedInput{Page: 1, PerPage: 1})
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, *page.Items, 1, "should return a page")
+		assert.Equal(t, "other@gle.com", (*page.Items)[0].Email, "should return the newest first")
+
+		filtered, err := deps.client.ListUsers(deps.ctx, &app.GetUserPaginatedInput{
+			Page:    1,
+			PerPage: 10,
+			Emails:  []string{MOCK_EMAIL},
+		})
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, *filtered.Items, 1, "should filter by email")
+		assert.Equal(t, first, (*filtered.Items)[0].Id, "should return the matching user")
+	})
+
+	t.Run("should decode the answered errors", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		deps.server.AddUser(mockUser)
+
+		_, err := deps.client.CreateUser(deps.ctx, mockUser)
+		assert.ErrorIs(t, err, exception.ErrPermission, "should return the code of the answer")
+
+		_, err = deps.client.CreateUser(deps.ctx, &app.CreateUserInput{Email: MOCK_EMAIL})
+
+		var answered *exception.Error
+		assert.True(t, errors.As(err, &answered), "should return an exception error")
+		assert.Equal(t, 400, answered.Status, "should return the status of the answer")
+		assert.NotEmpty(t, answered.Details, "should return the validation details")
+	})
+
+	t.Run("should log users in", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		id := deps.server.AddUser(mockUser)
+
+		output, err := deps.client.Login(deps.ctx, &app.LoginInput{Email: MOCK_EMAIL, Password: MOCK_PASSWORD})
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, id, output.Id, "should return the user id")
+
+		_, err = deps.client.Login(deps.ctx, &app.LoginInput{Email: MOCK_EMAIL, Password: "wrong"})
+		assert.ErrorIs(t, err, exception.New(app.CodeInvalidCredentials), "should return invalid credentials")
+	})
+
+	t.Run("should send the keys and the correlation id", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		id := deps.server.AddUser(mockUser)
+
+		err := deps.client.UnlockUserById(correlation.WithId(deps.ctx, "correlation-1"), id)
+		assert.Nil(t, err, "should not return error")
+
+		request := deps.server.Requests()[0]
+		assert.Equal(t, "correlation-1", request.Headers.Get(correlation.Header), "should propagate the correlation id")
+		assert.Equal(t, "service-key", request.Headers.Get(users.HeaderAPIKey), "should send the api key")
+		assert.Equal(t, "admin-key", request.Headers.Get(users.HeaderAdminKey), "should send the admin key")
+	})
+}
+
+func TestClient_Retries(t *testing.T) {
+	t.Run("should retry idempotent calls keeping the idempotency key", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		deps.server.FailNext(exception.New(exception.CodeInternal), exception.New(ratelimit.CodeRateLimited))
+
+		created, err := deps.client.CreateUser(deps.ctx, mockUser)
+		assert.Nil(t, err, "should not return error")
+		assert.NotEmpty(t, created.Id, "should return the id")
+
+		requests := deps.server.Requests()
+		assert.Len(t, requests, 3, "should retry until it succeeds")
+		assert.NotEmpty(t, requests[0].Headers.Get(idempotency.HeaderIdempotencyKey), "should send an idempotency key")
+		assert.Equal(
+			t,
+			requests[0].Headers.Get(idempotency.HeaderIdempotencyKey),
+			requests[2].Headers.Get(idempotency.HeaderIdempotencyKey),
+			"should keep the key across retries",
+		)
+	})
+
+	t.Run("should give up after the retries", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		deps.server.FailNext(
+			exception.New(exception.CodeInternal),
+			exception.New(exception.CodeInternal),
+			exception.New(exception.CodeInternal),
+		)
+
+		_, err := deps.client.GetUserById(deps.ctx, &app.GetUserByIdInput{Id: "123"})
+
+		assert.ErrorIs(t, err, exception.ErrInternal, "should return the last error")
+		assert.Len(t, deps.server.Requests(), 3, "should retry twice by default")
+	})
+
+	t.Run("should not retry calls that are not idempotent", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+		deps.server.AddUser(mockUser)
+		deps.server.FailNext(exception.New(exception.CodeInternal))
+
+		_, err := deps.client.Login(deps.ctx, &app.LoginInput{Email: MOCK_EMAIL, Password: MOCK_PASSWORD})
+
+		assert.ErrorIs(t, err, exception.ErrInternal, "should return the error")
+		assert.Len(t, deps.server.Requests(), 1, "should not retry")
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		deps := BeforeEach_TestClient(t)
+
+		_, err := deps.client.GetUserById(deps.ctx, &app.GetUserByIdInput{Id: "123"})
+
+		assert.ErrorIs(t, err, exception.ErrNotFound, "should return the error")
+		assert.Len(t, deps.server.Requests(), 1, "should not retry")
+	})
+
+	t.Run("should wait the retry after answered", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		client, _ := users.New(users.Config{BaseURL: server.URL, BaseDelay: time.Millisecond})
+
+		started := time.Now()
+		err := client.DeleteUserById(context.TODO(), "123")
+
+		assert.Nil(t, err, "should not return error")
+		assert.GreaterOrEqual(t, time.Since(started), time.Second, "should wait the retry after")
+	})
+
+	t.Run("should answer EHTTP when the answer is not an http exception", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client, _ := users.New(users.Config{BaseURL: server.URL, Retries: -1})
+
+		err := client.DeleteUserById(context.TODO(), "123")
+
+		var answered *exception.Error
+		assert.True(t, errors.As(err, &answered), "should return an exception error")
+		assert.Equal(t, exception.CodeHttp, answered.Code, "should return EHTTP")
+		assert.Equal(t, http.StatusBadGateway, answered.Status, "should return the status of the answer")
+	})
+}
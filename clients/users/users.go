@@ -0,0 +1,158 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+)
+
+const usersPath = "/api/v1/users"
+
+func userPath(id string, segments ...string) string {
+	path := usersPath + "/" + url.PathEscape(id)
+	for _, segment := range segments {
+		path += "/" + segment
+	}
+
+	return path
+}
+
+func (cl *Client) GetUserById(ctx context.Context, input *app.GetUserByIdInput) (*app.GetUserByIdOutput, error) {
+	var output app.GetUserByIdOutput
+
+	err := cl.do(ctx, &call{
+		method:     http.MethodGet,
+		path:       userPath(input.Id),
+		query:      url.Values{"deleted": {strconv.FormatBool(input.Deleted)}},
+		output:     &output,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (cl *Client) ListUsers(
+	ctx context.Context,
+	input *app.GetUserPaginatedInput,
+) (*database.PaginatedSlice[app.GetUserPaginatedOutput], error) {
+	var output database.PaginatedSlice[app.GetUserPaginatedOutput]
+
+	query := url.Values{
+		"page":     {strconv.Itoa(input.Page)},
+		"per_page": {strconv.Itoa(input.PerPage)},
+		"deleted":  {strconv.FormatBool(input.Deleted)},
+	}
+	for _, email := range input.Emails {
+		query.Add("email", email)
+	}
+	for _, id := range input.Ids {
+		query.Add("id", id)
+	}
+
+	err := cl.do(ctx, &call{
+		method:     http.MethodGet,
+		path:       usersPath,
+		query:      query,
+		output:     &output,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+// CreateUser sends an Idempotency-Key, kept across the retries, so retrying
+// does not create the user twice.
+func (cl *Client) CreateUser(ctx context.Context, input *app.CreateUserInput) (*app.CreateUserOutput, error) {
+	var output app.CreateUserOutput
+
+	err := cl.do(ctx, &call{
+		method:     http.MethodPost,
+		path:       usersPath,
+		headers:    map[string]string{idempotency.HeaderIdempotencyKey: uuid.NewString()},
+		body:       input,
+		output:     &output,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (cl *Client) UpdateUserById(
+	ctx context.Context,
+	id string,
+	input *app.UpdateUserByIdInput,
+) (*app.UpdateUserByIdOutput, error) {
+	var output app.UpdateUserByIdOutput
+
+	err := cl.do(ctx, &call{
+		method: http.MethodPatch,
+		path:   userPath(id),
+		body:   input,
+		output: &output,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (cl *Client) DeleteUserById(ctx context.Context, id string) error {
+	return cl.do(ctx, &call{
+		method:     http.MethodDelete,
+		path:       userPath(id),
+		idempotent: true,
+	})
+}
+
+// Login is not retried, as each failed attempt counts towards the lockout.
+func (cl *Client) Login(ctx context.Context, input *app.LoginInput) (*app.LoginOutput, error) {
+	var output app.LoginOutput
+
+	err := cl.do(ctx, &call{
+		method: http.MethodPost,
+		path:   usersPath + "/login",
+		body:   input,
+		output: &output,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+// UnlockAccount is not retried, as the token is spent by the first call.
+func (cl *Client) UnlockAccount(ctx context.Context, input *app.UnlockAccountInput) error {
+	return cl.do(ctx, &call{
+		method: http.MethodPost,
+		path:   usersPath + "/unlock",
+		body:   input,
+	})
+}
+
+// UnlockUserById is an admin call, sent with the AdminKey of the config.
+// Unlocking an unlocked account does nothing, so it is retried.
+func (cl *Client) UnlockUserById(ctx context.Context, id string) error {
+	return cl.do(ctx, &call{
+		method:     http.MethodPost,
+		path:       userPath(id, "unlock"),
+		headers:    map[string]string{HeaderAdminKey: cl.config.AdminKey},
+		idempotent: true,
+	})
+}
@@ -0,0 +1,341 @@
+// Package userstest provides a fake users service for the tests of the
+// services calling it through the users client.
+package userstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/italoservio/braz_ecommerce/clients/users"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/validation"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type user struct {
+	*domain.UserDatabaseNoPassword
+	password string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method  string
+	Path    string
+	Headers http.Header
+}
+
+// Server answers the routes of the users service as it does, keeping the
+// users in memory. Tokens are not checked, so any unlock of an existing
+// account succeeds.
+type Server struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	users    []*user
+	failures []*exception.Error
+	requests []Request
+}
+
+// NewServer starts a server, to be closed by the test.
+func NewServer() *Server {
+	s := &Server{}
+
+	fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	fbr.Use(s.record)
+	fbr.Post("/api/v1/users", s.createUser)
+	fbr.Post("/api/v1/users/login", s.login)
+	fbr.Post("/api/v1/users/unlock", s.unlockAccount)
+	fbr.Get("/api/v1/users", s.listUsers)
+	fbr.Get("/api/v1/users/:id", s.getUserById)
+	fbr.Delete("/api/v1/users/:id", s.deleteUserById)
+	fbr.Patch("/api/v1/users/:id", s.updateUserById)
+	fbr.Post("/api/v1/users/:id/unlock", s.unlockUserById)
+
+	s.Server = httptest.NewServer(adaptor.FiberApp(fbr))
+
+	return s
+}
+
+// Client returns a client of the server that does not wait between retries.
+func (s *Server) Client(config users.Config) *users.Client {
+	config.BaseURL = s.URL
+	if config.BaseDelay == 0 {
+		config.BaseDelay = time.Millisecond
+	}
+
+	client, err := users.New(config)
+	if err != nil {
+		panic(err)
+	}
+
+	return client
+}
+
+// AddUser stores the user as created and returns its id.
+func (s *Server) AddUser(input *app.CreateUserInput) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.add(input)
+}
+
+// FailNext makes the next requests answer the errors, one per request, e.g.
+// exception.New(exception.CodeInternal).
+func (s *Server) FailNext(errs ...*exception.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures = append(s.failures, errs...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) record(c *fiber.Ctx) error {
+	s.mutex.Lock()
+
+	headers := http.Header{}
+	c.Request().Header.VisitAll(func(key []byte, value []byte) {
+		headers.Add(string(key), string(value))
+	})
+	s.requests = append(s.requests, Request{Method: c.Method(), Path: c.Path(), Headers: headers})
+
+	if len(s.failures) > 0 {
+		failure := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mutex.Unlock()
+
+		return failure
+	}
+
+	s.mutex.Unlock()
+
+	return c.Next()
+}
+
+func (s *Server) add(input *app.CreateUserInput) string {
+	now := time.Now()
+	id := primitive.NewObjectID().Hex()
+
+	s.users = append(s.users, &user{
+		UserDatabaseNoPassword: &domain.UserDatabaseNoPassword{
+			DatabaseIdentifier: &database.DatabaseIdentifier{Id: id},
+			User: &domain.User{
+				Type:      input.Type,
+				FirstName: input.FirstName,
+				LastName:  input.LastName,
+				Email:     input.Email,
+				Addresses: []domain.UserAddress{},
+			},
+			DatabaseTimestamp: &database.DatabaseTimestamp{CreatedAt: now, UpdatedAt: now},
+		},
+		password: input.Password,
+	})
+
+	return id
+}
+
+// find returns the user of the id, the deleted ones only when asked for.
+func (s *Server) find(id string, deleted bool) (*user, error) {
+	for _, u := range s.users {
+		if u.Id == id && (deleted || u.DeletedAt == nil) {
+			return u, nil
+		}
+	}
+
+	return nil, exception.New(exception.CodeNotFound)
+}
+
+func (s *Server) createUser(c *fiber.Ctx) error {
+	body := &app.CreateUserInput{}
+	if err := c.BodyParser(body); err != nil {
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == body.Email && u.DeletedAt == nil {
+			return exception.New(exception.CodePermission)
+		}
+	}
+
+	id := s.add(body)
+
+	return c.Status(http.StatusCreated).JSON(app.CreateUserOutput{
+		DatabaseIdentifier: &database.DatabaseIdentifier{Id: id},
+	})
+}
+
+func (s *Server) listUsers(c *fiber.Ctx) error {
+	query := struct {
+		Page    int      `query:"page" validate:"required,number,gt=0"`
+		PerPage int      `query:"per_page" validate:"required,number,gt=0,lte=100"`
+		Emails  []string `query:"email"`
+		Ids     []string `query:"id"`
+		Deleted bool     `query:"deleted"`
+	}{}
+
+	if err := c.QueryParser(&query); err != nil {
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, query); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	matching := []app.GetUserPaginatedOutput{}
+	for i := len(s.users) - 1; i >= 0; i-- {
+		u := s.users[i]
+
+		switch {
+		case !query.Deleted && u.DeletedAt != nil:
+		case len(query.Emails) > 0 && !slices.Contains(query.Emails, u.Email):
+		case len(query.Ids) > 0 && !slices.Contains(query.Ids, u.Id):
+		default:
+			matching = append(matching, app.GetUserPaginatedOutput{UserDatabaseNoPassword: u.UserDatabaseNoPassword})
+		}
+	}
+
+	start := min((query.Page-1)*query.PerPage, len(matching))
+	items := matching[start:min(start+query.PerPage, len(matching))]
+
+	return c.JSON(database.NewPaginatedSlice(query.Page, query.PerPage, &items))
+}
+
+func (s *Server) getUserById(c *fiber.Ctx) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	u, err := s.find(c.Params("id"), c.QueryBool("deleted"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(app.GetUserByIdOutput{UserDatabaseNoPassword: u.UserDatabaseNoPassword})
+}
+
+func (s *Server) deleteUserById(c *fiber.Ctx) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	u, err := s.find(c.Params("id"), false)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	u.DeletedAt = &now
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (s *Server) updateUserById(c *fiber.Ctx) error {
+	body := &app.UpdateUserByIdInput{}
+	if err := c.BodyParser(body); err != nil {
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	u, err := s.find(c.Params("id"), false)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range s.users {
+		if body.Email != "" && other.Email == body.Email && other != u && other.DeletedAt == nil {
+			return exception.New(exception.CodePermission)
+		}
+	}
+
+	for field, value := range map[*string]string{
+		&u.FirstName: body.FirstName,
+		&u.LastName:  body.LastName,
+		&u.Email:     body.Email,
+		&u.Type:      body.Type,
+		&u.password:  body.Password,
+	} {
+		if value != "" {
+			*field = value
+		}
+	}
+	u.UpdatedAt = time.Now()
+
+	return c.JSON(app.UpdateUserByIdOutput{UserDatabaseNoPassword: u.UserDatabaseNoPassword})
+}
+
+func (s *Server) login(c *fiber.Ctx) error {
+	body := &app.LoginInput{}
+	if err := c.BodyParser(body); err != nil {
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == body.Email && u.password == body.Password && u.DeletedAt == nil {
+			return c.JSON(app.LoginOutput{DatabaseIdentifier: &database.DatabaseIdentifier{Id: u.Id}})
+		}
+	}
+
+	return exception.New(app.CodeInvalidCredentials)
+}
+
+func (s *Server) unlockAccount(c *fiber.Ctx) error {
+	body := &app.UnlockAccountInput{}
+	if err := c.BodyParser(body); err != nil {
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (s *Server) unlockUserById(c *fiber.Ctx) error {
+	if c.Get(users.HeaderAdminKey) == "" {
+		return exception.New(exception.CodePermission)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.find(c.Params("id"), false); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,60 +11,244 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	fbrlogger "github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
-	"github.com/google/uuid"
 	"github.com/italoservio/braz_ecommerce/cmd/users/start"
+	"github.com/italoservio/braz_ecommerce/packages/config"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/health"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
 	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+	"github.com/italoservio/braz_ecommerce/packages/openapi"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/packages/rpc"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/grpc/pb"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/http"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	app := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	env, err := start.NewEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fiberConfig, err := env.Fiber()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fiberConfig.ErrorHandler = exception.HttpExceptionHandler
+	app := fiber.New(fiberConfig)
+
+	loggerImpl := logger.NewLoggerWithConfig(logger.Config{
+		Level:  env.LOG_LEVEL,
+		Format: env.LOG_FORMAT,
+	})
+	logger.SetDefault(loggerImpl)
+
+	loggerImpl.Info("config loaded", "config", config.Redacted(env))
+
+	keyring, err := env.Keyring(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		ServiceName: "users",
+		Exporter:    env.TRACE_EXPORTER,
+		File:        env.TRACE_FILE,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	metricsImpl := metrics.New()
+	healthRegistry := health.NewRegistry(health.DefaultCacheTTL)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+
+	var db *database.Database
+	var controllers *start.Controllers
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	var idempotencyStore idempotency.Store = idempotency.NewMemoryStore()
+
+	if env.DB_DRIVER == start.DatabaseDriverMemory {
+		controllers, err = start.InMemoryInjectionsContainer(
+			keyring,
+			env.ENC_INDEX_KEY,
+			env.LoginPolicy(),
+			env.Mailer(loggerImpl),
+			loggerImpl,
+			metricsImpl,
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		db, err = database.NewDatabase(
+			env.DB_URI,
+			env.DB_NAME,
+			options.Client().SetPoolMonitor(metricsImpl.PoolMonitor()),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		healthRegistry.Register(health.Check{Name: "mongodb", Checker: db.Ping, Critical: true})
+
+		controllers, err = start.InjectionsContainer(
+			keyring,
+			env.ENC_INDEX_KEY,
+			env.LoginPolicy(),
+			db,
+			env.DatabaseTimeouts(),
+			env.Mailer(loggerImpl),
+			loggerImpl,
+			metricsImpl,
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	env := start.NewEnv()
+		mongoRateLimitStore := ratelimit.NewMongoStore(db)
+		if err := mongoRateLimitStore.EnsureIndexes(context.Background()); err != nil {
+			log.Fatal(err)
+		}
 
-	db, err := database.NewDatabase(env.DB_URI, env.DB_NAME)
+		rateLimitStore = mongoRateLimitStore
+
+		mongoIdempotencyStore := idempotency.NewMongoStore(db)
+		if err := mongoIdempotencyStore.EnsureIndexes(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+
+		idempotencyStore = mongoIdempotencyStore
+
+		rotationJob, err := start.RotationJobContainer(keyring, env.ENC_INDEX_KEY, db, loggerImpl)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		go rotationJob.Start(jobsCtx, env.ENC_ROTATION_INTERVAL)
+	}
+
+	apiLimiter, signupLimiter, loginLimiter, err := env.RateLimiters(rateLimitStore)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	userController := start.InjectionsContainer(db)
+	idempotencyImpl, err := env.Idempotency(idempotencyStore)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	app.Get("/health", start.HealthCheckEndpoint(db))
+	app.Use(correlation.New())
+	app.Use(i18n.New())
+	app.Use(tracing.New())
+	app.Use(metricsImpl.Middleware())
+	app.Get("/health/live", healthRegistry.LiveHandler())
+	app.Get("/health/ready", healthRegistry.ReadyHandler())
+	app.Get("/metrics", metricsImpl.Handler())
 
 	api := app.Group("/api")
 	api.Use(fbrlogger.New(loggerConfig()))
-	api.Use(requestid.New(requestid.Config{
-		Header:     string(logger.CorrelationId),
-		ContextKey: string(logger.CorrelationId),
-		Generator: func() string {
-			return uuid.New().String()
-		},
-	}))
+	api.Use(apiLimiter.Middleware())
+	api.Get("/errors", exception.CatalogHandler()).Name(http.RouteErrorsCatalog)
 
 	usersV1 := api.Group("/v1/users")
-	usersV1.Post("/", userController.CreateUser)
-	usersV1.Get("/", userController.GetUserPaginated)
-	usersV1.Get("/:id", userController.GetUserById)
-	usersV1.Delete("/:id", userController.DeleteUserById)
-	usersV1.Patch("/:id", userController.UpdateUserById)
+	usersV1.Post("/", signupLimiter.Middleware(), idempotencyImpl.Middleware(), controllers.Users.CreateUser).
+		Name(http.RouteCreateUser)
+	usersV1.Post("/login", loginLimiter.Middleware(), controllers.Auth.Login).Name(http.RouteLogin)
+	usersV1.Post("/unlock", loginLimiter.Middleware(), controllers.Auth.UnlockAccount).Name(http.RouteUnlockAccount)
+	usersV1.Get("/", controllers.Users.GetUserPaginated).Name(http.RouteGetUserPaginated)
+	usersV1.Get("/:id", controllers.Users.GetUserById).Name(http.RouteGetUserById)
+	usersV1.Delete("/:id", controllers.Users.DeleteUserById).Name(http.RouteDeleteUserById)
+	usersV1.Patch("/:id", controllers.Users.UpdateUserById).Name(http.RouteUpdateUserById)
+	usersV1.Post("/:id/unlock", http.RequireAdminKey(env.ADMIN_KEY), controllers.Auth.UnlockUserById).
+		Name(http.RouteUnlockUserById)
+
+	document, err := openapi.Generate(
+		openapi.Info{Title: "Braz E-commerce users", Version: "1.0.0"},
+		app.GetRoutes(true),
+		http.Routes(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	api.Get("/docs/openapi.json", openapi.Handler(document))
+	api.Get("/docs", openapi.Viewer("/api/docs/openapi.json"))
+
+	go func() {
+		if err := app.Listen(env.Address()); err != nil {
+			log.Fatal(err)
+		}
+	}()
 
-	go func() { log.Fatal(app.Listen(env.PORT)) }()
+	var rpcServer *grpc.Server
+	if address := env.GRPCAddress(); address != "" {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		rpcServer = rpc.NewServer(rpc.Config{
+			Logger:  loggerImpl,
+			APIKey:  env.GRPC_API_KEY,
+			Metrics: metricsImpl,
+			Limiter: apiLimiter,
+		})
+		pb.RegisterUsersServiceServer(rpcServer, controllers.UsersRPC)
+
+		go func() {
+			if err := rpcServer.Serve(listener); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
-	gracefulShutdown(app, db)
+	stopJobs()
+	gracefulShutdown(env.SHUTDOWN_DRAIN_DELAY, env.SHUTDOWN_TIMEOUT, app, rpcServer, db, healthRegistry, shutdownTracing)
 }
 
-func gracefulShutdown(app *fiber.App, db *database.Database) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// gracefulShutdown fails readiness and waits drainDelay, for the load
+// balancer to probe it and stop routing traffic, before closing the
+// listeners. The servers and the dependencies then get timeout to stop.
+func gracefulShutdown(
+	drainDelay time.Duration,
+	timeout time.Duration,
+	app *fiber.App,
+	rpcServer *grpc.Server,
+	db *database.Database,
+	healthRegistry *health.Registry,
+	shutdownTracing func(ctx context.Context) error,
+) {
+	healthRegistry.Shutdown()
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	app.ShutdownWithContext(ctx)
-	db.Client().Disconnect(ctx)
+
+	if rpcServer != nil {
+		rpc.Shutdown(ctx, rpcServer)
+	}
+
+	if db != nil {
+		db.Client().Disconnect(ctx)
+	}
+
+	shutdownTracing(ctx)
 }
 
 func loggerConfig() fbrlogger.Config {
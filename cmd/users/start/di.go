@@ -1,25 +1,123 @@
 package start
 
 import (
+	"context"
+
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/encryption"
 	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
 	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/grpc"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/http"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
 )
 
-func InjectionsContainer(db *database.Database) *http.UserControllerImpl {
-	loggerImpl := logger.NewLogger()
-	encryptionImpl := encryption.NewEncryptionImpl(loggerImpl)
+// Controllers are the HTTP controllers and the gRPC server of the users
+// service.
+type Controllers struct {
+	Users    *http.UserControllerImpl
+	Auth     *http.AuthControllerImpl
+	UsersRPC *grpc.UserServerImpl
+}
+
+func InjectionsContainer(
+	keyring *encryption.Keyring,
+	indexKey string,
+	loginPolicy app.LoginPolicy,
+	db *database.Database,
+	timeouts database.Timeouts,
+	mailer mail.MailerInterface,
+	loggerImpl *logger.Logger,
+	metricsImpl *metrics.Metrics,
+) (*Controllers, error) {
+	encryptionImpl := encryption.NewEncryptionImpl(loggerImpl, keyring)
+	fieldEncryptionImpl, err := encryption.NewFieldEncryptionImpl(encryptionImpl, indexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	usersMetrics := app.NewUsersMetrics(metricsImpl)
+	auditImpl := app.NewAuditImpl(loggerImpl)
+
+	loginAttemptsStoreImpl := storage.NewLoginAttemptsStoreImpl(loggerImpl, db)
+	if err := loginAttemptsStoreImpl.EnsureIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	userRepositoryImpl := storage.NewUserRepositoryImpl(loggerImpl, db).WithTimeouts(timeouts).WithObserver(metricsImpl)
+	if err := userRepositoryImpl.EnsureIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	crudRepositoryImpl := database.NewCrudRepository(loggerImpl, db).WithTimeouts(timeouts).WithObserver(metricsImpl)
+	getUserByIdImpl := app.NewGetUserByIdImpl(fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl)
+	deleteUserByIdImpl := app.NewDeleteUserByIdImpl(crudRepositoryImpl, userRepositoryImpl, usersMetrics)
+	createUserImpl := app.NewCreateUserImpl(encryptionImpl, fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl, db, usersMetrics)
+	getUserPaginatedImpl := app.NewGetUserPaginatedImpl(fieldEncryptionImpl, crudRepositoryImpl)
+	updateUserByIdImpl := app.NewUpdateUserByIdImpl(encryptionImpl, fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl)
+	loginImpl := app.NewLoginImpl(encryptionImpl, fieldEncryptionImpl, userRepositoryImpl, loginAttemptsStoreImpl, mailer, auditImpl, usersMetrics, loginPolicy)
+	unlockAccountImpl := app.NewUnlockAccountImpl(loginAttemptsStoreImpl, auditImpl)
+	unlockUserByIdImpl := app.NewUnlockUserByIdImpl(crudRepositoryImpl, loginAttemptsStoreImpl, auditImpl)
+
+	userControllerImpl := http.NewUserControllerImpl(
+		loggerImpl,
+		getUserByIdImpl,
+		deleteUserByIdImpl,
+		createUserImpl,
+		getUserPaginatedImpl,
+		updateUserByIdImpl,
+	)
+
+	authControllerImpl := http.NewAuthControllerImpl(loggerImpl, loginImpl, unlockAccountImpl, unlockUserByIdImpl)
+
+	userServerImpl := grpc.NewUserServerImpl(
+		getUserByIdImpl,
+		deleteUserByIdImpl,
+		createUserImpl,
+		getUserPaginatedImpl,
+		updateUserByIdImpl,
+	)
+
+	return &Controllers{Users: userControllerImpl, Auth: authControllerImpl, UsersRPC: userServerImpl}, nil
+}
+
+// InMemoryInjectionsContainer wires the users service on top of an in-memory
+// database, so it can run without MongoDB.
+func InMemoryInjectionsContainer(
+	keyring *encryption.Keyring,
+	indexKey string,
+	loginPolicy app.LoginPolicy,
+	mailer mail.MailerInterface,
+	loggerImpl *logger.Logger,
+	metricsImpl *metrics.Metrics,
+) (*Controllers, error) {
+	encryptionImpl := encryption.NewEncryptionImpl(loggerImpl, keyring)
+	fieldEncryptionImpl, err := encryption.NewFieldEncryptionImpl(encryptionImpl, indexKey)
+	if err != nil {
+		return nil, err
+	}
 
-	userRepositoryImpl := storage.NewUserRepositoryImpl(loggerImpl, db)
-	crudRepositoryImpl := database.NewCrudRepository(loggerImpl, db)
-	getUserByIdImpl := app.NewGetUserByIdImpl(crudRepositoryImpl, userRepositoryImpl)
-	deleteUserByIdImpl := app.NewDeleteUserByIdImpl(crudRepositoryImpl, userRepositoryImpl)
-	createUserImpl := app.NewCreateUserImpl(encryptionImpl, crudRepositoryImpl, userRepositoryImpl)
-	getUserPaginatedImpl := app.NewGetUserPaginatedImpl(crudRepositoryImpl)
-	updateUserByIdImpl := app.NewUpdateUserByIdImpl(encryptionImpl, crudRepositoryImpl, userRepositoryImpl)
+	usersMetrics := app.NewUsersMetrics(metricsImpl)
+	auditImpl := app.NewAuditImpl(loggerImpl)
+	memoryDatabase := database.NewMemoryDatabase()
+	loginAttemptsStoreImpl := storage.NewLoginAttemptsMemoryStoreImpl()
+
+	userRepositoryImpl := storage.NewUserMemoryRepositoryImpl(loggerImpl, memoryDatabase)
+	if err := userRepositoryImpl.EnsureIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	crudRepositoryImpl := database.NewMemoryCrudRepository(loggerImpl, memoryDatabase)
+	getUserByIdImpl := app.NewGetUserByIdImpl(fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl)
+	deleteUserByIdImpl := app.NewDeleteUserByIdImpl(crudRepositoryImpl, userRepositoryImpl, usersMetrics)
+	createUserImpl := app.NewCreateUserImpl(encryptionImpl, fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl, memoryDatabase, usersMetrics)
+	getUserPaginatedImpl := app.NewGetUserPaginatedImpl(fieldEncryptionImpl, crudRepositoryImpl)
+	updateUserByIdImpl := app.NewUpdateUserByIdImpl(encryptionImpl, fieldEncryptionImpl, crudRepositoryImpl, userRepositoryImpl)
+	loginImpl := app.NewLoginImpl(encryptionImpl, fieldEncryptionImpl, userRepositoryImpl, loginAttemptsStoreImpl, mailer, auditImpl, usersMetrics, loginPolicy)
+	unlockAccountImpl := app.NewUnlockAccountImpl(loginAttemptsStoreImpl, auditImpl)
+	unlockUserByIdImpl := app.NewUnlockUserByIdImpl(crudRepositoryImpl, loginAttemptsStoreImpl, auditImpl)
 
 	userControllerImpl := http.NewUserControllerImpl(
 		loggerImpl,
@@ -30,5 +128,39 @@ func InjectionsContainer(db *database.Database) *http.UserControllerImpl {
 		updateUserByIdImpl,
 	)
 
-	return userControllerImpl
+	authControllerImpl := http.NewAuthControllerImpl(loggerImpl, loginImpl, unlockAccountImpl, unlockUserByIdImpl)
+
+	userServerImpl := grpc.NewUserServerImpl(
+		getUserByIdImpl,
+		deleteUserByIdImpl,
+		createUserImpl,
+		getUserPaginatedImpl,
+		updateUserByIdImpl,
+	)
+
+	return &Controllers{Users: userControllerImpl, Auth: authControllerImpl, UsersRPC: userServerImpl}, nil
+}
+
+// RotationJobContainer wires the job that re-encrypts the users passwords and
+// personal fields with the primary key of the keyring.
+func RotationJobContainer(
+	keyring *encryption.Keyring,
+	indexKey string,
+	db *database.Database,
+	loggerImpl *logger.Logger,
+) (*encryption.RotationJob, error) {
+	encryptionImpl := encryption.NewEncryptionImpl(loggerImpl, keyring)
+	fieldEncryptionImpl, err := encryption.NewFieldEncryptionImpl(encryptionImpl, indexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rotationJob := encryption.NewRotationJob(
+		loggerImpl,
+		encryptionImpl,
+		keyring,
+		storage.NewUserPasswordRotationStoreImpl(loggerImpl, db),
+	).WithDocuments(storage.NewUserFieldsRotationStoreImpl(loggerImpl, db, fieldEncryptionImpl))
+
+	return rotationJob, nil
 }
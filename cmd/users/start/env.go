@@ -1,24 +1,253 @@
 package start
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/config"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+)
+
+const (
+	DatabaseDriverMongo  = "mongo"
+	DatabaseDriverMemory = "memory"
+
+	MailDriverLog  = "log"
+	MailDriverSMTP = "smtp"
+
+	KMSProviderNone = "none"
+	KMSProviderAWS  = "aws"
+	KMSProviderFile = "file"
 )
 
 type EnvironmentVariables struct {
-	PORT       string
-	DB_URI     string
-	DB_NAME    string
-	ENC_SECRET string
+	PORT                       string        `env:"PORT" default:"3000" validate:"required,numeric"`
+	PROXY_HEADER               string        `env:"PROXY_HEADER"`
+	TRUSTED_PROXIES            string        `env:"TRUSTED_PROXIES" validate:"required_with=PROXY_HEADER"`
+	DB_DRIVER                  string        `env:"DB_DRIVER" default:"mongo" validate:"oneof=mongo memory"`
+	DB_URI                     string        `env:"DB_URI" validate:"required_if=DB_DRIVER mongo,omitempty,uri" secret:"true"`
+	DB_NAME                    string        `env:"DB_NAME" default:"users" validate:"required"`
+	DB_TIMEOUT_GET_BY_ID       time.Duration `env:"DB_TIMEOUT_GET_BY_ID" default:"10s" validate:"gt=0"`
+	DB_TIMEOUT_GET_BY_EMAIL    time.Duration `env:"DB_TIMEOUT_GET_BY_EMAIL" default:"10s" validate:"gt=0"`
+	DB_TIMEOUT_DELETE_BY_ID    time.Duration `env:"DB_TIMEOUT_DELETE_BY_ID" default:"10s" validate:"gt=0"`
+	DB_TIMEOUT_CREATE_ONE      time.Duration `env:"DB_TIMEOUT_CREATE_ONE" default:"10s" validate:"gt=0"`
+	DB_TIMEOUT_UPDATE_BY_ID    time.Duration `env:"DB_TIMEOUT_UPDATE_BY_ID" default:"10s" validate:"gt=0"`
+	DB_TIMEOUT_GET_PAGINATED   time.Duration `env:"DB_TIMEOUT_GET_PAGINATED" default:"10s" validate:"gt=0"`
+	ENC_KEYS                   string        `env:"ENC_KEYS" validate:"required_if=ENC_KMS_PROVIDER none" secret:"true"`
+	ENC_PRIMARY_KEY            string        `env:"ENC_PRIMARY_KEY" default:"v1" validate:"required"`
+	ENC_INDEX_KEY              string        `env:"ENC_INDEX_KEY" validate:"required,min=32" secret:"true"`
+	ENC_ROTATION_INTERVAL      time.Duration `env:"ENC_ROTATION_INTERVAL" default:"1h" validate:"gt=0"`
+	ENC_KMS_PROVIDER           string        `env:"ENC_KMS_PROVIDER" default:"none" validate:"oneof=none aws file"`
+	ENC_KMS_ID                 string        `env:"ENC_KMS_ID" default:"kms" validate:"required"`
+	ENC_KMS_KEY                string        `env:"ENC_KMS_KEY" validate:"required_if=ENC_KMS_PROVIDER aws"`
+	ENC_KMS_MASTER_KEY_PATH    string        `env:"ENC_KMS_MASTER_KEY_PATH" validate:"required_if=ENC_KMS_PROVIDER file"`
+	ENC_DATA_KEY_MAX_AGE       time.Duration `env:"ENC_DATA_KEY_MAX_AGE" default:"1m" validate:"gt=0"`
+	ENC_DATA_KEY_MAX_USES      int           `env:"ENC_DATA_KEY_MAX_USES" default:"100" validate:"gt=0"`
+	AWS_REGION                 string        `env:"AWS_REGION" default:"sa-east-1" validate:"required"`
+	AWS_ENDPOINT               string        `env:"AWS_ENDPOINT" validate:"omitempty,url"`
+	LOG_LEVEL                  string        `env:"LOG_LEVEL" default:"info" validate:"oneof=debug info warn warning error"`
+	LOG_FORMAT                 string        `env:"LOG_FORMAT" default:"text" validate:"oneof=text json"`
+	TRACE_EXPORTER             string        `env:"TRACE_EXPORTER" default:"none" validate:"oneof=none stdout file"`
+	TRACE_FILE                 string        `env:"TRACE_FILE" validate:"required_if=TRACE_EXPORTER file"`
+	SHUTDOWN_TIMEOUT           time.Duration `env:"SHUTDOWN_TIMEOUT" default:"10s" validate:"gt=0"`
+	SHUTDOWN_DRAIN_DELAY       time.Duration `env:"SHUTDOWN_DRAIN_DELAY" default:"5s" validate:"gte=0"`
+	LOGIN_DELAY_AFTER          int           `env:"LOGIN_DELAY_AFTER" default:"3" validate:"gt=0"`
+	LOGIN_BASE_DELAY           time.Duration `env:"LOGIN_BASE_DELAY" default:"1s" validate:"gt=0"`
+	LOGIN_MAX_DELAY            time.Duration `env:"LOGIN_MAX_DELAY" default:"30s" validate:"gt=0"`
+	LOGIN_MAX_ACCOUNT_FAILURES int           `env:"LOGIN_MAX_ACCOUNT_FAILURES" default:"10" validate:"gt=0"`
+	LOGIN_MAX_IP_FAILURES      int           `env:"LOGIN_MAX_IP_FAILURES" default:"100" validate:"gt=0"`
+	LOGIN_LOCK_DURATION        time.Duration `env:"LOGIN_LOCK_DURATION" default:"15m" validate:"gt=0"`
+	LOGIN_WINDOW               time.Duration `env:"LOGIN_WINDOW" default:"15m" validate:"gt=0"`
+	LOGIN_UNLOCK_URL           string        `env:"LOGIN_UNLOCK_URL" default:"http://localhost:8080/unlock" validate:"required,url"`
+	ADMIN_KEY                  string        `env:"ADMIN_KEY" validate:"omitempty,min=32" secret:"true"`
+	MAIL_DRIVER                string        `env:"MAIL_DRIVER" default:"log" validate:"oneof=log smtp"`
+	MAIL_FROM                  string        `env:"MAIL_FROM" validate:"required_if=MAIL_DRIVER smtp,omitempty,email"`
+	SMTP_ADDRESS               string        `env:"SMTP_ADDRESS" validate:"required_if=MAIL_DRIVER smtp,omitempty,hostname_port"`
+	SMTP_USERNAME              string        `env:"SMTP_USERNAME"`
+	SMTP_PASSWORD              string        `env:"SMTP_PASSWORD" secret:"true"`
+	RATE_LIMIT_API_LIMIT       int           `env:"RATE_LIMIT_API_LIMIT" default:"300" validate:"gt=0"`
+	RATE_LIMIT_API_WINDOW      time.Duration `env:"RATE_LIMIT_API_WINDOW" default:"1m" validate:"gt=0"`
+	RATE_LIMIT_SIGNUP_LIMIT    int           `env:"RATE_LIMIT_SIGNUP_LIMIT" default:"10" validate:"gt=0"`
+	RATE_LIMIT_SIGNUP_WINDOW   time.Duration `env:"RATE_LIMIT_SIGNUP_WINDOW" default:"1h" validate:"gt=0"`
+	RATE_LIMIT_LOGIN_LIMIT     int           `env:"RATE_LIMIT_LOGIN_LIMIT" default:"20" validate:"gt=0"`
+	RATE_LIMIT_LOGIN_WINDOW    time.Duration `env:"RATE_LIMIT_LOGIN_WINDOW" default:"15m" validate:"gt=0"`
+	IDEMPOTENCY_TTL            time.Duration `env:"IDEMPOTENCY_TTL" default:"24h" validate:"gt=0"`
+	IDEMPOTENCY_LOCK_TIMEOUT   time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT" default:"1m" validate:"gt=0"`
+	GRPC_PORT                  string        `env:"GRPC_PORT" validate:"omitempty,numeric"`
+	GRPC_API_KEY               string        `env:"GRPC_API_KEY" validate:"required_with=GRPC_PORT,omitempty,min=32" secret:"true"`
+}
+
+// NewEnv loads the environment, and the dotenv file in CONFIG_FILE when set,
+// failing when a variable is missing or malformed.
+func NewEnv() (*EnvironmentVariables, error) {
+	env := &EnvironmentVariables{}
+
+	if err := config.Load(env, os.Getenv("CONFIG_FILE")); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// Fiber trusts the client address sent in the PROXY_HEADER header, e.g.
+// X-Forwarded-For, only to the TRUSTED_PROXIES, IPs or CIDR ranges separated
+// by commas.
+func (ev *EnvironmentVariables) Fiber() (fiber.Config, error) {
+	if ev.PROXY_HEADER == "" {
+		return fiber.Config{}, nil
+	}
+
+	proxies := strings.Split(ev.TRUSTED_PROXIES, ",")
+	for i, proxy := range proxies {
+		proxies[i] = strings.TrimSpace(proxy)
+
+		_, _, err := net.ParseCIDR(proxies[i])
+		if net.ParseIP(proxies[i]) == nil && err != nil {
+			return fiber.Config{}, fmt.Errorf("TRUSTED_PROXIES has an invalid address %q", proxies[i])
+		}
+	}
+
+	return fiber.Config{
+		ProxyHeader:             ev.PROXY_HEADER,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          proxies,
+	}, nil
+}
+
+// Keyring holds the ENC_KEYS and, when ENC_KMS_PROVIDER is set, the key
+// manager that wraps the data keys under the ENC_KMS_ID key id. Its data keys
+// are reused for ENC_DATA_KEY_MAX_AGE and ENC_DATA_KEY_MAX_USES encryptions.
+func (ev *EnvironmentVariables) Keyring(ctx context.Context) (*encryption.Keyring, error) {
+	managers := []encryption.KeyManager{}
+
+	switch ev.ENC_KMS_PROVIDER {
+	case KMSProviderAWS:
+		client, err := encryption.NewKMSClient(ctx, ev.AWS_REGION, ev.AWS_ENDPOINT)
+		if err != nil {
+			return nil, err
+		}
+
+		managers = append(managers, encryption.NewKMSKeyManager(ev.ENC_KMS_ID, ev.ENC_KMS_KEY, client))
+	case KMSProviderFile:
+		manager, err := encryption.NewFileKeyManager(ev.ENC_KMS_ID, ev.ENC_KMS_MASTER_KEY_PATH)
+		if err != nil {
+			return nil, err
+		}
+
+		managers = append(managers, manager)
+	}
+
+	for i, manager := range managers {
+		managers[i] = encryption.NewCachingKeyManager(manager, ev.ENC_DATA_KEY_MAX_AGE, ev.ENC_DATA_KEY_MAX_USES)
+	}
+
+	return encryption.ParseKeyring(ev.ENC_PRIMARY_KEY, ev.ENC_KEYS, managers...)
+}
+
+// RateLimiters limits the API as a whole by IP, allowing bursts, and the
+// signup and the login, along with the unlock, by IP over sliding windows.
+func (ev *EnvironmentVariables) RateLimiters(
+	store ratelimit.Store,
+) (api *ratelimit.Limiter, signup *ratelimit.Limiter, login *ratelimit.Limiter, err error) {
+	api, err = ratelimit.NewLimiter(store, ratelimit.Policy{
+		Name:      "api",
+		Algorithm: ratelimit.TokenBucket,
+		Limit:     ev.RATE_LIMIT_API_LIMIT,
+		Window:    ev.RATE_LIMIT_API_WINDOW,
+		Key:       ratelimit.ByIP,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	signup, err = ratelimit.NewLimiter(store, ratelimit.Policy{
+		Name:      "signup",
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     ev.RATE_LIMIT_SIGNUP_LIMIT,
+		Window:    ev.RATE_LIMIT_SIGNUP_WINDOW,
+		Key:       ratelimit.ByIP,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	login, err = ratelimit.NewLimiter(store, ratelimit.Policy{
+		Name:      "login",
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     ev.RATE_LIMIT_LOGIN_LIMIT,
+		Window:    ev.RATE_LIMIT_LOGIN_WINDOW,
+		Key:       ratelimit.ByIP,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return api, signup, login, nil
+}
+
+func (ev *EnvironmentVariables) Idempotency(store idempotency.Store) (*idempotency.Idempotency, error) {
+	return idempotency.New(store, idempotency.Config{
+		TTL:         ev.IDEMPOTENCY_TTL,
+		LockTimeout: ev.IDEMPOTENCY_LOCK_TIMEOUT,
+	})
 }
 
-var Env *EnvironmentVariables
+func (ev *EnvironmentVariables) LoginPolicy() app.LoginPolicy {
+	return app.LoginPolicy{
+		DelayAfter:         ev.LOGIN_DELAY_AFTER,
+		BaseDelay:          ev.LOGIN_BASE_DELAY,
+		MaxDelay:           ev.LOGIN_MAX_DELAY,
+		MaxAccountFailures: ev.LOGIN_MAX_ACCOUNT_FAILURES,
+		MaxIPFailures:      ev.LOGIN_MAX_IP_FAILURES,
+		LockDuration:       ev.LOGIN_LOCK_DURATION,
+		Window:             ev.LOGIN_WINDOW,
+		UnlockURL:          ev.LOGIN_UNLOCK_URL,
+	}
+}
+
+// Mailer sends through SMTP_ADDRESS when MAIL_DRIVER is smtp and logs the
+// messages otherwise.
+func (ev *EnvironmentVariables) Mailer(lg logger.LoggerInterface) mail.MailerInterface {
+	if ev.MAIL_DRIVER == MailDriverSMTP {
+		return mail.NewSMTPMailer(ev.SMTP_ADDRESS, ev.MAIL_FROM, ev.SMTP_USERNAME, ev.SMTP_PASSWORD)
+	}
+
+	return mail.NewLogMailer(lg)
+}
 
-func NewEnv() *EnvironmentVariables {
-	return &EnvironmentVariables{
-		PORT:       fmt.Sprintf(":%v", os.Getenv("PORT")),
-		DB_URI:     os.Getenv("DB_URI"),
-		DB_NAME:    os.Getenv("DB_NAME"),
-		ENC_SECRET: os.Getenv("ENC_SECRET"),
+// DatabaseTimeouts are the timeouts of the repository operations.
+func (ev *EnvironmentVariables) DatabaseTimeouts() database.Timeouts {
+	return database.Timeouts{
+		database.OperationGetById:      ev.DB_TIMEOUT_GET_BY_ID,
+		database.OperationGetByEmail:   ev.DB_TIMEOUT_GET_BY_EMAIL,
+		database.OperationDeleteById:   ev.DB_TIMEOUT_DELETE_BY_ID,
+		database.OperationCreateOne:    ev.DB_TIMEOUT_CREATE_ONE,
+		database.OperationUpdateById:   ev.DB_TIMEOUT_UPDATE_BY_ID,
+		database.OperationGetPaginated: ev.DB_TIMEOUT_GET_PAGINATED,
 	}
 }
+
+func (ev *EnvironmentVariables) Address() string {
+	return ":" + ev.PORT
+}
+
+// GRPCAddress is empty unless GRPC_PORT is set, as the gRPC server is
+// optional.
+func (ev *EnvironmentVariables) GRPCAddress() string {
+	if ev.GRPC_PORT == "" {
+		return ""
+	}
+
+	return ":" + ev.GRPC_PORT
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	FileSuffix = "_FILE"
+	Redaction  = "******"
+)
+
+// Load fills target, a pointer to a struct, field by field from its `env`
+// tag. Values come from the environment, then from the optional dotenv file,
+// then from the `default` tag. A KEY_FILE variable points to a file holding
+// the value of KEY, which is how secrets are usually mounted. Once loaded,
+// the `validate` tags are checked and every failure is reported at once.
+func Load(target any, file string) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return errors.New("config target must be a pointer to a struct")
+	}
+
+	fileValues := map[string]string{}
+	if file != "" {
+		var err error
+		if fileValues, err = readDotenv(file); err != nil {
+			return err
+		}
+	}
+
+	structure := value.Elem()
+	for i := 0; i < structure.NumField(); i++ {
+		field := structure.Type().Field(i)
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		raw, ok, err := lookup(key, fileValues)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			raw, ok = field.Tag.Lookup("default")
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := set(structure.Field(i), raw); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return validate(target)
+}
+
+func lookup(key string, fileValues map[string]string) (string, bool, error) {
+	if raw, ok := os.LookupEnv(key); ok {
+		return raw, true, nil
+	}
+
+	if path, ok := os.LookupEnv(key + FileSuffix); ok {
+		return readSecret(key, path)
+	}
+
+	if raw, ok := fileValues[key]; ok {
+		return raw, true, nil
+	}
+
+	if path, ok := fileValues[key+FileSuffix]; ok {
+		return readSecret(key, path)
+	}
+
+	return "", false, nil
+}
+
+func readSecret(key string, path string) (string, bool, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", key+FileSuffix, err)
+	}
+
+	return strings.TrimSpace(string(bytes)), true, nil
+}
+
+// readDotenv reads KEY=VALUE lines, skipping blank lines and comments.
+func readDotenv(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, raw, found := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+
+		raw = strings.TrimSpace(raw)
+		if unquoted, err := strconv.Unquote(raw); err == nil {
+			raw = unquoted
+		} else if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+			raw = raw[1 : len(raw)-1]
+		}
+
+		values[strings.TrimSpace(key)] = raw
+	}
+
+	return values, scanner.Err()
+}
+
+func set(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+func validate(target any) error {
+	err := validator.New().Struct(target)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	structure := reflect.TypeOf(target).Elem()
+	messages := []string{}
+
+	for _, validationError := range validationErrors {
+		key := validationError.Field()
+		if field, ok := structure.FieldByName(validationError.StructField()); ok {
+			key = field.Tag.Get("env")
+		}
+
+		rule := validationError.Tag()
+		if validationError.Param() != "" {
+			rule = fmt.Sprintf("%s=%s", rule, validationError.Param())
+		}
+
+		messages = append(messages, fmt.Sprintf("%s failed on %s", key, rule))
+	}
+
+	return fmt.Errorf("invalid config: %s", strings.Join(messages, ", "))
+}
+
+// Redacted returns the effective config by env key, hiding the values of the
+// fields tagged with `secret:"true"`, so it can be logged at boot.
+func Redacted(target any) map[string]string {
+	structure := reflect.Indirect(reflect.ValueOf(target))
+	redacted := map[string]string{}
+
+	for i := 0; i < structure.NumField(); i++ {
+		field := structure.Type().Field(i)
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		value := fmt.Sprint(structure.Field(i).Interface())
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = Redaction
+		}
+
+		redacted[key] = value
+	}
+
+	return redacted
+}
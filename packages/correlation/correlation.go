@@ -0,0 +1,103 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+const (
+	Header = "X-Correlation-ID"
+)
+
+var validId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func WithId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the correlation id of ctx or an empty string. Fiber
+// request contexts are supported as well, through the id stored in locals.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+
+	if id, ok := ctx.Value(Header).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+func NewId() string {
+	return uuid.New().String()
+}
+
+// New returns a middleware that accepts the inbound correlation id or creates
+// one, answers it on the response and stores it in the user context handed
+// to the app services.
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(Header)
+		if !validId.MatchString(id) {
+			id = NewId()
+		}
+
+		c.Set(Header, id)
+		c.Locals(Header, id)
+		c.SetUserContext(WithId(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
+
+// Inject writes the correlation id of ctx into a message carrier, such as the
+// attributes of a published event.
+func Inject(ctx context.Context, carrier map[string]string) {
+	if id := FromContext(ctx); id != "" {
+		carrier[Header] = id
+	}
+}
+
+// Extract returns a context carrying the correlation id found in a message
+// carrier, creating a new id when there is none.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	id := carrier[Header]
+	if !validId.MatchString(id) {
+		id = NewId()
+	}
+
+	return WithId(ctx, id)
+}
+
+// Transport propagates the correlation id of the request context on outbound
+// HTTP calls.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	outbound := req.Clone(req.Context())
+	outbound.Header.Set(Header, id)
+
+	return t.Base.RoundTrip(outbound)
+}
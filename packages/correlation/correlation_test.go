@@ -0,0 +1,115 @@
+package correlation_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+func BeforeEach_TestCorrelation(t *testing.T, handler fiber.Handler) *fiber.App {
+	fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	fbr.Use(correlation.New())
+	fbr.Get("/", handler)
+	return fbr
+}
+
+func TestCorrelation_New(t *testing.T) {
+	t.Run("should pass the inbound id to the user context and the response", func(t *testing.T) {
+		var fromContext string
+		fbr := BeforeEach_TestCorrelation(t, func(c *fiber.Ctx) error {
+			fromContext = correlation.FromContext(c.UserContext())
+			return c.SendStatus(200)
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(correlation.Header, "abc-123")
+		response, err := fbr.Test(req, -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "abc-123", fromContext, "should inject the inbound id")
+		assert.Equal(t, "abc-123", response.Header.Get(correlation.Header), "should answer the inbound id")
+	})
+
+	t.Run("should create an id when the inbound one is missing or invalid", func(t *testing.T) {
+		var fromContext string
+		fbr := BeforeEach_TestCorrelation(t, func(c *fiber.Ctx) error {
+			fromContext = correlation.FromContext(c.UserContext())
+			return c.SendStatus(200)
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(correlation.Header, strings.Repeat("a", 129))
+		response, err := fbr.Test(req, -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, fromContext, 36, "should create a new id")
+		assert.Equal(t, fromContext, response.Header.Get(correlation.Header), "should answer the new id")
+	})
+
+	t.Run("should answer the id on error responses", func(t *testing.T) {
+		fbr := BeforeEach_TestCorrelation(t, func(c *fiber.Ctx) error {
+			return errors.New(exception.CodeNotFound)
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(correlation.Header, "abc-123")
+		response, err := fbr.Test(req, -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 404, response.StatusCode, "should keep the error status")
+		assert.Equal(t, "abc-123", response.Header.Get(correlation.Header), "should answer the inbound id")
+	})
+}
+
+func TestCorrelation_FromContext(t *testing.T) {
+	t.Run("should return empty string when context has no id", func(t *testing.T) {
+		assert.Equal(t, "", correlation.FromContext(context.TODO()))
+	})
+}
+
+func TestCorrelation_Carrier(t *testing.T) {
+	t.Run("should inject and extract the id of an event", func(t *testing.T) {
+		attributes := map[string]string{}
+		correlation.Inject(correlation.WithId(context.TODO(), "abc-123"), attributes)
+
+		ctx := correlation.Extract(context.TODO(), attributes)
+
+		assert.Equal(t, "abc-123", attributes[correlation.Header], "should write the id")
+		assert.Equal(t, "abc-123", correlation.FromContext(ctx), "should read the id")
+	})
+
+	t.Run("should create an id when the event has none", func(t *testing.T) {
+		ctx := correlation.Extract(context.TODO(), map[string]string{})
+
+		assert.Len(t, correlation.FromContext(ctx), 36)
+	})
+}
+
+func TestCorrelation_Transport(t *testing.T) {
+	t.Run("should send the id on outbound requests", func(t *testing.T) {
+		var received string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Get(correlation.Header)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: correlation.NewTransport(nil)}
+		ctx := correlation.WithId(context.TODO(), "abc-123")
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+
+		response, err := client.Do(req)
+
+		assert.Nil(t, err, "should not return error")
+		response.Body.Close()
+		assert.Equal(t, "abc-123", received, "should propagate the id")
+		assert.Equal(t, "", req.Header.Get(correlation.Header), "should not change the original request")
+	})
+}
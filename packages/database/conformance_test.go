@@ -0,0 +1,38 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/database/databasetest"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryCrudRepository_Conformance(t *testing.T) {
+	databasetest.RunCrudRepositoryConformance(t, func(t *testing.T) (database.CrudRepositoryInterface, string) {
+		memoryDatabase := database.NewMemoryDatabase()
+		return database.NewMemoryCrudRepository(logger.NewLogger(), memoryDatabase), MOCK_COLL_NAME
+	})
+}
+
+func TestCrudRepository_Conformance(t *testing.T) {
+	uri := os.Getenv("DB_TEST_URI")
+	if uri == "" {
+		t.Skip("DB_TEST_URI is not set, skipping MongoDB conformance")
+	}
+
+	db, err := database.NewDatabase(uri, "conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	databasetest.RunCrudRepositoryConformance(t, func(t *testing.T) (database.CrudRepositoryInterface, string) {
+		collection := "conformance_" + primitive.NewObjectID().Hex()
+		t.Cleanup(func() { db.Collection(collection).Drop(context.TODO()) })
+
+		return database.NewCrudRepository(logger.NewLogger(), db), collection
+	})
+}
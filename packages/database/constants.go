@@ -1,5 +1,8 @@
 package database
 
 const (
-	UsersCollection = "users"
+	UsersCollection           = "users"
+	RateLimitsCollection      = "rate_limits"
+	LoginAttemptsCollection   = "login_attempts"
+	IdempotencyKeysCollection = "idempotency_keys"
 )
@@ -2,7 +2,7 @@ package database
 
 import (
 	"context"
-	"errors"
+	"maps"
 	"reflect"
 	"time"
 
@@ -54,10 +54,28 @@ type CrudRepositoryInterface interface {
 type CrudRepository struct {
 	logger   logger.LoggerInterface
 	database *Database
+	timeouts Timeouts
+	observer OperationObserver
 }
 
 func NewCrudRepository(lg logger.LoggerInterface, db *Database) *CrudRepository {
-	return &CrudRepository{logger: lg, database: db}
+	return &CrudRepository{logger: lg, database: db, timeouts: Timeouts{}}
+}
+
+// WithTimeouts returns a copy of the repository whose operations take the
+// given timeouts.
+func (cr *CrudRepository) WithTimeouts(timeouts Timeouts) *CrudRepository {
+	copied := *cr
+	copied.timeouts = maps.Clone(timeouts)
+	return &copied
+}
+
+// WithObserver returns a copy of the repository reporting its operations to
+// the observer.
+func (cr *CrudRepository) WithObserver(observer OperationObserver) *CrudRepository {
+	copied := *cr
+	copied.observer = observer
+	return &copied
 }
 
 func (cr *CrudRepository) GetById(
@@ -66,16 +84,19 @@ func (cr *CrudRepository) GetById(
 	id string,
 	deleted bool,
 	structure any,
-) error {
+) (err error) {
 	coll := cr.database.Collection(collection)
 
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, span, end := cr.database.StartOperation(ctx, cr.observer, collection, OperationGetById)
+	defer func() { end(err) }()
+
+	timeout, cancel := cr.timeouts.Context(ctx, OperationGetById)
 	defer cancel()
 
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
 	filter := bson.M{"_id": objectId, "deleted_at": nil}
@@ -84,15 +105,17 @@ func (cr *CrudRepository) GetById(
 		filter = bson.M{"_id": objectId}
 	}
 
+	span.SetAttributes(FilterAttribute(filter))
+
 	err = coll.FindOne(timeout, filter).Decode(structure)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			cr.logger.WithCtx(ctx).Error(err.Error())
-			return errors.New(exception.CodeNotFound)
+			return exception.Wrap(exception.CodeNotFound, err)
 		}
 
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return ParseToDatabaseError(err)
 	}
 
 	return nil
@@ -102,27 +125,33 @@ func (cr *CrudRepository) DeleteById(
 	ctx context.Context,
 	collection string,
 	id string,
-) error {
+) (err error) {
 	coll := cr.database.Collection(collection)
 
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, span, end := cr.database.StartOperation(ctx, cr.observer, collection, OperationDeleteById)
+	defer func() { end(err) }()
+
+	timeout, cancel := cr.timeouts.Context(ctx, OperationDeleteById)
 	defer cancel()
 
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
+	filter := bson.M{"_id": objectId}
+	span.SetAttributes(FilterAttribute(filter))
+
 	_, err = coll.UpdateOne(
 		timeout,
-		bson.M{"_id": objectId},
+		filter,
 		bson.D{{Key: "$set", Value: bson.D{{Key: "deleted_at", Value: time.Now()}}}},
 	)
 
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return ParseToDatabaseError(err)
 	}
 
 	return nil
@@ -132,16 +161,19 @@ func (cr *CrudRepository) CreateOne(
 	ctx context.Context,
 	collection string,
 	structure any,
-) (string, error) {
+) (_ string, err error) {
 	coll := cr.database.Collection(collection)
 
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, _, end := cr.database.StartOperation(ctx, cr.observer, collection, OperationCreateOne)
+	defer func() { end(err) }()
+
+	timeout, cancel := cr.timeouts.Context(ctx, OperationCreateOne)
 	defer cancel()
 
 	result, err := coll.InsertOne(timeout, structure)
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return "", errors.New(exception.CodeDatabaseFailed)
+		return "", ParseToDatabaseError(err)
 	}
 	return result.InsertedID.(primitive.ObjectID).Hex(), nil
 }
@@ -152,34 +184,45 @@ func (cr *CrudRepository) UpdateById(
 	id string,
 	inputStructure any,
 	outputStructure any,
-) error {
+) (err error) {
 	coll := cr.database.Collection(collection)
 
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, span, end := cr.database.StartOperation(ctx, cr.observer, collection, OperationUpdateById)
+	defer func() { end(err) }()
+
+	timeout, cancel := cr.timeouts.Context(ctx, OperationUpdateById)
 	defer cancel()
 
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
 	document, err := ParseToDocument(inputStructure)
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
+	filter := bson.M{"_id": objectId, "deleted_at": nil}
+	span.SetAttributes(FilterAttribute(filter))
+
 	err = coll.FindOneAndUpdate(
 		timeout,
-		bson.M{"_id": objectId},
+		filter,
 		bson.D{{Key: "$set", Value: document}},
 		options.FindOneAndUpdate().SetReturnDocument(options.After),
 	).Decode(outputStructure)
 
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			cr.logger.WithCtx(ctx).Error(err.Error())
+			return exception.Wrap(exception.CodeNotFound, err)
+		}
+
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return ParseToDatabaseError(err)
 	}
 
 	return nil
@@ -194,16 +237,21 @@ func (cr *CrudRepository) GetPaginated(
 	projections map[string]int,
 	sortings map[string]int,
 	structures any,
-) error {
+) (err error) {
 	coll := cr.database.Collection(collection)
 
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, span, end := cr.database.StartOperation(ctx, cr.observer, collection, OperationGetPaginated)
+	defer func() { end(err) }()
+
+	timeout, cancel := cr.timeouts.Context(ctx, OperationGetPaginated)
 	defer cancel()
 
 	filtersBson := mapToBsonM(filters)
 	projectionBson := mapToBsonM[int](projections)
 	sortingsBson := mapToBsonM[int](sortings)
 
+	span.SetAttributes(FilterAttribute(filtersBson))
+
 	limit := int64(perPage)
 	skip := int64(perPage * (page - 1))
 
@@ -215,14 +263,14 @@ func (cr *CrudRepository) GetPaginated(
 	})
 	if err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return ParseToDatabaseError(err)
 	}
 
-	defer cursor.Close(ctx)
+	defer cursor.Close(timeout)
 
-	if err = cursor.All(ctx, structures); err != nil {
+	if err = cursor.All(timeout, structures); err != nil {
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return ParseToDatabaseError(err)
 	}
 
 	return nil
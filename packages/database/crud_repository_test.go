@@ -3,6 +3,7 @@ package database_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
@@ -131,6 +132,42 @@ func TestCrudRepository_GetById(t *testing.T) {
 		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
 	})
 
+	rootMt.Run("should return timeout error when the operation takes too long", func(nestedMt *mtest.T) {
+		mockId := primitive.NewObjectID()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger, mockDB).
+			WithTimeouts(database.Timeouts{database.OperationGetById: time.Nanosecond})
+
+		var result MockStructure
+
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, mockId.Hex(), false, &result)
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeTimeout, err.Error(), "should return timeout error")
+	})
+
+	rootMt.Run("should return timeout error when the caller deadline is exceeded", func(nestedMt *mtest.T) {
+		mockId := primitive.NewObjectID()
+
+		expiredCtx, cancel := context.WithDeadline(ctx, time.Now())
+		defer cancel()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger, mockDB)
+
+		var result MockStructure
+
+		err := crudRepository.GetById(expiredCtx, MOCK_COLL_NAME, mockId.Hex(), false, &result)
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeTimeout, err.Error(), "should return timeout error")
+	})
+
 	rootMt.Run("should return error when wrong object id is provided", func(nestedMt *mtest.T) {
 
 		mockWrongId := "something_wrong"
@@ -338,6 +375,33 @@ func TestCrudRepository_UpdateById(t *testing.T) {
 		assert.NotNil(t, err, "should return database call error")
 	})
 
+	rootMt.Run("should return not found error when no document that is not deleted matches", func(nestedMt *mtest.T) {
+		mockId := primitive.NewObjectID().Hex()
+
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse(
+			primitive.E{Key: "ok", Value: 1},
+			primitive.E{Key: "value", Value: nil},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger, mockDB)
+
+		var output MockStructure
+		err := crudRepository.UpdateById(
+			ctx,
+			MOCK_COLL_NAME,
+			mockId,
+			MockStructure{Foo: "bar", Id: mockId},
+			&output,
+		)
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return not found error")
+	})
+
 	rootMt.Run("should return nil and fill struct when call database with success", func(nestedMt *mtest.T) {
 		mockId := primitive.NewObjectID().Hex()
 
@@ -0,0 +1,257 @@
+// Package databasetest provides a conformance suite that any
+// database.CrudRepositoryInterface implementation can run against itself,
+// proving every backend behaves the same way the MongoDB one does.
+package databasetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Factory returns a repository and an empty collection for a single test.
+type Factory func(t *testing.T) (database.CrudRepositoryInterface, string)
+
+type ConformanceDocument struct {
+	Id        string     `bson:"_id,omitempty"`
+	Name      string     `bson:"name,omitempty"`
+	Position  int        `bson:"position,omitempty"`
+	Group     string     `bson:"group,omitempty"`
+	CreatedAt time.Time  `bson:"created_at,omitempty"`
+	DeletedAt *time.Time `bson:"deleted_at"`
+}
+
+type conformanceInsert struct {
+	Name      string     `bson:"name"`
+	Position  int        `bson:"position"`
+	Group     string     `bson:"group"`
+	CreatedAt time.Time  `bson:"created_at"`
+	DeletedAt *time.Time `bson:"deleted_at"`
+}
+
+var conformanceNames = []string{"alpha", "bravo", "charlie", "delta", "echo"}
+
+func seed(t *testing.T, factory Factory) (database.CrudRepositoryInterface, string, []string) {
+	repository, collection := factory(t)
+	now := time.Now().Truncate(time.Millisecond)
+
+	ids := []string{}
+	for i, name := range conformanceNames {
+		id, err := repository.CreateOne(context.TODO(), collection, &conformanceInsert{
+			Name:      name,
+			Position:  i + 1,
+			Group:     []string{"even", "odd"}[(i+1)%2],
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("failed to seed collection: %s", err.Error())
+		}
+
+		ids = append(ids, id)
+	}
+
+	return repository, collection, ids
+}
+
+func page(
+	t *testing.T,
+	repository database.CrudRepositoryInterface,
+	collection string,
+	number int,
+	perPage int,
+	filters map[string]any,
+	projections map[string]int,
+	sortings map[string]int,
+) []ConformanceDocument {
+	documents := []ConformanceDocument{}
+
+	err := repository.GetPaginated(
+		context.TODO(),
+		collection,
+		number,
+		perPage,
+		filters,
+		projections,
+		sortings,
+		&documents,
+	)
+	if err != nil {
+		t.Fatalf("failed to get page: %s", err.Error())
+	}
+
+	return documents
+}
+
+func names(documents []ConformanceDocument) []string {
+	result := []string{}
+	for _, document := range documents {
+		result = append(result, document.Name)
+	}
+	return result
+}
+
+// RunCrudRepositoryConformance runs the whole suite using factory to build a
+// fresh repository and collection for every test.
+func RunCrudRepositoryConformance(t *testing.T, factory Factory) {
+	ctx := context.TODO()
+
+	t.Run("should return not found error when the document does not exist", func(t *testing.T) {
+		repository, collection, _ := seed(t, factory)
+
+		var document ConformanceDocument
+		err := repository.GetById(ctx, collection, primitive.NewObjectID().Hex(), true, &document)
+
+		assert.NotNil(t, err, "should return error")
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return not found error")
+	})
+
+	t.Run("should return validation error when the id is invalid", func(t *testing.T) {
+		repository, collection, _ := seed(t, factory)
+
+		var document ConformanceDocument
+		getErr := repository.GetById(ctx, collection, "something_wrong", false, &document)
+		deleteErr := repository.DeleteById(ctx, collection, "something_wrong")
+		updateErr := repository.UpdateById(ctx, collection, "something_wrong", &ConformanceDocument{Name: "x"}, &document)
+
+		for _, err := range []error{getErr, deleteErr, updateErr} {
+			assert.NotNil(t, err, "should return error")
+			assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return validation error")
+		}
+	})
+
+	t.Run("should return the created document by id", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+
+		var document ConformanceDocument
+		err := repository.GetById(ctx, collection, ids[1], false, &document)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, ids[1], document.Id, "should return the expected id")
+		assert.Equal(t, "bravo", document.Name, "should return the expected document")
+		assert.Nil(t, document.DeletedAt, "should not be deleted")
+	})
+
+	t.Run("should hide soft deleted documents unless deleted ones are requested", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+
+		err := repository.DeleteById(ctx, collection, ids[0])
+		assert.Nil(t, err, "should not return error")
+
+		var hidden ConformanceDocument
+		err = repository.GetById(ctx, collection, ids[0], false, &hidden)
+		assert.NotNil(t, err, "should return error")
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should not find the deleted document")
+
+		var deleted ConformanceDocument
+		err = repository.GetById(ctx, collection, ids[0], true, &deleted)
+		assert.Nil(t, err, "should not return error")
+		assert.NotNil(t, deleted.DeletedAt, "should fill the deletion date")
+
+		visible := page(t, repository, collection, 1, 10, map[string]any{"deleted_at": nil}, nil, map[string]int{"position": 1})
+		all := page(t, repository, collection, 1, 10, map[string]any{}, nil, map[string]int{"position": 1})
+
+		assert.Equal(t, []string{"bravo", "charlie", "delta", "echo"}, names(visible), "should leave the deleted document out")
+		assert.Equal(t, 5, len(all), "should list the deleted document without the deletion filter")
+	})
+
+	t.Run("should not fail when deleting a document that does not exist", func(t *testing.T) {
+		repository, collection, _ := seed(t, factory)
+
+		err := repository.DeleteById(ctx, collection, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	t.Run("should update the document and return it after the update", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+
+		var updated ConformanceDocument
+		err := repository.UpdateById(ctx, collection, ids[2], &ConformanceDocument{Name: "changed"}, &updated)
+
+		var stored ConformanceDocument
+		repository.GetById(ctx, collection, ids[2], false, &stored)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "changed", updated.Name, "should return the updated field")
+		assert.Equal(t, 3, updated.Position, "should keep the other fields")
+		assert.Equal(t, "changed", stored.Name, "should persist the update")
+	})
+
+	t.Run("should return not found error when updating a deleted document", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+
+		err := repository.DeleteById(ctx, collection, ids[3])
+		assert.Nil(t, err, "should not return error")
+
+		var updated ConformanceDocument
+		err = repository.UpdateById(ctx, collection, ids[3], &ConformanceDocument{Name: "changed"}, &updated)
+
+		var stored ConformanceDocument
+		repository.GetById(ctx, collection, ids[3], true, &stored)
+
+		assert.NotNil(t, err, "should return error")
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return not found error")
+		assert.Equal(t, "delta", stored.Name, "should not update the deleted document")
+	})
+
+	t.Run("should paginate until the last page and return empty after it", func(t *testing.T) {
+		repository, collection, _ := seed(t, factory)
+		sortings := map[string]int{"position": 1}
+
+		first := page(t, repository, collection, 1, 2, nil, nil, sortings)
+		second := page(t, repository, collection, 2, 2, nil, nil, sortings)
+		last := page(t, repository, collection, 3, 2, nil, nil, sortings)
+		after := page(t, repository, collection, 4, 2, nil, nil, sortings)
+		whole := page(t, repository, collection, 1, 5, nil, nil, sortings)
+
+		assert.Equal(t, []string{"alpha", "bravo"}, names(first), "should return the first page")
+		assert.Equal(t, []string{"charlie", "delta"}, names(second), "should return the second page")
+		assert.Equal(t, []string{"echo"}, names(last), "should return the remaining items")
+		assert.Equal(t, 0, len(after), "should return no items after the last page")
+		assert.Equal(t, 5, len(whole), "should return every item when they fit in a page")
+	})
+
+	t.Run("should sort ascending and descending", func(t *testing.T) {
+		repository, collection, _ := seed(t, factory)
+
+		ascending := page(t, repository, collection, 1, 10, nil, nil, map[string]int{"created_at": 1})
+		descending := page(t, repository, collection, 1, 10, nil, nil, map[string]int{"name": -1})
+		byGroup := page(t, repository, collection, 1, 10, map[string]any{"group": "even"}, nil, map[string]int{"position": -1})
+
+		assert.Equal(t, conformanceNames, names(ascending), "should sort ascending")
+		assert.Equal(t, []string{"echo", "delta", "charlie", "bravo", "alpha"}, names(descending), "should sort descending")
+		assert.Equal(t, []string{"delta", "bravo"}, names(byGroup), "should sort the filtered items")
+	})
+
+	t.Run("should filter by a list of values", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+		objectIds, _ := database.ParseToDatabaseId(ids[0], ids[3])
+
+		byId := page(t, repository, collection, 1, 10, map[string]any{"_id": objectIds}, nil, map[string]int{"position": 1})
+		byName := page(t, repository, collection, 1, 10, map[string]any{"name": []string{"echo", "bravo"}}, nil, map[string]int{"position": 1})
+
+		assert.Equal(t, []string{"alpha", "delta"}, names(byId), "should filter by ids")
+		assert.Equal(t, []string{"bravo", "echo"}, names(byName), "should filter by names")
+	})
+
+	t.Run("should apply exclusion and inclusion projections", func(t *testing.T) {
+		repository, collection, ids := seed(t, factory)
+		sortings := map[string]int{"position": 1}
+
+		excluded := page(t, repository, collection, 1, 1, nil, map[string]int{"name": 0}, sortings)
+		included := page(t, repository, collection, 1, 1, nil, map[string]int{"name": 1}, sortings)
+		withoutId := page(t, repository, collection, 1, 1, nil, map[string]int{"name": 1, "_id": 0}, sortings)
+
+		assert.Equal(t, "", excluded[0].Name, "should exclude the projected field")
+		assert.Equal(t, 1, excluded[0].Position, "should keep the other fields")
+		assert.Equal(t, "alpha", included[0].Name, "should include the projected field")
+		assert.Equal(t, ids[0], included[0].Id, "should include the id by default")
+		assert.Equal(t, 0, included[0].Position, "should leave the other fields out")
+		assert.Equal(t, "", withoutId[0].Id, "should exclude the id when requested")
+	})
+}
@@ -0,0 +1,8 @@
+package database
+
+import "errors"
+
+var (
+	errMemoryInvalidId    = errors.New("memory database: _id must be an object id")
+	errMemoryDuplicateKey = errors.New("memory database: duplicate key")
+)
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OperationObserver is told about every finished repository operation, e.g.
+// to record its latency and error metrics.
+type OperationObserver interface {
+	ObserveOperation(collection string, operation string, duration time.Duration, err error)
+}
+
+// StartOperation starts the span of a repository operation. The returned
+// function ends the span and reports the operation to the observer, when
+// there is one, with driver errors parsed by ParseToDatabaseError. Filters
+// are recorded through FilterAttribute only, which hides their values.
+func (db *Database) StartOperation(
+	ctx context.Context,
+	observer OperationObserver,
+	collection string,
+	operation Operation,
+) (context.Context, trace.Span, func(err error)) {
+	started := time.Now()
+
+	ctx, span := tracing.Start(
+		ctx,
+		fmt.Sprintf("%s %s", collection, operation),
+		semconv.DBSystemMongoDB,
+		semconv.DBName(db.Name()),
+		semconv.DBMongoDBCollection(collection),
+		semconv.DBOperation(string(operation)),
+	)
+
+	return ctx, span, func(err error) {
+		tracing.End(span, err)
+
+		if observer != nil {
+			observer.ObserveOperation(collection, string(operation), time.Since(started), observedError(err))
+		}
+	}
+}
+
+func FilterAttribute(filter any) attribute.KeyValue {
+	return attribute.String("db.filter", tracing.FilterShape(filter))
+}
+
+// observedError keeps typed errors and parses the others, e.g. a driver error
+// returned as it is, into their database exception.
+func observedError(err error) error {
+	var typed *exception.Error
+	if err == nil || errors.As(err, &typed) {
+		return err
+	}
+
+	return ParseToDatabaseError(err)
+}
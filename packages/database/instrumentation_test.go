@@ -0,0 +1,100 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type observedOperation struct {
+	collection string
+	operation  string
+	code       string
+}
+
+type operationObserverStub struct {
+	operations []observedOperation
+}
+
+func (oos *operationObserverStub) ObserveOperation(
+	collection string,
+	operation string,
+	duration time.Duration,
+	err error,
+) {
+	code := ""
+	if err != nil {
+		code = err.Error()
+	}
+
+	oos.operations = append(oos.operations, observedOperation{collection, operation, code})
+}
+
+func TestCrudRepository_Tracing(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should record a span per operation without filter values", func(nestedMt *mtest.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		defer otel.SetTracerProvider(previous)
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_NS, mtest.FirstBatch))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger, mockDB)
+
+		mockId := primitive.NewObjectID().Hex()
+		var result MockStructure
+
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, mockId, false, &result)
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return not found")
+
+		span := recorder.Ended()[0]
+		attributes := map[string]string{}
+		for _, attr := range span.Attributes() {
+			attributes[string(attr.Key)] = attr.Value.Emit()
+		}
+
+		assert.Equal(t, "users get_by_id", span.Name(), "should name the span after the operation")
+		assert.Equal(t, MOCK_COLL_NAME, attributes["db.mongodb.collection"])
+		assert.Equal(t, MOCK_DB_NAME, attributes["db.name"])
+		assert.Equal(t, `{"_id":"?","deleted_at":"?"}`, attributes["db.filter"], "should hide filter values")
+		assert.NotContains(t, attributes["db.filter"], mockId, "should not record the id")
+		assert.Equal(t, codes.Error, span.Status().Code, "should record the error")
+	})
+
+	rootMt.Run("should report every operation to the observer", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		nestedMt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "failed"}))
+		defer nestedMt.ClearMockResponses()
+
+		observer := &operationObserverStub{}
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger, mockDB).WithObserver(observer)
+
+		mockId := primitive.NewObjectID().Hex()
+
+		crudRepository.DeleteById(ctx, MOCK_COLL_NAME, mockId)
+		crudRepository.DeleteById(ctx, MOCK_COLL_NAME, mockId)
+
+		assert.Equal(t, []observedOperation{
+			{MOCK_COLL_NAME, string(database.OperationDeleteById), ""},
+			{MOCK_COLL_NAME, string(database.OperationDeleteById), exception.CodeDatabaseFailed},
+		}, observer.operations)
+	})
+}
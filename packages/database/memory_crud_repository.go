@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryCrudRepository struct {
+	logger   logger.LoggerInterface
+	database *MemoryDatabase
+}
+
+func NewMemoryCrudRepository(lg logger.LoggerInterface, db *MemoryDatabase) *MemoryCrudRepository {
+	return &MemoryCrudRepository{logger: lg, database: db}
+}
+
+func (mr *MemoryCrudRepository) GetById(
+	ctx context.Context,
+	collection string,
+	id string,
+	deleted bool,
+	structure any,
+) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	filter := map[string]any{"_id": objectId, "deleted_at": nil}
+
+	if deleted {
+		filter = map[string]any{"_id": objectId}
+	}
+
+	found, err := mr.database.FindOne(collection, filter, structure)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	if !found {
+		return exception.New(exception.CodeNotFound)
+	}
+
+	return nil
+}
+
+func (mr *MemoryCrudRepository) DeleteById(
+	ctx context.Context,
+	collection string,
+	id string,
+) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	mr.database.Update(
+		collection,
+		map[string]any{"_id": objectId},
+		bson.M{"deleted_at": time.Now()},
+	)
+
+	return nil
+}
+
+func (mr *MemoryCrudRepository) CreateOne(
+	ctx context.Context,
+	collection string,
+	structure any,
+) (string, error) {
+	id, err := mr.database.Insert(collection, structure)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return "", exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	return id.Hex(), nil
+}
+
+func (mr *MemoryCrudRepository) UpdateById(
+	ctx context.Context,
+	collection string,
+	id string,
+	inputStructure any,
+	outputStructure any,
+) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	fields, err := toMemoryDocument(inputStructure)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	document, found, err := mr.database.Update(collection, map[string]any{"_id": objectId, "deleted_at": nil}, fields)
+	if err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	if !found {
+		return exception.New(exception.CodeNotFound)
+	}
+
+	if err := DecodeMemoryDocument(document, outputStructure); err != nil {
+		mr.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	return nil
+}
+
+func (mr *MemoryCrudRepository) GetPaginated(
+	ctx context.Context,
+	collection string,
+	page int,
+	perPage int,
+	filters map[string]any,
+	projections map[string]int,
+	sortings map[string]int,
+	structures any,
+) error {
+	rv := reflect.ValueOf(structures)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		mr.logger.WithCtx(ctx).Error("structures must be a pointer to a slice")
+		return exception.New(exception.CodeDatabaseFailed)
+	}
+
+	documents := mr.database.Find(
+		collection,
+		filters,
+		projections,
+		sortings,
+		perPage*(page-1),
+		perPage,
+	)
+
+	items := reflect.MakeSlice(rv.Elem().Type(), 0, len(documents))
+	for _, document := range documents {
+		item := reflect.New(rv.Elem().Type().Elem())
+
+		if err := DecodeMemoryDocument(document, item.Interface()); err != nil {
+			mr.logger.WithCtx(ctx).Error(err.Error())
+			return exception.Wrap(exception.CodeDatabaseFailed, err)
+		}
+
+		items = reflect.Append(items, item.Elem())
+	}
+
+	rv.Elem().Set(items)
+
+	return nil
+}
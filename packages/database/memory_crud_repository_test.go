@@ -0,0 +1,421 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockMemoryStructure struct {
+	Id        string     `bson:"_id,omitempty"`
+	Foo       string     `bson:"foo,omitempty"`
+	Rank      int        `bson:"rank,omitempty"`
+	Active    bool       `bson:"active,omitempty"`
+	CreatedAt time.Time  `bson:"created_at,omitempty"`
+	DeletedAt *time.Time `bson:"deleted_at"`
+}
+
+type MockMemoryInsert struct {
+	Foo       string     `bson:"foo"`
+	Rank      int        `bson:"rank"`
+	Active    bool       `bson:"active"`
+	CreatedAt time.Time  `bson:"created_at"`
+	DeletedAt *time.Time `bson:"deleted_at"`
+}
+
+func BeforeEach_TestMemoryCrudRepository(t *testing.T) (*database.MemoryCrudRepository, []string) {
+	crudRepository := database.NewMemoryCrudRepository(logger.NewLogger(), database.NewMemoryDatabase())
+	now := time.Now()
+
+	ids := []string{}
+	for i, foo := range []string{"bar", "buzz", "fizz"} {
+		id, err := crudRepository.CreateOne(context.TODO(), MOCK_COLL_NAME, MockMemoryInsert{
+			Foo:       foo,
+			Rank:      i + 1,
+			Active:    i%2 == 0,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return crudRepository, ids
+}
+
+func TestMemoryCrudRepository_GetById(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should return the document when it exists", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		var result MockMemoryStructure
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, ids[0], false, &result)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, ids[0], result.Id, "should return the expected id")
+		assert.Equal(t, "bar", result.Foo, "should return the expected object by id")
+	})
+
+	t.Run("should hide soft deleted documents unless deleted is requested", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.DeleteById(ctx, MOCK_COLL_NAME, ids[0])
+		assert.Nil(t, err, "should not return error")
+
+		var hidden MockMemoryStructure
+		err = crudRepository.GetById(ctx, MOCK_COLL_NAME, ids[0], false, &hidden)
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should not find deleted document")
+
+		var deleted MockMemoryStructure
+		err = crudRepository.GetById(ctx, MOCK_COLL_NAME, ids[0], true, &deleted)
+		assert.Nil(t, err, "should not return error")
+		assert.NotNil(t, deleted.DeletedAt, "should fill the deletion date")
+	})
+
+	t.Run("should return error when no document is found", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		var result MockMemoryStructure
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, primitive.NewObjectID().Hex(), false, &result)
+
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return the expected error")
+	})
+
+	t.Run("should return error when wrong object id is provided", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		var result MockMemoryStructure
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, "something_wrong", false, &result)
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return object id error")
+	})
+
+	t.Run("should return error when failed to decode the document", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.GetById(ctx, MOCK_COLL_NAME, ids[0], false, "something_wrong")
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return decode error")
+	})
+}
+
+func TestMemoryCrudRepository_DeleteById(t *testing.T) {
+	t.Run("should return error when wrong object id is provided", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.DeleteById(context.TODO(), MOCK_COLL_NAME, "something_wrong")
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return object id error")
+	})
+}
+
+func TestMemoryCrudRepository_CreateOne(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should keep the provided id", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+		mockId := primitive.NewObjectID()
+
+		id, err := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"_id": mockId})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, mockId.Hex(), id, "should return the expected id")
+	})
+
+	t.Run("should return error when the id already exists", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+		mockId, _ := primitive.ObjectIDFromHex(ids[0])
+
+		_, err := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"_id": mockId})
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return duplicate error")
+	})
+
+	t.Run("should return duplicate key error when a unique field is taken", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		memoryDatabase.EnsureUnique(MOCK_COLL_NAME, "foo")
+		crudRepository := database.NewMemoryCrudRepository(logger.NewLogger(), memoryDatabase)
+
+		_, err := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"foo": "bar"})
+		assert.Nil(t, err, "should not return error")
+
+		_, err = crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"foo": "bar"})
+		assert.True(t, database.IsDuplicateKeyError(err), "should return duplicate key error")
+
+		_, err = crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"rank": 1})
+		assert.Nil(t, err, "should leave out documents without the field")
+	})
+
+	t.Run("should return error when the id is not an object id", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		_, err := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"_id": "something_wrong"})
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return id error")
+	})
+
+	t.Run("should return error when structure is not a document", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		_, err := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, "something_wrong")
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return marshal error")
+	})
+}
+
+func TestMemoryCrudRepository_UpdateById(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should set the fields and return the updated document", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		var output MockMemoryStructure
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, ids[1], MockMemoryStructure{Foo: "updated"}, &output)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "updated", output.Foo, "should return the updated field")
+		assert.Equal(t, 2, output.Rank, "should keep the other fields")
+	})
+
+	t.Run("should return duplicate key error and keep the document when a unique field is taken", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		memoryDatabase.EnsureUnique(MOCK_COLL_NAME, "foo")
+		crudRepository := database.NewMemoryCrudRepository(logger.NewLogger(), memoryDatabase)
+
+		crudRepository.CreateOne(ctx, MOCK_COLL_NAME, MockMemoryInsert{Foo: "bar", Rank: 1})
+		id, _ := crudRepository.CreateOne(ctx, MOCK_COLL_NAME, MockMemoryInsert{Foo: "buzz", Rank: 2})
+
+		var output MockMemoryStructure
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, id, MockMemoryStructure{Foo: "bar", Rank: 3}, &output)
+		assert.True(t, database.IsDuplicateKeyError(err), "should return duplicate key error")
+
+		err = crudRepository.GetById(ctx, MOCK_COLL_NAME, id, false, &output)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "buzz", output.Foo, "should keep the unique field")
+		assert.Equal(t, 2, output.Rank, "should keep the other fields")
+
+		err = crudRepository.UpdateById(ctx, MOCK_COLL_NAME, id, MockMemoryStructure{Foo: "buzz", Rank: 3}, &output)
+		assert.Nil(t, err, "should let the document keep its own value")
+	})
+
+	t.Run("should return error when wrong object id is provided", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		var output MockMemoryStructure
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, "something_wrong", MockMemoryStructure{}, &output)
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return object id error")
+	})
+
+	t.Run("should return error when failed to parse struct to document", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, ids[0], "something_wrong", "something_wrong")
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return parse error")
+	})
+
+	t.Run("should return not found error when no document is found", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		var output MockMemoryStructure
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, primitive.NewObjectID().Hex(), MockMemoryStructure{}, &output)
+
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return not found error")
+	})
+
+	t.Run("should return error when failed to decode the document", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.UpdateById(ctx, MOCK_COLL_NAME, ids[0], MockMemoryStructure{}, "something_wrong")
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return decode error")
+	})
+}
+
+func TestMemoryCrudRepository_GetPaginated(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should filter by a list of values", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+		objectIds, _ := database.ParseToDatabaseId(ids[0], ids[2])
+
+		structures := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(
+			ctx,
+			MOCK_COLL_NAME,
+			1,
+			10,
+			map[string]any{"_id": objectIds, "deleted_at": nil},
+			map[string]int{},
+			map[string]int{"rank": 1},
+			&structures,
+		)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 2, len(structures), "should return the expected number of items")
+		assert.Equal(t, "bar", structures[0].Foo, "should return the expected first item")
+		assert.Equal(t, "fizz", structures[1].Foo, "should return the expected last item")
+	})
+
+	t.Run("should filter by a single value", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		structures := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(
+			ctx,
+			MOCK_COLL_NAME,
+			1,
+			10,
+			map[string]any{"rank": 2},
+			nil,
+			nil,
+			&structures,
+		)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 1, len(structures), "should return the expected number of items")
+		assert.Equal(t, "buzz", structures[0].Foo, "should return the expected item")
+	})
+
+	t.Run("should leave out soft deleted documents when filtering by deletion", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+		crudRepository.DeleteById(ctx, MOCK_COLL_NAME, ids[1])
+
+		structures := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(
+			ctx,
+			MOCK_COLL_NAME,
+			1,
+			10,
+			map[string]any{"deleted_at": nil},
+			nil,
+			nil,
+			&structures,
+		)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 2, len(structures), "should return only not deleted items")
+	})
+
+	t.Run("should sort descending and paginate", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		firstPage := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 2, nil, nil, map[string]int{"created_at": -1}, &firstPage)
+		assert.Nil(t, err, "should not return error")
+
+		secondPage := []MockMemoryStructure{}
+		err = crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 2, 2, nil, nil, map[string]int{"created_at": -1}, &secondPage)
+		assert.Nil(t, err, "should not return error")
+
+		outOfRange := []MockMemoryStructure{}
+		err = crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 3, 2, nil, nil, map[string]int{"created_at": -1}, &outOfRange)
+		assert.Nil(t, err, "should not return error")
+
+		assert.Equal(t, []string{"fizz", "buzz"}, []string{firstPage[0].Foo, firstPage[1].Foo}, "should return the newest first")
+		assert.Equal(t, 1, len(secondPage), "should return the remaining items")
+		assert.Equal(t, "bar", secondPage[0].Foo, "should return the oldest last")
+		assert.Equal(t, 0, len(outOfRange), "should return no items after the last page")
+	})
+
+	t.Run("should sort by string, boolean and object id values", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		byFoo := []MockMemoryStructure{}
+		crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, map[string]int{"foo": -1}, &byFoo)
+
+		byActive := []MockMemoryStructure{}
+		crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, map[string]int{"active": 1, "rank": 1}, &byActive)
+
+		byId := []MockMemoryStructure{}
+		crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, map[string]int{"_id": -1}, &byId)
+
+		assert.Equal(t, "fizz", byFoo[0].Foo, "should sort strings")
+		assert.Equal(t, "buzz", byActive[0].Foo, "should sort booleans with false first")
+		assert.Equal(t, "bar", byActive[1].Foo, "should break ties with the next key")
+		assert.Equal(t, ids[2], byId[0].Id, "should sort object ids")
+	})
+
+	t.Run("should sort missing values first", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+		crudRepository.CreateOne(ctx, MOCK_COLL_NAME, map[string]any{"foo": "nameless", "rank": 1.5})
+
+		byDate := []map[string]any{}
+		crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, map[string]int{"created_at": 1}, &byDate)
+
+		byRank := []map[string]any{}
+		crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, map[string]int{"rank": 1}, &byRank)
+
+		assert.Equal(t, "nameless", byDate[0]["foo"], "should return the document without date first")
+		assert.Equal(t, "nameless", byRank[1]["foo"], "should compare numbers of different types")
+	})
+
+	t.Run("should apply exclusion projections", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		structures := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, map[string]int{"foo": 0}, nil, &structures)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "", structures[0].Foo, "should exclude the projected field")
+		assert.Equal(t, 1, structures[0].Rank, "should keep the other fields")
+	})
+
+	t.Run("should apply inclusion projections", func(t *testing.T) {
+		crudRepository, ids := BeforeEach_TestMemoryCrudRepository(t)
+
+		structures := []MockMemoryStructure{}
+		err := crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, map[string]int{"foo": 1}, nil, &structures)
+		assert.Nil(t, err, "should not return error")
+
+		withoutId := []MockMemoryStructure{}
+		err = crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, map[string]int{"foo": 1, "_id": 0}, nil, &withoutId)
+		assert.Nil(t, err, "should not return error")
+
+		assert.Equal(t, "bar", structures[0].Foo, "should include the projected field")
+		assert.Equal(t, ids[0], structures[0].Id, "should include the id")
+		assert.Equal(t, 0, structures[0].Rank, "should exclude the other fields")
+		assert.Equal(t, "", withoutId[0].Id, "should exclude the id when requested")
+	})
+
+	t.Run("should return error when failed to fill the array", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		err := crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, nil, "something_wrong")
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return fill error")
+	})
+
+	t.Run("should return error when failed to decode an item", func(t *testing.T) {
+		crudRepository, _ := BeforeEach_TestMemoryCrudRepository(t)
+
+		structures := []string{}
+		err := crudRepository.GetPaginated(ctx, MOCK_COLL_NAME, 1, 10, nil, nil, nil, &structures)
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return decode error")
+	})
+}
+
+func TestMemoryDatabase_WithTransaction(t *testing.T) {
+	t.Run("should run the function with the same context", func(t *testing.T) {
+		ctx := context.TODO()
+		memoryDatabase := database.NewMemoryDatabase()
+
+		err := memoryDatabase.WithTransaction(ctx, func(txCtx context.Context) error {
+			assert.Equal(t, ctx, txCtx, "should pass the caller context")
+			return nil
+		})
+
+		assert.Nil(t, err, "should not return error")
+	})
+}
@@ -0,0 +1,380 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryDatabase keeps collections of documents in memory. It backs the
+// in-memory repositories used by tests and local development and mimics the
+// MongoDB semantics the repositories rely on.
+type MemoryDatabase struct {
+	mutex       sync.RWMutex
+	collections map[string][]bson.M
+	uniques     map[string][]string
+}
+
+func NewMemoryDatabase() *MemoryDatabase {
+	return &MemoryDatabase{collections: make(map[string][]bson.M), uniques: make(map[string][]string)}
+}
+
+// EnsureUnique declares a unique index on a field of the collection, enforced
+// by Insert and Update. Like the partial unique indexes the repositories
+// create in MongoDB, documents without the field, or with it empty, are left
+// out.
+func (md *MemoryDatabase) EnsureUnique(collection string, field string) {
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+
+	if !slices.Contains(md.uniques[collection], field) {
+		md.uniques[collection] = append(md.uniques[collection], field)
+	}
+}
+
+// WithTransaction has no isolation in memory, it behaves like a standalone
+// MongoDB deployment and just runs fn.
+func (md *MemoryDatabase) WithTransaction(
+	ctx context.Context,
+	fn func(txCtx context.Context) error,
+) error {
+	return fn(ctx)
+}
+
+// Insert stores a copy of the document, generating its _id when missing.
+func (md *MemoryDatabase) Insert(collection string, structure any) (primitive.ObjectID, error) {
+	document, err := toMemoryDocument(structure)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+
+	if id, ok := document["_id"]; !ok || id == nil {
+		document["_id"] = primitive.NewObjectID()
+	}
+
+	id, ok := document["_id"].(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errMemoryInvalidId
+	}
+
+	for _, existent := range md.collections[collection] {
+		if existent["_id"] == id {
+			return primitive.NilObjectID, errMemoryDuplicateKey
+		}
+	}
+
+	if md.breaksUnique(collection, document) {
+		return primitive.NilObjectID, errMemoryDuplicateKey
+	}
+
+	md.collections[collection] = append(md.collections[collection], document)
+
+	return id, nil
+}
+
+// Update sets the given fields in the first document matching the filter and
+// returns a copy of it as it is after the update. The document is left as it
+// was when the update breaks a unique index.
+func (md *MemoryDatabase) Update(collection string, filter map[string]any, fields bson.M) (bson.M, bool, error) {
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+
+	for _, document := range md.collections[collection] {
+		if matchesMemoryFilter(document, filter) {
+			updated := copyMemoryDocument(document)
+			for key, value := range fields {
+				updated[key] = normalizeMemoryValue(value)
+			}
+
+			if md.breaksUnique(collection, updated) {
+				return nil, true, errMemoryDuplicateKey
+			}
+
+			for key := range fields {
+				document[key] = updated[key]
+			}
+
+			return copyMemoryDocument(document), true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// breaksUnique tells whether another document of the collection has the same
+// value in one of its unique fields.
+func (md *MemoryDatabase) breaksUnique(collection string, document bson.M) bool {
+	for _, field := range md.uniques[collection] {
+		value := document[field]
+		if value == nil || value == "" {
+			continue
+		}
+
+		for _, existent := range md.collections[collection] {
+			if existent["_id"] != document["_id"] && reflect.DeepEqual(existent[field], value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Find returns copies of the documents matching the filter, sorted, paginated
+// and projected the same way a MongoDB find would do.
+func (md *MemoryDatabase) Find(
+	collection string,
+	filter map[string]any,
+	projections map[string]int,
+	sortings map[string]int,
+	skip int,
+	limit int,
+) []bson.M {
+	md.mutex.RLock()
+	defer md.mutex.RUnlock()
+
+	documents := []bson.M{}
+	for _, document := range md.collections[collection] {
+		if matchesMemoryFilter(document, filter) {
+			documents = append(documents, document)
+		}
+	}
+
+	sortMemoryDocuments(documents, sortings)
+
+	skip = max(0, min(skip, len(documents)))
+	documents = documents[skip:]
+
+	if limit > 0 && limit < len(documents) {
+		documents = documents[:limit]
+	}
+
+	result := make([]bson.M, 0, len(documents))
+	for _, document := range documents {
+		result = append(result, projectMemoryDocument(document, projections))
+	}
+
+	return result
+}
+
+// FindOne decodes the first document matching the filter into structure and
+// reports whether one was found.
+func (md *MemoryDatabase) FindOne(collection string, filter map[string]any, structure any) (bool, error) {
+	documents := md.Find(collection, filter, nil, nil, 0, 1)
+	if len(documents) == 0 {
+		return false, nil
+	}
+
+	return true, DecodeMemoryDocument(documents[0], structure)
+}
+
+func DecodeMemoryDocument(document bson.M, structure any) error {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(data, structure)
+}
+
+func toMemoryDocument(structure any) (bson.M, error) {
+	data, err := bson.Marshal(structure)
+	if err != nil {
+		return nil, err
+	}
+
+	var document bson.M
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// normalizeMemoryValue converts a Go value to the type it would have after a
+// round trip through the database, so it can be compared with stored values.
+func normalizeMemoryValue(value any) any {
+	document, err := toMemoryDocument(bson.M{"value": value})
+	if err != nil {
+		return value
+	}
+
+	return document["value"]
+}
+
+func matchesMemoryFilter(document bson.M, filter map[string]any) bool {
+	for key, expected := range filter {
+		actual := document[key]
+
+		if expected == nil {
+			if actual != nil {
+				return false
+			}
+			continue
+		}
+
+		rv := reflect.ValueOf(expected)
+		if rv.Kind() == reflect.Slice {
+			found := false
+			for i := 0; i < rv.Len(); i++ {
+				if reflect.DeepEqual(actual, normalizeMemoryValue(rv.Index(i).Interface())) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return false
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, normalizeMemoryValue(expected)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortMemoryDocuments(documents []bson.M, sortings map[string]int) {
+	keys := make([]string, 0, len(sortings))
+	for key := range sortings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		for _, key := range keys {
+			comparison := compareMemoryValues(documents[i][key], documents[j][key])
+			if comparison == 0 {
+				continue
+			}
+
+			if sortings[key] < 0 {
+				return comparison > 0
+			}
+			return comparison < 0
+		}
+
+		return false
+	})
+}
+
+// compareMemoryValues follows the MongoDB comparison order for the types the
+// repositories store: null, numbers, strings, object ids, booleans and dates.
+func compareMemoryValues(a any, b any) int {
+	rankA, rankB := memoryTypeRank(a), memoryTypeRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch va := a.(type) {
+	case string:
+		return cmp.Compare(va, b.(string))
+	case primitive.ObjectID:
+		return cmp.Compare(va.Hex(), b.(primitive.ObjectID).Hex())
+	case bool:
+		return cmp.Compare(boolToInt(va), boolToInt(b.(bool)))
+	case primitive.DateTime:
+		return cmp.Compare(va, b.(primitive.DateTime))
+	}
+
+	if rankA == memoryRankNumber {
+		return cmp.Compare(toFloat(a), toFloat(b))
+	}
+
+	return 0
+}
+
+const (
+	memoryRankNull = iota
+	memoryRankNumber
+	memoryRankString
+	memoryRankOther
+	memoryRankObjectId
+	memoryRankBool
+	memoryRankDate
+)
+
+func memoryTypeRank(value any) int {
+	switch value.(type) {
+	case nil:
+		return memoryRankNull
+	case int32, int64, float64:
+		return memoryRankNumber
+	case string:
+		return memoryRankString
+	case primitive.ObjectID:
+		return memoryRankObjectId
+	case bool:
+		return memoryRankBool
+	case primitive.DateTime:
+		return memoryRankDate
+	}
+
+	return memoryRankOther
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return value.(float64)
+}
+
+// projectMemoryDocument applies top level projections. Like MongoDB, any
+// included field makes it an inclusion projection where _id is kept unless
+// explicitly excluded.
+func projectMemoryDocument(document bson.M, projections map[string]int) bson.M {
+	inclusion := false
+	for key, value := range projections {
+		if key != "_id" && value != 0 {
+			inclusion = true
+		}
+	}
+
+	projected := bson.M{}
+	for key, value := range document {
+		projection, ok := projections[key]
+
+		switch {
+		case inclusion && key == "_id" && (!ok || projection != 0):
+			projected[key] = value
+		case inclusion && ok && projection != 0:
+			projected[key] = value
+		case !inclusion && (!ok || projection != 0):
+			projected[key] = value
+		}
+	}
+
+	return copyMemoryDocument(projected)
+}
+
+func copyMemoryDocument(document bson.M) bson.M {
+	copied, err := toMemoryDocument(document)
+	if err != nil {
+		return document
+	}
+
+	return copied
+}
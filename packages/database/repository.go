@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+)
+
+type RepositoryInterface[T any] interface {
+	GetById(ctx context.Context, id string, deleted bool) (*T, error)
+	Find(ctx context.Context, input *FindInput) (*PaginatedSlice[T], error)
+	Create(ctx context.Context, document *T) (string, error)
+	Update(ctx context.Context, id string, changes *T) (*T, error)
+	SoftDelete(ctx context.Context, id string) error
+}
+
+// Repository is a CrudRepository bound to a single collection and document
+// type, so callers no longer pass the collection and output structure around.
+type Repository[T any] struct {
+	crudRepository CrudRepositoryInterface
+	collection     string
+}
+
+func NewRepository[T any](cr CrudRepositoryInterface, collection string) *Repository[T] {
+	return &Repository[T]{crudRepository: cr, collection: collection}
+}
+
+type FindInput struct {
+	Page        int
+	PerPage     int
+	Filters     map[string]any
+	Projections map[string]int
+	Sortings    map[string]int
+	Deleted     bool
+}
+
+func (r *Repository[T]) GetById(ctx context.Context, id string, deleted bool) (*T, error) {
+	var document T
+
+	err := r.crudRepository.GetById(ctx, r.collection, id, deleted, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// Find answers EVALIDATION without an input, since it can not tell the page.
+func (r *Repository[T]) Find(ctx context.Context, input *FindInput) (*PaginatedSlice[T], error) {
+	if input == nil {
+		return nil, exception.New(exception.CodeValidationFailed)
+	}
+
+	filters := make(map[string]any, len(input.Filters)+1)
+	for k, v := range input.Filters {
+		filters[k] = v
+	}
+
+	if !input.Deleted {
+		filters["deleted_at"] = nil
+	}
+
+	documents := []T{}
+
+	err := r.crudRepository.GetPaginated(
+		ctx,
+		r.collection,
+		input.Page,
+		input.PerPage,
+		filters,
+		input.Projections,
+		input.Sortings,
+		&documents,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPaginatedSlice[T](input.Page, input.PerPage, &documents), nil
+}
+
+func (r *Repository[T]) Create(ctx context.Context, document *T) (string, error) {
+	return r.crudRepository.CreateOne(ctx, r.collection, document)
+}
+
+// Update sets the fields of changes and returns the document as it is after
+// the update, so T should tag its fields with omitempty for the empty ones to
+// be kept. Deleted documents are not updated and answer ENOTFOUND, and nil
+// changes answer EVALIDATION.
+func (r *Repository[T]) Update(ctx context.Context, id string, changes *T) (*T, error) {
+	if changes == nil {
+		return nil, exception.New(exception.CodeValidationFailed)
+	}
+
+	var document T
+
+	err := r.crudRepository.UpdateById(ctx, r.collection, id, changes, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+func (r *Repository[T]) SoftDelete(ctx context.Context, id string) error {
+	return r.crudRepository.DeleteById(ctx, r.collection, id)
+}
@@ -0,0 +1,261 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func BeforeEach_TestRepository(mt *mtest.T) *database.Repository[MockStructure] {
+	mockDB := &database.Database{Database: mt.Client.Database(MOCK_DB_NAME)}
+	crudRepository := database.NewCrudRepository(logger.NewLogger(), mockDB)
+
+	return database.NewRepository[MockStructure](crudRepository, MOCK_COLL_NAME)
+}
+
+func TestRepository_GetById(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return the typed document when call database with success", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+		mockId := primitive.NewObjectID()
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			1,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: mockId.Hex()},
+				{Key: "foo", Value: "bar"},
+			},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		result, err := repository.GetById(ctx, mockId.Hex(), false)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "bar", result.Foo, "should return the expected object by id")
+	})
+
+	rootMt.Run("should return error when no document is found", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_NS, mtest.FirstBatch))
+		defer nestedMt.ClearMockResponses()
+
+		result, err := repository.GetById(ctx, primitive.NewObjectID().Hex(), false)
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Nil(t, result, "should not return a document")
+		assert.Equal(t, exception.CodeNotFound, err.Error(), "should return the expected error")
+	})
+}
+
+func TestRepository_Find(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return a page scoped to not deleted documents", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID().Hex()},
+				{Key: "foo", Value: "bar"},
+			},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		filters := map[string]any{"foo": "bar"}
+
+		result, err := repository.Find(ctx, &database.FindInput{
+			Page:    1,
+			PerPage: 10,
+			Filters: filters,
+		})
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+		scoped := filter.Lookup("deleted_at").Type == bson.TypeNull
+
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, scoped, "should filter out deleted documents")
+		assert.Equal(t, 1, len(filters), "should not change the input filters")
+		assert.Equal(t, 1, result.Page, "should return the expected page")
+		assert.Equal(t, 1, len(*result.Items), "should return the expected number of items")
+	})
+
+	rootMt.Run("should not scope the page when deleted documents are requested", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_NS, mtest.FirstBatch))
+		defer nestedMt.ClearMockResponses()
+
+		_, err := repository.Find(ctx, &database.FindInput{Page: 1, PerPage: 10, Deleted: true})
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+		_, lookupErr := filter.LookupErr("deleted_at")
+
+		assert.NotNil(t, lookupErr, "should not filter by deletion")
+	})
+
+	rootMt.Run("should return validation error without input", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		result, err := repository.Find(ctx, nil)
+
+		assert.Nil(t, result, "should not return a page")
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return validation error")
+	})
+
+	rootMt.Run("should return error when failed to call database", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		defer nestedMt.ClearMockResponses()
+
+		result, err := repository.Find(ctx, &database.FindInput{Page: 1, PerPage: 10})
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Nil(t, result, "should not return a page")
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
+	})
+}
+
+func TestRepository_Create(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return the inserted id when created with success", func(nestedMt *mtest.T) {
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		crudRepository := database.NewCrudRepository(logger.NewLogger(), mockDB)
+
+		type MockStructureB struct {
+			Id  primitive.ObjectID `bson:"_id"`
+			Foo string             `bson:"foo"`
+		}
+
+		repository := database.NewRepository[MockStructureB](crudRepository, MOCK_COLL_NAME)
+		mockId := primitive.NewObjectID()
+
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		id, err := repository.Create(ctx, &MockStructureB{Id: mockId, Foo: "bar"})
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, mockId.Hex(), id, "should return the expected id")
+	})
+}
+
+func TestRepository_Update(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	type MockChanges struct {
+		Id   string `bson:"_id,omitempty"`
+		Foo  string `bson:"foo,omitempty"`
+		Fizz string `bson:"fizz,omitempty"`
+	}
+
+	BeforeEach_TestRepository_Update := func(mt *mtest.T) *database.Repository[MockChanges] {
+		mockDB := &database.Database{Database: mt.Client.Database(MOCK_DB_NAME)}
+		return database.NewRepository[MockChanges](database.NewCrudRepository(logger.NewLogger(), mockDB), MOCK_COLL_NAME)
+	}
+
+	rootMt.Run("should return the updated document when call database with success", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository_Update(nestedMt)
+		mockId := primitive.NewObjectID().Hex()
+
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "value", Value: bson.D{
+				{Key: "_id", Value: mockId},
+				{Key: "foo", Value: "buzz"},
+				{Key: "fizz", Value: "bar"},
+			}},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		result, err := repository.Update(ctx, mockId, &MockChanges{Foo: "buzz"})
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		set := nestedMt.GetStartedEvent().Command.Lookup("update", "$set").Document()
+		elements, _ := set.Elements()
+
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, elements, 1, "should only set the given fields")
+		assert.Equal(t, "buzz", set.Lookup("foo").StringValue(), "should set the changes")
+		assert.Equal(t, "buzz", result.Foo, "should return the updated object")
+		assert.Equal(t, "bar", result.Fizz, "should return the fields kept")
+	})
+
+	rootMt.Run("should return error when wrong object id is provided", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository_Update(nestedMt)
+
+		result, err := repository.Update(ctx, "something_wrong", &MockChanges{Foo: "buzz"})
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Nil(t, result, "should not return a document")
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return object id error")
+	})
+
+	rootMt.Run("should return validation error without changes", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository_Update(nestedMt)
+
+		result, err := repository.Update(ctx, primitive.NewObjectID().Hex(), nil)
+
+		assert.Nil(t, result, "should not return a document")
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should return validation error")
+	})
+}
+
+func TestRepository_SoftDelete(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return nil when call database with success", func(nestedMt *mtest.T) {
+		repository := BeforeEach_TestRepository(nestedMt)
+
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "nModified", Value: 1}})
+		defer nestedMt.ClearMockResponses()
+
+		err := repository.SoftDelete(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err, "should not return error")
+	})
+}
@@ -12,14 +12,19 @@ type Database struct {
 	*mongo.Database
 }
 
-func NewDatabase(uri string, name string) (*Database, error) {
+func NewDatabase(uri string, name string, opts ...*options.ClientOptions) (*Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{options.Client().ApplyURI(uri)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Database{client.Database(name)}, nil
 }
+
+// Ping checks the connection to the database server, e.g. for readiness.
+func (db *Database) Ping(ctx context.Context) error {
+	return db.Client().Ping(ctx, nil)
+}
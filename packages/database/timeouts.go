@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+type Operation string
+
+const (
+	OperationGetById      Operation = "get_by_id"
+	OperationGetByEmail   Operation = "get_by_email"
+	OperationDeleteById   Operation = "delete_by_id"
+	OperationCreateOne    Operation = "create_one"
+	OperationUpdateById   Operation = "update_by_id"
+	OperationGetPaginated Operation = "get_paginated"
+)
+
+const (
+	DefaultTimeout = time.Second * 10
+)
+
+// Timeouts holds how long each repository operation may take. Operations
+// not present in the map fall back to DefaultTimeout.
+type Timeouts map[Operation]time.Duration
+
+func (t Timeouts) For(operation Operation) time.Duration {
+	if timeout, ok := t[operation]; ok && timeout > 0 {
+		return timeout
+	}
+
+	return DefaultTimeout
+}
+
+// Context derives the context of an operation from the caller one, so it is
+// cancelled either by the caller or by the operation timeout.
+func (t Timeouts) Context(
+	ctx context.Context,
+	operation Operation,
+) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.For(operation))
+}
@@ -0,0 +1,51 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeouts_For(t *testing.T) {
+	t.Run("should return the configured timeout of the operation", func(t *testing.T) {
+		timeouts := database.Timeouts{database.OperationGetById: time.Second}
+
+		assert.Equal(t, time.Second, timeouts.For(database.OperationGetById))
+	})
+
+	t.Run("should return the default timeout when operation is not configured", func(t *testing.T) {
+		timeouts := database.Timeouts{database.OperationGetById: 0}
+
+		assert.Equal(t, database.DefaultTimeout, timeouts.For(database.OperationGetById))
+		assert.Equal(t, database.DefaultTimeout, timeouts.For(database.OperationCreateOne))
+	})
+}
+
+func TestTimeouts_Context(t *testing.T) {
+	t.Run("should derive a context bounded by the operation timeout", func(t *testing.T) {
+		timeouts := database.Timeouts{database.OperationGetById: time.Minute}
+
+		ctx, cancel := timeouts.Context(context.TODO(), database.OperationGetById)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+
+		assert.True(t, ok, "should have a deadline")
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("should be cancelled when the caller context is cancelled", func(t *testing.T) {
+		timeouts := database.Timeouts{}
+		parent, cancelParent := context.WithCancel(context.TODO())
+
+		ctx, cancel := timeouts.Context(parent, database.OperationGetById)
+		defer cancel()
+
+		cancelParent()
+
+		assert.ErrorIs(t, ctx.Err(), context.Canceled, "should follow the caller context")
+	})
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	TransactionMaxAttempts = 3
+
+	labelTransientTransactionError      = "TransientTransactionError"
+	labelUnknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+type TransactionInterface interface {
+	WithTransaction(
+		ctx context.Context,
+		fn func(txCtx context.Context) error,
+	) error
+}
+
+var transactionSupport sync.Map
+
+// WithTransaction runs fn as a unit of work. Repository calls made with txCtx
+// take part in the same transaction, which is retried on transient errors.
+// Nested calls join the outer transaction, and deployments without
+// transaction support (e.g. a standalone Mongo) just run fn as is.
+func (db *Database) WithTransaction(
+	ctx context.Context,
+	fn func(txCtx context.Context) error,
+) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	supported, err := db.supportsTransactions(ctx)
+	if err != nil {
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	if !supported {
+		return fn(ctx)
+	}
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+	defer session.EndSession(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err = runTransaction(ctx, session, fn)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= TransactionMaxAttempts || !hasErrorLabel(err, labelTransientTransactionError) {
+			return err
+		}
+	}
+}
+
+func runTransaction(
+	ctx context.Context,
+	session mongo.Session,
+	fn func(txCtx context.Context) error,
+) error {
+	if err := session.StartTransaction(); err != nil {
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+
+	txCtx := mongo.NewSessionContext(ctx, session)
+
+	if err := fn(txCtx); err != nil {
+		session.AbortTransaction(ctx)
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := session.CommitTransaction(txCtx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < TransactionMaxAttempts && hasErrorLabel(err, labelUnknownTransactionCommitResult) {
+			continue
+		}
+
+		return exception.Wrap(exception.CodeDatabaseFailed, err)
+	}
+}
+
+func (db *Database) supportsTransactions(ctx context.Context) (bool, error) {
+	client := db.Client()
+
+	if supported, ok := transactionSupport.Load(client); ok {
+		return supported.(bool), nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	transactionSupport.Store(client, supported)
+
+	return supported, nil
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var serverError mongo.ServerError
+	if errors.As(err, &serverError) {
+		return serverError.HasErrorLabel(label)
+	}
+
+	return false
+}
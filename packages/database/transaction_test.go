@@ -0,0 +1,186 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestTransaction_WithTransaction(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	replicaSetHello := mtest.CreateSuccessResponse(bson.E{Key: "setName", Value: "rs0"})
+
+	rootMt.Run("should run the function without a session when deployment is standalone", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		calls := 0
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			assert.Nil(t, mongo.SessionFromContext(txCtx), "should not start a session")
+			return nil
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 1, calls, "should call the function once")
+
+		err = mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			return nil
+		})
+
+		assert.Nil(t, err, "should not check the deployment again")
+		assert.Equal(t, 2, calls, "should call the function again")
+	})
+
+	rootMt.Run("should run the function inside a session when deployment supports transactions", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(replicaSetHello, mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			assert.NotNil(t, mongo.SessionFromContext(txCtx), "should start a session")
+
+			_, err := mockDB.Collection(MOCK_COLL_NAME).InsertOne(txCtx, bson.D{{Key: "foo", Value: "bar"}})
+			return err
+		})
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	rootMt.Run("should join the outer transaction when called with a transactional context", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(replicaSetHello, mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			return mockDB.WithTransaction(txCtx, func(nestedCtx context.Context) error {
+				assert.Equal(t, txCtx, nestedCtx, "should reuse the outer context")
+				return nil
+			})
+		})
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	rootMt.Run("should retry the transaction when a transient error happens", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(replicaSetHello, mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		calls := 0
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			if calls == 1 {
+				return mongo.CommandError{Labels: []string{"TransientTransactionError"}}
+			}
+			return nil
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 2, calls, "should call the function twice")
+	})
+
+	rootMt.Run("should stop retrying when the maximum of attempts is reached", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(replicaSetHello)
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		calls := 0
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			return mongo.CommandError{Labels: []string{"TransientTransactionError"}}
+		})
+
+		assert.NotNil(t, err, "should return error")
+		assert.Equal(t, database.TransactionMaxAttempts, calls, "should call the function until the limit")
+	})
+
+	rootMt.Run("should return the function error without retrying", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(replicaSetHello)
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		calls := 0
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			return errors.New(exception.CodePermission)
+		})
+
+		assert.Equal(t, exception.CodePermission, err.Error(), "should return the function error")
+		assert.Equal(t, 1, calls, "should call the function once")
+	})
+
+	rootMt.Run("should retry the commit when its result is unknown", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(
+			replicaSetHello,
+			mtest.CreateSuccessResponse(),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{
+				Code:   50,
+				Labels: []string{"UnknownTransactionCommitResult"},
+			}),
+			mtest.CreateSuccessResponse(),
+		)
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			_, err := mockDB.Collection(MOCK_COLL_NAME).InsertOne(txCtx, bson.D{{Key: "foo", Value: "bar"}})
+			return err
+		})
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	rootMt.Run("should return error when failed to commit", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(
+			replicaSetHello,
+			mtest.CreateSuccessResponse(),
+			bson.D{{Key: "ok", Value: 0}},
+		)
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			_, err := mockDB.Collection(MOCK_COLL_NAME).InsertOne(txCtx, bson.D{{Key: "foo", Value: "bar"}})
+			return err
+		})
+
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
+	})
+
+	rootMt.Run("should return error when failed to check the deployment", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{nestedMt.Client.Database(MOCK_DB_NAME)}
+
+		err := mockDB.WithTransaction(ctx, func(txCtx context.Context) error {
+			return nil
+		})
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
+	})
+}
@@ -1,8 +1,13 @@
 package database
 
 import (
+	"context"
+	"errors"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func ParseToDocument(structure any) (*bson.D, error) {
@@ -30,3 +35,17 @@ func ParseToDatabaseId(ids ...string) ([]primitive.ObjectID, error) {
 
 	return objectIds, nil
 }
+
+func ParseToDatabaseError(err error) error {
+	if mongo.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return exception.Wrap(exception.CodeTimeout, err)
+	}
+
+	return exception.Wrap(exception.CodeDatabaseFailed, err)
+}
+
+// IsDuplicateKeyError tells whether the write broke a unique index, also when
+// the error was parsed by ParseToDatabaseError or came from MemoryDatabase.
+func IsDuplicateKeyError(err error) bool {
+	return mongo.IsDuplicateKeyError(err) || errors.Is(err, errMemoryDuplicateKey)
+}
@@ -1,10 +1,14 @@
 package database_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestUtils_ParseDocument(t *testing.T) {
@@ -62,3 +66,36 @@ func TestUtils_ParseToDatabaseId(t *testing.T) {
 		assert.Nil(t, objectIds, "should not return object ids")
 	})
 }
+
+func TestUtils_ParseToDatabaseError(t *testing.T) {
+	t.Run("should parse to timeout error when the deadline is exceeded", func(t *testing.T) {
+		err := database.ParseToDatabaseError(context.DeadlineExceeded)
+
+		assert.Equal(t, exception.CodeTimeout, err.Error(), "should return timeout error")
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "should keep the cause")
+		assert.ErrorIs(t, err, exception.ErrTimeout, "should match the timeout error")
+	})
+
+	t.Run("should parse to database error when the cause is unknown", func(t *testing.T) {
+		cause := errors.New("something goes wrong")
+
+		err := database.ParseToDatabaseError(cause)
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database error")
+		assert.ErrorIs(t, err, cause, "should keep the cause")
+	})
+}
+
+func TestUtils_IsDuplicateKeyError(t *testing.T) {
+	t.Run("should tell duplicate key errors parsed to database errors", func(t *testing.T) {
+		err := database.ParseToDatabaseError(mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}})
+
+		assert.True(t, database.IsDuplicateKeyError(err), "should be a duplicate key error")
+	})
+
+	t.Run("should not tell other errors", func(t *testing.T) {
+		err := database.ParseToDatabaseError(errors.New("something goes wrong"))
+
+		assert.False(t, database.IsDuplicateKeyError(err), "should not be a duplicate key error")
+	})
+}
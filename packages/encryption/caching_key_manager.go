@@ -0,0 +1,112 @@
+package encryption
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxUnwrappedKeys bounds the data keys a CachingKeyManager keeps unwrapped.
+const maxUnwrappedKeys = 1024
+
+type unwrappedKey struct {
+	plaintext []byte
+	expiresAt time.Time
+}
+
+// CachingKeyManager reuses the data keys of a key manager for up to maxAge
+// and maxUses encryptions, so the fields of a record, encrypted one after the
+// other, cost a single round trip to e.g. AWS KMS. The data keys it unwraps
+// are kept for maxAge as well, so reading the record costs one too. Every
+// encryption still takes its own random nonce.
+type CachingKeyManager struct {
+	manager   KeyManager
+	maxAge    time.Duration
+	maxUses   int
+	now       func() time.Time
+	mutex     sync.Mutex
+	current   *DataKey
+	createdAt time.Time
+	uses      int
+	unwrapped map[string]unwrappedKey
+}
+
+func NewCachingKeyManager(km KeyManager, maxAge time.Duration, maxUses int) *CachingKeyManager {
+	return &CachingKeyManager{
+		manager:   km,
+		maxAge:    maxAge,
+		maxUses:   maxUses,
+		now:       time.Now,
+		unwrapped: map[string]unwrappedKey{},
+	}
+}
+
+// WithClock replaces the clock the age of the data keys is measured with.
+func (ckm *CachingKeyManager) WithClock(now func() time.Time) *CachingKeyManager {
+	ckm.now = now
+	return ckm
+}
+
+func (ckm *CachingKeyManager) Id() string {
+	return ckm.manager.Id()
+}
+
+func (ckm *CachingKeyManager) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	ckm.mutex.Lock()
+	defer ckm.mutex.Unlock()
+
+	now := ckm.now()
+	if ckm.current == nil || ckm.uses >= ckm.maxUses || now.Sub(ckm.createdAt) >= ckm.maxAge {
+		dataKey, err := ckm.manager.GenerateDataKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ckm.current, ckm.createdAt, ckm.uses = dataKey, now, 0
+		ckm.remember(dataKey.Wrapped, dataKey.Plaintext, now)
+	}
+
+	ckm.uses++
+	return ckm.current, nil
+}
+
+func (ckm *CachingKeyManager) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	now := ckm.now()
+
+	ckm.mutex.Lock()
+	cached, ok := ckm.unwrapped[string(wrapped)]
+	ckm.mutex.Unlock()
+
+	if ok && now.Before(cached.expiresAt) {
+		return cached.plaintext, nil
+	}
+
+	plaintext, err := ckm.manager.DecryptDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	ckm.mutex.Lock()
+	ckm.remember(wrapped, plaintext, now)
+	ckm.mutex.Unlock()
+
+	return plaintext, nil
+}
+
+// remember keeps the unwrapped key for maxAge. Once the cache is full, the
+// expired keys are dropped, or every key when none expired.
+func (ckm *CachingKeyManager) remember(wrapped []byte, plaintext []byte, now time.Time) {
+	if len(ckm.unwrapped) >= maxUnwrappedKeys {
+		for key, cached := range ckm.unwrapped {
+			if !now.Before(cached.expiresAt) {
+				delete(ckm.unwrapped, key)
+			}
+		}
+
+		if len(ckm.unwrapped) >= maxUnwrappedKeys {
+			clear(ckm.unwrapped)
+		}
+	}
+
+	ckm.unwrapped[string(wrapped)] = unwrappedKey{plaintext: plaintext, expiresAt: now.Add(ckm.maxAge)}
+}
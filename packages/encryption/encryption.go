@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/italoservio/braz_ecommerce/packages/exception"
 	"github.com/italoservio/braz_ecommerce/packages/logger"
@@ -16,54 +17,137 @@ import (
 type EncryptionInterface interface {
 	Encrypt(
 		ctx context.Context,
-		secret string,
 		text string,
 	) (*EncryptedText, error)
+	Decrypt(
+		ctx context.Context,
+		encrypted *EncryptedText,
+	) (string, error)
 }
 
+// nonceSize is the standard GCM nonce size, stored as the salt.
+const nonceSize = 12
+
+var errUnknownKey = errors.New("unknown encryption key")
+
 type EncryptionImpl struct {
-	logger logger.LoggerInterface
+	logger  logger.LoggerInterface
+	keyring *Keyring
 }
 
-func NewEncryptionImpl(lg logger.LoggerInterface) *EncryptionImpl {
+func NewEncryptionImpl(lg logger.LoggerInterface, kr *Keyring) *EncryptionImpl {
 	return &EncryptionImpl{
-		logger: lg,
+		logger:  lg,
+		keyring: kr,
 	}
 }
 
+// EncryptedText is prefixed by the id of the key that encrypted it, e.g.
+// "v2:9f86d0...".
 type EncryptedText struct {
 	EncryptedText string
 	Salt          string
 }
 
-func (e *EncryptionImpl) Encrypt(ctx context.Context, secret string, text string) (*EncryptedText, error) {
-	if secret == "" || text == "" {
-		e.logger.WithCtx(ctx).Error("secret or text is empty")
-		return nil, errors.New(exception.CodeValidationFailed)
+func (e *EncryptionImpl) Encrypt(ctx context.Context, text string) (*EncryptedText, error) {
+	if text == "" {
+		e.logger.WithCtx(ctx).Error("text is empty")
+		return nil, exception.New(exception.CodeValidationFailed)
 	}
 
-	block, err := aes.NewCipher([]byte(secret))
-	if err != nil {
-		e.logger.WithCtx(ctx).Error(err.Error())
-		return nil, errors.New(exception.CodeInternal)
-	}
+	keyId := e.keyring.PrimaryId()
 
-	salt := make([]byte, 12)
+	salt := make([]byte, nonceSize)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		e.logger.WithCtx(ctx).Error(err.Error())
-		return nil, errors.New(exception.CodeInternal)
+		return nil, exception.Wrap(exception.CodeInternal, err)
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
+	cipherText, err := e.seal(ctx, keyId, salt, []byte(text))
 	if err != nil {
-		e.logger.WithCtx(ctx).Error(err.Error())
-		return nil, errors.New(exception.CodeInternal)
+		e.logger.WithCtx(ctx).Error(err.Error(), "key_id", keyId)
+		return nil, exception.Wrap(exception.CodeInternal, err)
 	}
 
-	cipherText := aesgcm.Seal(nil, salt, []byte(text), nil)
-
 	return &EncryptedText{
-		EncryptedText: hex.EncodeToString(cipherText),
+		EncryptedText: keyId + keySeparator + hex.EncodeToString(cipherText),
 		Salt:          hex.EncodeToString(salt),
 	}, nil
 }
+
+// Decrypt reads texts encrypted with any key of the keyring.
+func (e *EncryptionImpl) Decrypt(ctx context.Context, encrypted *EncryptedText) (string, error) {
+	keyId := KeyId(encrypted.EncryptedText)
+
+	cipherText, err := hex.DecodeString(strings.TrimPrefix(encrypted.EncryptedText, keyId+keySeparator))
+	if err != nil {
+		e.logger.WithCtx(ctx).Error(err.Error())
+		return "", exception.Wrap(exception.CodeInternal, err)
+	}
+
+	salt, err := hex.DecodeString(encrypted.Salt)
+	if err != nil {
+		e.logger.WithCtx(ctx).Error(err.Error())
+		return "", exception.Wrap(exception.CodeInternal, err)
+	}
+
+	if len(salt) != nonceSize {
+		e.logger.WithCtx(ctx).Error("invalid salt size")
+		return "", exception.New(exception.CodeInternal)
+	}
+
+	text, err := e.open(ctx, keyId, salt, cipherText)
+	if err != nil {
+		e.logger.WithCtx(ctx).Error(err.Error(), "key_id", keyId)
+		return "", exception.Wrap(exception.CodeInternal, err)
+	}
+
+	return string(text), nil
+}
+
+// seal encrypts with the AES key of the keyring, or with a new data key
+// wrapped by the key manager.
+func (e *EncryptionImpl) seal(ctx context.Context, keyId string, salt []byte, text []byte) ([]byte, error) {
+	if manager, ok := e.keyring.manager(keyId); ok {
+		return sealEnvelope(ctx, manager, salt, text)
+	}
+
+	secret, ok := e.keyring.key(keyId)
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	aesgcm, err := newGCM(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesgcm.Seal(nil, salt, text, nil), nil
+}
+
+func (e *EncryptionImpl) open(ctx context.Context, keyId string, salt []byte, cipherText []byte) ([]byte, error) {
+	if manager, ok := e.keyring.manager(keyId); ok {
+		return openEnvelope(ctx, manager, salt, cipherText)
+	}
+
+	secret, ok := e.keyring.key(keyId)
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	aesgcm, err := newGCM(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesgcm.Open(nil, salt, cipherText, nil)
+}
+
+func newGCM(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
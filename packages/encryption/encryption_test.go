@@ -0,0 +1,535 @@
+package encryption_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	MOCK_KEY_V1 = "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6"
+	MOCK_KEY_V2 = "9aQwErTyUiOpAsDfGhJkLzXcVbNm1234"
+)
+
+func newKeyring(t *testing.T, primary string) *encryption.Keyring {
+	keyring, err := encryption.NewKeyring(primary, map[string]string{"v1": MOCK_KEY_V1, "v2": MOCK_KEY_V2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestKeyring(t *testing.T) {
+	t.Run("should parse keys in the id:secret format", func(t *testing.T) {
+		keyring, err := encryption.ParseKeyring("v2", "v1:"+MOCK_KEY_V1+", v2:"+MOCK_KEY_V2)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "v2", keyring.PrimaryId())
+		assert.Equal(t, []string{"v1", "v2"}, keyring.Ids())
+	})
+
+	t.Run("should return error when keys are invalid", func(t *testing.T) {
+		for keys, message := range map[string]string{
+			MOCK_KEY_V1: "id:secret format",
+			"v1:short":  "16, 24 or 32 bytes",
+			"v1:" + MOCK_KEY_V1 + ",v1:" + MOCK_KEY_V2: "duplicated",
+			"v2:" + MOCK_KEY_V2:                        "primary key \"v1\" is not in the keyring",
+			":" + MOCK_KEY_V1:                          "invalid key id",
+		} {
+			_, err := encryption.ParseKeyring("v1", keys)
+
+			assert.ErrorContains(t, err, message)
+		}
+	})
+
+	t.Run("should read the key id of encrypted texts", func(t *testing.T) {
+		assert.Equal(t, "v2", encryption.KeyId("v2:9f86d0"))
+		assert.Equal(t, encryption.LegacyKeyId, encryption.KeyId("9f86d0"), "should fall back to the legacy key")
+	})
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.TODO()
+	lg := logger.NewLogger()
+
+	t.Run("should encrypt with the primary key and decrypt back", func(t *testing.T) {
+		encryptionImpl := encryption.NewEncryptionImpl(lg, newKeyring(t, "v2"))
+
+		encrypted, err := encryptionImpl.Encrypt(ctx, "secret")
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, strings.HasPrefix(encrypted.EncryptedText, "v2:"), "should prefix the key id")
+
+		text, err := encryptionImpl.Decrypt(ctx, encrypted)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+	})
+
+	t.Run("should decrypt texts encrypted with an older key", func(t *testing.T) {
+		encrypted, _ := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1")).Encrypt(ctx, "secret")
+
+		text, err := encryption.NewEncryptionImpl(lg, newKeyring(t, "v2")).Decrypt(ctx, encrypted)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+	})
+
+	t.Run("should decrypt legacy texts without key id with the legacy key", func(t *testing.T) {
+		encryptionImpl := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1"))
+		encrypted, _ := encryptionImpl.Encrypt(ctx, "secret")
+
+		legacy := &encryption.EncryptedText{
+			EncryptedText: strings.TrimPrefix(encrypted.EncryptedText, "v1:"),
+			Salt:          encrypted.Salt,
+		}
+
+		text, err := encryptionImpl.Decrypt(ctx, legacy)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+	})
+
+	t.Run("should return error when key is not in the keyring", func(t *testing.T) {
+		encryptionImpl := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1"))
+
+		_, err := encryptionImpl.Decrypt(ctx, &encryption.EncryptedText{EncryptedText: "v3:9f86d0", Salt: "00"})
+
+		assert.Equal(t, exception.CodeInternal, err.Error())
+	})
+
+	t.Run("should return error when text was tampered", func(t *testing.T) {
+		encryptionImpl := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1"))
+		encrypted, _ := encryptionImpl.Encrypt(ctx, "secret")
+		encrypted.Salt = strings.Repeat("0", len(encrypted.Salt))
+
+		_, err := encryptionImpl.Decrypt(ctx, encrypted)
+
+		assert.Equal(t, exception.CodeInternal, err.Error())
+	})
+
+	t.Run("should return error when text is empty", func(t *testing.T) {
+		encryptionImpl := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1"))
+
+		_, err := encryptionImpl.Encrypt(ctx, "")
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error())
+	})
+}
+
+type rotationStoreStub struct {
+	records map[string]encryption.EncryptedText
+	failing map[string]bool
+}
+
+func (rss *rotationStoreStub) FindStale(
+	ctx context.Context,
+	keyId string,
+	after string,
+	limit int,
+) ([]encryption.RotationRecord, error) {
+	ids := []string{}
+	for id, encrypted := range rss.records {
+		if id > after && encryption.KeyId(encrypted.EncryptedText) != keyId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	records := []encryption.RotationRecord{}
+	for _, id := range ids {
+		if len(records) == limit {
+			break
+		}
+		records = append(records, encryption.RotationRecord{Id: id, Encrypted: rss.records[id]})
+	}
+
+	return records, nil
+}
+
+func (rss *rotationStoreStub) Replace(
+	ctx context.Context,
+	id string,
+	previous encryption.EncryptedText,
+	next encryption.EncryptedText,
+) error {
+	if rss.failing[id] {
+		return errors.New(exception.CodeDatabaseFailed)
+	}
+
+	rss.records[id] = next
+	return nil
+}
+
+type documentRotationStoreStub struct {
+	keyIds  map[string]string
+	failing map[string]bool
+	primary string
+}
+
+func (drs *documentRotationStoreStub) FindStale(
+	ctx context.Context,
+	keyId string,
+	after string,
+	limit int,
+) ([]string, error) {
+	drs.primary = keyId
+
+	ids := []string{}
+	for id, documentKeyId := range drs.keyIds {
+		if id > after && documentKeyId != keyId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	return ids, nil
+}
+
+func (drs *documentRotationStoreStub) Rotate(ctx context.Context, id string) error {
+	if drs.failing[id] {
+		return errors.New(exception.CodeDatabaseFailed)
+	}
+
+	drs.keyIds[id] = drs.primary
+	return nil
+}
+
+func TestFieldEncryption(t *testing.T) {
+	const MOCK_INDEX_KEY = "h7Jd9sLq2WnX4vBz8Rt6Yp3Kc5Mf1Ga0"
+
+	newFieldEncryption := func(t *testing.T, primary string) *encryption.FieldEncryptionImpl {
+		fieldEncryption, err := encryption.NewFieldEncryptionImpl(
+			encryption.NewEncryptionImpl(logger.NewLogger(), newKeyring(t, primary)),
+			MOCK_INDEX_KEY,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fieldEncryption
+	}
+
+	t.Run("should return error when the index key is too short", func(t *testing.T) {
+		_, err := encryption.NewFieldEncryptionImpl(nil, "short")
+
+		assert.NotNil(t, err, "should return error")
+	})
+
+	t.Run("should encrypt and decrypt a field", func(t *testing.T) {
+		ctx := context.TODO()
+		fieldEncryption := newFieldEncryption(t, "v1")
+
+		field, err := fieldEncryption.EncryptField(ctx, "goo@gle.com")
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, strings.HasPrefix(field, "v1:"), "should prefix the field with the key id")
+		assert.NotContains(t, field, "goo@gle.com", "should not keep the text")
+
+		text, err := newFieldEncryption(t, "v2").DecryptField(ctx, field)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "goo@gle.com", text, "should decrypt fields of any key of the keyring")
+	})
+
+	t.Run("should keep empty and plaintext fields as they are", func(t *testing.T) {
+		ctx := context.TODO()
+		fieldEncryption := newFieldEncryption(t, "v1")
+
+		field, err := fieldEncryption.EncryptField(ctx, "")
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "", field, "should not encrypt empty texts")
+
+		for _, plaintext := range []string{"", "Italo", "goo@gle.com", "Rua A: 10"} {
+			text, err := fieldEncryption.DecryptField(ctx, plaintext)
+			assert.Nil(t, err, "should not return error")
+			assert.Equal(t, plaintext, text, "should return the plaintext")
+		}
+	})
+
+	t.Run("should return error when the field was tampered", func(t *testing.T) {
+		ctx := context.TODO()
+		fieldEncryption := newFieldEncryption(t, "v1")
+
+		field, _ := fieldEncryption.EncryptField(ctx, "goo@gle.com")
+		parts := strings.Split(field, ":")
+		parts[1] = strings.Repeat("0", len(parts[1]))
+
+		_, err := fieldEncryption.DecryptField(ctx, strings.Join(parts, ":"))
+		assert.Equal(t, exception.CodeInternal, err.Error(), "should return internal error")
+	})
+
+	t.Run("should compute the same blind index for the normalized text", func(t *testing.T) {
+		fieldEncryption := newFieldEncryption(t, "v1")
+
+		index := fieldEncryption.BlindIndex("goo@gle.com")
+		assert.Len(t, index, 64, "should be a hex encoded HMAC-SHA256")
+		assert.Equal(t, index, fieldEncryption.BlindIndex(" GOO@gle.com "), "should ignore case and spaces")
+		assert.Equal(t, index, newFieldEncryption(t, "v2").BlindIndex("goo@gle.com"), "should not depend on the keyring")
+		assert.NotEqual(t, index, fieldEncryption.BlindIndex("foo@gle.com"), "should differ between texts")
+		assert.Equal(t, "", fieldEncryption.BlindIndex(""), "should not index empty texts")
+	})
+}
+
+func TestRotationJob_RunOnce(t *testing.T) {
+	ctx := context.TODO()
+	lg := logger.NewLogger()
+
+	t.Run("should re-encrypt every record with the primary key", func(t *testing.T) {
+		previous := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1"))
+		store := &rotationStoreStub{records: map[string]encryption.EncryptedText{}, failing: map[string]bool{"c": true}}
+
+		for _, id := range []string{"a", "b", "c", "d", "e"} {
+			encrypted, _ := previous.Encrypt(ctx, "secret-"+id)
+			store.records[id] = *encrypted
+		}
+
+		keyring := newKeyring(t, "v2")
+		current := encryption.NewEncryptionImpl(lg, keyring)
+		job := encryption.NewRotationJob(lg, current, keyring, store).WithBatchSize(2)
+
+		rotated, err := job.RunOnce(ctx)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 4, rotated, "should skip the failing record")
+
+		for id, encrypted := range store.records {
+			expected := "v2"
+			if id == "c" {
+				expected = "v1"
+			}
+			assert.Equal(t, expected, encryption.KeyId(encrypted.EncryptedText))
+
+			text, _ := current.Decrypt(ctx, &encrypted)
+			assert.Equal(t, "secret-"+id, text, "should keep the content")
+		}
+	})
+
+	t.Run("should re-encrypt the documents of every store", func(t *testing.T) {
+		keyring := newKeyring(t, "v2")
+		current := encryption.NewEncryptionImpl(lg, keyring)
+		records := &rotationStoreStub{records: map[string]encryption.EncryptedText{}}
+		documents := &documentRotationStoreStub{
+			keyIds:  map[string]string{"a": "v1", "b": "v2", "c": "v1", "d": "", "e": "v1"},
+			failing: map[string]bool{"c": true},
+		}
+
+		job := encryption.NewRotationJob(lg, current, keyring, records).WithBatchSize(2).WithDocuments(documents)
+
+		rotated, err := job.RunOnce(ctx)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 3, rotated, "should skip the failing document")
+		assert.Equal(t, map[string]string{"a": "v2", "b": "v2", "c": "v1", "d": "v2", "e": "v2"}, documents.keyIds)
+	})
+}
+
+func newFileKeyManager(t *testing.T, id string, masterKey string) *encryption.FileKeyManager {
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(masterKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyManager, err := encryption.NewFileKeyManager(id, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyManager
+}
+
+type kmsClientStub struct {
+	keyManager *encryption.FileKeyManager
+	keyIds     []string
+	failing    bool
+}
+
+func (kcs *kmsClientStub) GenerateDataKey(
+	ctx context.Context,
+	params *kms.GenerateDataKeyInput,
+	optFns ...func(*kms.Options),
+) (*kms.GenerateDataKeyOutput, error) {
+	kcs.keyIds = append(kcs.keyIds, *params.KeyId)
+	if kcs.failing {
+		return nil, errors.New("kms unavailable")
+	}
+
+	dataKey, err := kcs.keyManager.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.GenerateDataKeyOutput{Plaintext: dataKey.Plaintext, CiphertextBlob: dataKey.Wrapped}, nil
+}
+
+func (kcs *kmsClientStub) Decrypt(
+	ctx context.Context,
+	params *kms.DecryptInput,
+	optFns ...func(*kms.Options),
+) (*kms.DecryptOutput, error) {
+	kcs.keyIds = append(kcs.keyIds, *params.KeyId)
+	if kcs.failing {
+		return nil, errors.New("kms unavailable")
+	}
+
+	plaintext, err := kcs.keyManager.DecryptDataKey(ctx, params.CiphertextBlob)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.DecryptOutput{Plaintext: plaintext}, nil
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	ctx := context.TODO()
+	lg := logger.NewLogger()
+
+	t.Run("should return error when the master key file is invalid", func(t *testing.T) {
+		_, err := encryption.NewFileKeyManager("file", filepath.Join(t.TempDir(), "missing.key"))
+		assert.NotNil(t, err, "should return error when the file is missing")
+
+		path := filepath.Join(t.TempDir(), "master.key")
+		os.WriteFile(path, []byte("short"), 0o600)
+
+		_, err = encryption.NewFileKeyManager("file", path)
+		assert.NotNil(t, err, "should return error when the key has an invalid size")
+	})
+
+	t.Run("should encrypt each text with its own data key wrapped by the key manager", func(t *testing.T) {
+		keyManager := newFileKeyManager(t, "file", MOCK_KEY_V2)
+		keyring, err := encryption.ParseKeyring("file", "", keyManager)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, []string{"file"}, keyring.Ids())
+
+		encryptionImpl := encryption.NewEncryptionImpl(lg, keyring)
+
+		first, err := encryptionImpl.Encrypt(ctx, "secret")
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, strings.HasPrefix(first.EncryptedText, "file:"), "should prefix the key manager id")
+
+		second, _ := encryptionImpl.Encrypt(ctx, "secret")
+		assert.NotEqual(t, first.EncryptedText[:80], second.EncryptedText[:80], "should wrap a new data key per text")
+
+		text, err := encryptionImpl.Decrypt(ctx, first)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+	})
+
+	t.Run("should decrypt texts of the AES keys when a key manager is primary", func(t *testing.T) {
+		encrypted, _ := encryption.NewEncryptionImpl(lg, newKeyring(t, "v1")).Encrypt(ctx, "secret")
+
+		keyring, err := encryption.NewKeyring(
+			"file",
+			map[string]string{"v1": MOCK_KEY_V1},
+			newFileKeyManager(t, "file", MOCK_KEY_V2),
+		)
+		assert.Nil(t, err, "should not return error")
+
+		text, err := encryption.NewEncryptionImpl(lg, keyring).Decrypt(ctx, encrypted)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+	})
+
+	t.Run("should return error when the key manager id is duplicated", func(t *testing.T) {
+		_, err := encryption.NewKeyring(
+			"v1",
+			map[string]string{"v1": MOCK_KEY_V1},
+			newFileKeyManager(t, "v1", MOCK_KEY_V2),
+		)
+
+		assert.NotNil(t, err, "should return error")
+	})
+
+	t.Run("should return error when the data key was wrapped by another master key", func(t *testing.T) {
+		encrypted, _ := encryption.NewEncryptionImpl(
+			lg,
+			mustKeyring(encryption.NewKeyring("file", nil, newFileKeyManager(t, "file", MOCK_KEY_V1))),
+		).Encrypt(ctx, "secret")
+
+		_, err := encryption.NewEncryptionImpl(
+			lg,
+			mustKeyring(encryption.NewKeyring("file", nil, newFileKeyManager(t, "file", MOCK_KEY_V2))),
+		).Decrypt(ctx, encrypted)
+
+		assert.Equal(t, exception.CodeInternal, err.Error())
+	})
+
+	t.Run("should wrap data keys with the AWS KMS key", func(t *testing.T) {
+		client := &kmsClientStub{keyManager: newFileKeyManager(t, "stub", MOCK_KEY_V1)}
+		keyManager := encryption.NewKMSKeyManager("kms", "alias/users-data-key", client)
+		encryptionImpl := encryption.NewEncryptionImpl(lg, mustKeyring(encryption.NewKeyring("kms", nil, keyManager)))
+
+		encrypted, err := encryptionImpl.Encrypt(ctx, "secret")
+		assert.Nil(t, err, "should not return error")
+
+		text, err := encryptionImpl.Decrypt(ctx, encrypted)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "secret", text)
+		assert.Equal(t, []string{"alias/users-data-key", "alias/users-data-key"}, client.keyIds, "should call KMS with the key")
+	})
+
+	t.Run("should reuse the data keys of AWS KMS within their age and uses", func(t *testing.T) {
+		now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		client := &kmsClientStub{keyManager: newFileKeyManager(t, "stub", MOCK_KEY_V1)}
+		keyManager := encryption.NewCachingKeyManager(
+			encryption.NewKMSKeyManager("kms", "alias/users-data-key", client),
+			time.Minute,
+			3,
+		).WithClock(func() time.Time { return now })
+		encryptionImpl := encryption.NewEncryptionImpl(lg, mustKeyring(encryption.NewKeyring("kms", nil, keyManager)))
+
+		fields := []*encryption.EncryptedText{}
+		for _, text := range []string{"Italo", "Servio", "goo@gle.com"} {
+			encrypted, err := encryptionImpl.Encrypt(ctx, text)
+			assert.Nil(t, err, "should not return error")
+			fields = append(fields, encrypted)
+		}
+
+		assert.Len(t, client.keyIds, 1, "should generate one data key for the fields of a record")
+		assert.NotEqual(t, fields[0].Salt, fields[1].Salt, "should take a nonce per field")
+
+		for _, field := range fields {
+			_, err := encryptionImpl.Decrypt(ctx, field)
+			assert.Nil(t, err, "should not return error")
+		}
+
+		assert.Len(t, client.keyIds, 1, "should not unwrap the cached data key")
+
+		encryptionImpl.Encrypt(ctx, "Rua A")
+		assert.Len(t, client.keyIds, 2, "should generate a new data key past the max uses")
+
+		now = now.Add(time.Minute)
+		encryptionImpl.Encrypt(ctx, "SP")
+		assert.Len(t, client.keyIds, 3, "should generate a new data key past the max age")
+
+		_, err := encryptionImpl.Decrypt(ctx, fields[0])
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, client.keyIds, 4, "should unwrap the data key again past the max age")
+	})
+
+	t.Run("should return error when AWS KMS fails", func(t *testing.T) {
+		client := &kmsClientStub{keyManager: newFileKeyManager(t, "stub", MOCK_KEY_V1), failing: true}
+		keyManager := encryption.NewKMSKeyManager("kms", "alias/users-data-key", client)
+		encryptionImpl := encryption.NewEncryptionImpl(lg, mustKeyring(encryption.NewKeyring("kms", nil, keyManager)))
+
+		_, err := encryptionImpl.Encrypt(ctx, "secret")
+
+		assert.Equal(t, exception.CodeInternal, err.Error())
+	})
+}
+
+func mustKeyring(keyring *encryption.Keyring, err error) *encryption.Keyring {
+	if err != nil {
+		panic(err)
+	}
+	return keyring
+}
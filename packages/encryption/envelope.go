@@ -0,0 +1,90 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// DataKeySize is the size of the AES-256 data keys.
+const DataKeySize = 32
+
+// KeyManager holds a master key that never leaves it and wraps the data keys
+// that encrypt each record, e.g. AWS KMS.
+type KeyManager interface {
+	// Id is the key id of the manager in the keyring.
+	Id() string
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+	DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// DataKey is a data key in plaintext, to encrypt the record and be discarded,
+// and wrapped by the master key, to be stored along with the record.
+type DataKey struct {
+	Plaintext []byte
+	Wrapped   []byte
+}
+
+var errInvalidEnvelope = errors.New("invalid envelope")
+
+// sealEnvelope encrypts the text with a data key of the manager and returns
+// the wrapped data key followed by the cipher text, prefixed by the wrapped
+// key size.
+func sealEnvelope(ctx context.Context, km KeyManager, nonce []byte, text []byte) ([]byte, error) {
+	dataKey, err := km.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataKey.Wrapped) > 0xffff {
+		return nil, errInvalidEnvelope
+	}
+
+	envelope := binary.BigEndian.AppendUint16(nil, uint16(len(dataKey.Wrapped)))
+	envelope = append(envelope, dataKey.Wrapped...)
+
+	return aesgcm.Seal(envelope, nonce, text, nil), nil
+}
+
+func openEnvelope(ctx context.Context, km KeyManager, nonce []byte, envelope []byte) ([]byte, error) {
+	if len(envelope) < 2 {
+		return nil, errInvalidEnvelope
+	}
+
+	size := int(binary.BigEndian.Uint16(envelope))
+	if len(envelope) < 2+size {
+		return nil, errInvalidEnvelope
+	}
+
+	plaintext, err := km.DecryptDataKey(ctx, envelope[2:2+size])
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aesgcm.NonceSize() {
+		return nil, errInvalidEnvelope
+	}
+
+	return aesgcm.Open(nil, nonce, envelope[2+size:], nil)
+}
+
+func newDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
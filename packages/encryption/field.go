@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// MinIndexKeySize is the minimum size of the blind index key, the same as the
+// HMAC-SHA256 output.
+const MinIndexKeySize = sha256.Size
+
+// FieldEncryptionInterface encrypts single document fields at rest. Encrypted
+// fields are not searchable, equality lookups go through their blind index.
+type FieldEncryptionInterface interface {
+	EncryptField(
+		ctx context.Context,
+		text string,
+	) (string, error)
+	DecryptField(
+		ctx context.Context,
+		field string,
+	) (string, error)
+	BlindIndex(text string) string
+}
+
+type FieldEncryptionImpl struct {
+	encryption EncryptionInterface
+	indexKey   []byte
+}
+
+// NewFieldEncryptionImpl fails when the index key is too short. Unlike the
+// keyring keys, the index key can not be rotated without recomputing every
+// stored index.
+func NewFieldEncryptionImpl(en EncryptionInterface, indexKey string) (*FieldEncryptionImpl, error) {
+	if len(indexKey) < MinIndexKeySize {
+		return nil, errors.New("encryption index key must have at least 32 bytes")
+	}
+
+	return &FieldEncryptionImpl{encryption: en, indexKey: []byte(indexKey)}, nil
+}
+
+// EncryptField returns the text encrypted in a single string holding the key
+// id, the cipher text and the salt, e.g. "v2:9f86d0...:3a7bd3...". Empty texts
+// are kept empty.
+func (fe *FieldEncryptionImpl) EncryptField(ctx context.Context, text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	encrypted, err := fe.encryption.Encrypt(ctx, text)
+	if err != nil {
+		return "", err
+	}
+
+	return encrypted.EncryptedText + keySeparator + encrypted.Salt, nil
+}
+
+// DecryptField returns fields that were not encrypted, written before field
+// encryption was enabled, as they are.
+func (fe *FieldEncryptionImpl) DecryptField(ctx context.Context, field string) (string, error) {
+	encrypted, ok := parseField(field)
+	if !ok {
+		return field, nil
+	}
+
+	return fe.encryption.Decrypt(ctx, encrypted)
+}
+
+// BlindIndex is a keyed hash of the normalized text, deterministic so it can
+// be used in equality filters without revealing the text.
+func (fe *FieldEncryptionImpl) BlindIndex(text string) string {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	if normalized == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, fe.indexKey)
+	mac.Write([]byte(normalized))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func parseField(field string) (*EncryptedText, bool) {
+	parts := strings.Split(field, keySeparator)
+	if len(parts) != 3 || parts[0] == "" || !isHex(parts[1]) || !isHex(parts[2]) {
+		return nil, false
+	}
+
+	return &EncryptedText{
+		EncryptedText: parts[0] + keySeparator + parts[1],
+		Salt:          parts[2],
+	}, true
+}
+
+func isHex(text string) bool {
+	if text == "" {
+		return false
+	}
+
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
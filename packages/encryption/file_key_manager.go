@@ -0,0 +1,76 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileKeyManager keeps the master key in a file, e.g. a mounted secret. It
+// is meant for tests and local development, where no KMS is available.
+type FileKeyManager struct {
+	id        string
+	masterKey []byte
+}
+
+// NewFileKeyManager reads the master key, of 16, 24 or 32 bytes, from the
+// file at path.
+func NewFileKeyManager(id string, path string) (*FileKeyManager, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	masterKey := []byte(strings.TrimSpace(string(content)))
+
+	switch len(masterKey) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("master key of %q must have 16, 24 or 32 bytes", id)
+	}
+
+	return &FileKeyManager{id: id, masterKey: masterKey}, nil
+}
+
+func (fk *FileKeyManager) Id() string {
+	return fk.id
+}
+
+// GenerateDataKey wraps a new data key with the master key, the nonce is
+// stored before the wrapped key.
+func (fk *FileKeyManager) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	plaintext, err := newDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(fk.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return &DataKey{Plaintext: plaintext, Wrapped: aesgcm.Seal(nonce, nonce, plaintext, nil)}, nil
+}
+
+func (fk *FileKeyManager) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	aesgcm, err := newGCM(fk.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < aesgcm.NonceSize() {
+		return nil, errInvalidEnvelope
+	}
+
+	nonce, cipherText := wrapped[:aesgcm.NonceSize()], wrapped[aesgcm.NonceSize():]
+
+	return aesgcm.Open(nil, nonce, cipherText, nil)
+}
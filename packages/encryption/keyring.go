@@ -0,0 +1,135 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// LegacyKeyId is the key of ciphertexts written before key ids existed,
+	// which carry no prefix.
+	LegacyKeyId  = "v1"
+	keySeparator = ":"
+)
+
+// Keyring holds every active key by id, either an AES key or a key manager
+// wrapping per-record data keys. New data is always encrypted with the
+// primary key, while data encrypted with any other key of the ring can still
+// be read until it is re-encrypted.
+type Keyring struct {
+	primary  string
+	keys     map[string][]byte
+	managers map[string]KeyManager
+}
+
+func NewKeyring(primary string, keys map[string]string, managers ...KeyManager) (*Keyring, error) {
+	keyring := &Keyring{primary: primary, keys: map[string][]byte{}, managers: map[string]KeyManager{}}
+
+	for id, secret := range keys {
+		if !validKeyId(id) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		switch len(secret) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("key %q must have 16, 24 or 32 bytes", id)
+		}
+
+		keyring.keys[id] = []byte(secret)
+	}
+
+	for _, manager := range managers {
+		id := manager.Id()
+		if !validKeyId(id) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		if _, ok := keyring.keys[id]; ok {
+			return nil, fmt.Errorf("key %q is duplicated", id)
+		}
+
+		if _, ok := keyring.managers[id]; ok {
+			return nil, fmt.Errorf("key %q is duplicated", id)
+		}
+
+		keyring.managers[id] = manager
+	}
+
+	_, isKey := keyring.keys[primary]
+	_, isManager := keyring.managers[primary]
+	if !isKey && !isManager {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+	}
+
+	return keyring, nil
+}
+
+// ParseKeyring reads keys in the "id:secret,id:secret" format, e.g. the
+// ENC_KEYS variable. Keys may be empty when key managers are given.
+func ParseKeyring(primary string, keys string, managers ...KeyManager) (*Keyring, error) {
+	parsed := map[string]string{}
+
+	if strings.TrimSpace(keys) == "" && len(managers) > 0 {
+		return NewKeyring(primary, parsed, managers...)
+	}
+
+	for _, pair := range strings.Split(keys, ",") {
+		id, secret, found := strings.Cut(strings.TrimSpace(pair), keySeparator)
+		if !found {
+			return nil, errors.New("keys must be in the id:secret format")
+		}
+
+		if _, ok := parsed[id]; ok {
+			return nil, fmt.Errorf("key %q is duplicated", id)
+		}
+
+		parsed[id] = secret
+	}
+
+	return NewKeyring(primary, parsed, managers...)
+}
+
+func (k *Keyring) PrimaryId() string {
+	return k.primary
+}
+
+func (k *Keyring) Ids() []string {
+	ids := make([]string, 0, len(k.keys)+len(k.managers))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+
+	for id := range k.managers {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func (k *Keyring) key(id string) ([]byte, bool) {
+	secret, ok := k.keys[id]
+	return secret, ok
+}
+
+func (k *Keyring) manager(id string) (KeyManager, bool) {
+	manager, ok := k.managers[id]
+	return manager, ok
+}
+
+func validKeyId(id string) bool {
+	return id != "" && !strings.Contains(id, keySeparator)
+}
+
+// KeyId returns the id of the key that encrypted the text.
+func KeyId(encryptedText string) string {
+	id, _, found := strings.Cut(encryptedText, keySeparator)
+	if !found {
+		return LegacyKeyId
+	}
+
+	return id
+}
@@ -0,0 +1,81 @@
+package encryption
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSClient is the subset of the AWS KMS client used to wrap data keys.
+type KMSClient interface {
+	GenerateDataKey(
+		ctx context.Context,
+		params *kms.GenerateDataKeyInput,
+		optFns ...func(*kms.Options),
+	) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(
+		ctx context.Context,
+		params *kms.DecryptInput,
+		optFns ...func(*kms.Options),
+	) (*kms.DecryptOutput, error)
+}
+
+// KMSKeyManager wraps data keys with an AWS KMS key, so the master key never
+// leaves KMS.
+type KMSKeyManager struct {
+	id     string
+	keyId  string
+	client KMSClient
+}
+
+// NewKMSKeyManager takes the key id of the manager in the keyring and the
+// KMS key id, ARN or alias, e.g. "alias/users-data-key".
+func NewKMSKeyManager(id string, keyId string, client KMSClient) *KMSKeyManager {
+	return &KMSKeyManager{id: id, keyId: keyId, client: client}
+}
+
+// NewKMSClient loads the AWS credentials from the default chain. The endpoint
+// is optional and points the client to LocalStack in local environments.
+func NewKMSClient(ctx context.Context, region string, endpoint string) (*kms.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	return kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}), nil
+}
+
+func (km *KMSKeyManager) Id() string {
+	return km.id
+}
+
+func (km *KMSKeyManager) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	output, err := km.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(km.keyId),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{Plaintext: output.Plaintext, Wrapped: output.CiphertextBlob}, nil
+}
+
+func (km *KMSKeyManager) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	output, err := km.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(km.keyId),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Plaintext, nil
+}
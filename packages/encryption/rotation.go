@@ -0,0 +1,192 @@
+package encryption
+
+import (
+	"context"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+)
+
+const (
+	DefaultRotationBatchSize = 100
+)
+
+type RotationRecord struct {
+	Id        string
+	Encrypted EncryptedText
+}
+
+// RotationStore gives the rotation job access to the encrypted records of a
+// collection.
+type RotationStore interface {
+	// FindStale returns up to limit records, ordered by id and after the
+	// given one, not encrypted with keyId.
+	FindStale(ctx context.Context, keyId string, after string, limit int) ([]RotationRecord, error)
+	// Replace swaps the encrypted text of a record, unless it changed since
+	// it was read.
+	Replace(ctx context.Context, id string, previous EncryptedText, next EncryptedText) error
+}
+
+// DocumentRotationStore gives the rotation job access to the documents of a
+// collection holding several encrypted fields, which the store re-encrypts
+// together, e.g. along with the indexes computed from them.
+type DocumentRotationStore interface {
+	// FindStale returns the ids of up to limit documents, ordered by id and
+	// after the given one, holding fields not encrypted with keyId.
+	FindStale(ctx context.Context, keyId string, after string, limit int) ([]string, error)
+	// Rotate re-encrypts the fields of a document with the primary key,
+	// unless it changed since it was read.
+	Rotate(ctx context.Context, id string) error
+}
+
+// RotationJob re-encrypts with the primary key the records encrypted with
+// older keys, so those keys can be removed from the keyring afterwards.
+type RotationJob struct {
+	logger     logger.LoggerInterface
+	encryption EncryptionInterface
+	keyring    *Keyring
+	store      RotationStore
+	documents  []DocumentRotationStore
+	batchSize  int
+}
+
+func NewRotationJob(
+	lg logger.LoggerInterface,
+	en EncryptionInterface,
+	kr *Keyring,
+	st RotationStore,
+) *RotationJob {
+	return &RotationJob{
+		logger:     lg,
+		encryption: en,
+		keyring:    kr,
+		store:      st,
+		batchSize:  DefaultRotationBatchSize,
+	}
+}
+
+// WithBatchSize returns a copy of the job reading batchSize records at a time.
+func (rj *RotationJob) WithBatchSize(batchSize int) *RotationJob {
+	copied := *rj
+	copied.batchSize = batchSize
+	return &copied
+}
+
+// WithDocuments returns a copy of the job that also re-encrypts the documents
+// of the stores.
+func (rj *RotationJob) WithDocuments(stores ...DocumentRotationStore) *RotationJob {
+	copied := *rj
+	copied.documents = append(append([]DocumentRotationStore{}, rj.documents...), stores...)
+	return &copied
+}
+
+// RunOnce goes through the stale records and documents once and returns how
+// many were re-encrypted. Those that fail are logged and left for the next
+// run.
+func (rj *RotationJob) RunOnce(ctx context.Context) (int, error) {
+	rotated, err := rj.runRecords(ctx)
+	if err != nil {
+		return rotated, err
+	}
+
+	for _, store := range rj.documents {
+		rotatedDocuments, err := rj.runDocuments(ctx, store)
+		rotated += rotatedDocuments
+		if err != nil {
+			return rotated, err
+		}
+	}
+
+	return rotated, nil
+}
+
+func (rj *RotationJob) runRecords(ctx context.Context) (int, error) {
+	primary := rj.keyring.PrimaryId()
+	rotated := 0
+	after := ""
+
+	for {
+		records, err := rj.store.FindStale(ctx, primary, after, rj.batchSize)
+		if err != nil {
+			return rotated, err
+		}
+
+		for _, record := range records {
+			if err := rj.rotate(ctx, record); err != nil {
+				rj.logger.WithCtx(ctx).Error("failed to re-encrypt record", "id", record.Id, "error", err.Error())
+				continue
+			}
+
+			rotated++
+		}
+
+		if len(records) < rj.batchSize {
+			return rotated, nil
+		}
+
+		after = records[len(records)-1].Id
+	}
+}
+
+func (rj *RotationJob) runDocuments(ctx context.Context, store DocumentRotationStore) (int, error) {
+	primary := rj.keyring.PrimaryId()
+	rotated := 0
+	after := ""
+
+	for {
+		ids, err := store.FindStale(ctx, primary, after, rj.batchSize)
+		if err != nil {
+			return rotated, err
+		}
+
+		for _, id := range ids {
+			if err := store.Rotate(ctx, id); err != nil {
+				rj.logger.WithCtx(ctx).Error("failed to re-encrypt document", "id", id, "error", err.Error())
+				continue
+			}
+
+			rotated++
+		}
+
+		if len(ids) < rj.batchSize {
+			return rotated, nil
+		}
+
+		after = ids[len(ids)-1]
+	}
+}
+
+func (rj *RotationJob) rotate(ctx context.Context, record RotationRecord) error {
+	text, err := rj.encryption.Decrypt(ctx, &record.Encrypted)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := rj.encryption.Encrypt(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	return rj.store.Replace(ctx, record.Id, record.Encrypted, *encrypted)
+}
+
+// Start runs the job every interval until ctx is done.
+func (rj *RotationJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rotated, err := rj.RunOnce(ctx)
+		if err != nil {
+			rj.logger.WithCtx(ctx).Error("failed to rotate encryption keys", "error", err.Error())
+		} else if rotated > 0 {
+			rj.logger.WithCtx(ctx).Info("re-encrypted records", "count", rotated, "key_id", rj.keyring.PrimaryId())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package exception
+
+import (
+	"net/http"
+)
+
+// FieldError describes why a single field of the request failed validation.
+// Field is the name the client sent, e.g. the JSON or query name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+	Value   any    `json:"value,omitempty"`
+}
+
+// Error is the error returned across layers. Its message is the error code, so
+// it can still be compared with the codes, while the cause keeps the original
+// error for logs and errors.Is/As.
+type Error struct {
+	Code    string
+	Message string
+	Status  int
+	Details []FieldError
+	cause   error
+}
+
+var (
+	ErrNotFound         = New(CodeNotFound)
+	ErrDatabaseFailed   = New(CodeDatabaseFailed)
+	ErrValidationFailed = New(CodeValidationFailed)
+	ErrInternal         = New(CodeInternal)
+	ErrPermission       = New(CodePermission)
+	ErrTimeout          = New(CodeTimeout)
+)
+
+// New returns the error of the code with its registered message and status.
+// Codes not registered are answered as internal server errors.
+func New(code string) *Error {
+	definition, ok := Lookup(code)
+	if !ok {
+		return &Error{Code: code, Message: Http(code).ErrorMessage, Status: http.StatusInternalServerError}
+	}
+
+	return &Error{Code: code, Message: definition.Message, Status: definition.Status}
+}
+
+// Wrap returns the error of the code caused by err.
+func Wrap(code string, err error) *Error {
+	wrapped := New(code)
+	wrapped.cause = err
+	return wrapped
+}
+
+func NewValidationError(details []FieldError) *Error {
+	return New(CodeValidationFailed).WithDetails(details)
+}
+
+func (e *Error) Error() string {
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors of the same code, e.g. errors.Is(err, ErrNotFound).
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && e.Code == other.Code
+}
+
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+func (e *Error) WithDetails(details []FieldError) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func (e *Error) withStatus(status int) *Error {
+	copied := *e
+	copied.Status = status
+	return &copied
+}
+
+func (e *Error) HTTP() *HTTPException {
+	return &HTTPException{
+		Ok:            false,
+		StatusCode:    e.Status,
+		StatusMessage: http.StatusText(e.Status),
+		ErrorCode:     e.Code,
+		ErrorMessage:  e.Message,
+		Details:       e.Details,
+	}
+}
@@ -0,0 +1,56 @@
+package exception
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	t.Run("should build the error of the code with its default message and status", func(t *testing.T) {
+		err := New(CodePermission)
+
+		assert.Equal(t, CodePermission, err.Error(), "should return the code as message")
+		assert.Equal(t, "User not allowed to perform this action", err.Message)
+		assert.Equal(t, 403, err.Status)
+	})
+
+	t.Run("should answer unknown codes with internal server error", func(t *testing.T) {
+		err := New("EUNKNOWN")
+
+		assert.Equal(t, "EUNKNOWN", err.Code, "should keep the code")
+		assert.Equal(t, 500, err.Status)
+	})
+
+	t.Run("should match errors of the same code", func(t *testing.T) {
+		err := fmt.Errorf("getting user: %w", New(CodeNotFound))
+
+		assert.ErrorIs(t, err, ErrNotFound, "should match the wrapped error")
+		assert.NotErrorIs(t, err, ErrTimeout, "should not match other codes")
+
+		var typed *Error
+		assert.True(t, errors.As(err, &typed), "should be found with errors.As")
+		assert.Equal(t, CodeNotFound, typed.Code)
+	})
+
+	t.Run("should keep the cause", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := Wrap(CodeDatabaseFailed, cause)
+
+		assert.ErrorIs(t, err, cause, "should unwrap to the cause")
+		assert.ErrorIs(t, err, ErrDatabaseFailed, "should match its code")
+	})
+
+	t.Run("should not change the receiver when adding details or message", func(t *testing.T) {
+		details := []FieldError{{Field: "email", Tag: "required", Message: "email is required"}}
+
+		err := ErrValidationFailed.WithDetails(details).WithMessage("Invalid user")
+
+		assert.Equal(t, details, err.Details)
+		assert.Equal(t, "Invalid user", err.Message)
+		assert.Nil(t, ErrValidationFailed.Details, "should not change the sentinel")
+		assert.Equal(t, "Invalid input for one or more required attributes", ErrValidationFailed.Message)
+	})
+}
@@ -2,14 +2,17 @@ package exception
 
 import (
 	"net/http"
+
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
 )
 
 type HTTPException struct {
-	Ok            bool   `json:"ok"`
-	StatusCode    int    `json:"status_code"`
-	StatusMessage string `json:"status_message"`
-	ErrorCode     string `json:"error_code"`
-	ErrorMessage  string `json:"error_message"`
+	Ok            bool         `json:"ok"`
+	StatusCode    int          `json:"status_code"`
+	StatusMessage string       `json:"status_message"`
+	ErrorCode     string       `json:"error_code"`
+	ErrorMessage  string       `json:"error_message"`
+	Details       []FieldError `json:"details,omitempty"`
 }
 
 const (
@@ -18,18 +21,63 @@ const (
 	CodeValidationFailed = "EVALIDATION"
 	CodeInternal         = "EINTERNAL"
 	CodePermission       = "EPERMISSION"
+	CodeTimeout          = "ETIMEOUT"
+	// CodeHttp is any other client error of the HTTP layer, e.g. a method not
+	// allowed or a request body too large.
+	CodeHttp = "EHTTP"
 )
 
-func Http(code string) *HTTPException {
-	codes := map[string]bool{
-		(CodeNotFound):         true,
-		(CodeDatabaseFailed):   true,
-		(CodeValidationFailed): true,
-		(CodeInternal):         true,
-		(CodePermission):       true,
+// sharedDefinitions are the codes every service answers with.
+func sharedDefinitions() []Definition {
+	return []Definition{
+		{
+			Code:     CodeNotFound,
+			Status:   http.StatusNotFound,
+			Message:  "Entity not found",
+			Messages: map[string]string{i18n.Portuguese: "Entidade não encontrada"},
+		},
+		{
+			Code:     CodeDatabaseFailed,
+			Status:   http.StatusInternalServerError,
+			Message:  "Failed to communicate with database",
+			Messages: map[string]string{i18n.Portuguese: "Falha ao se comunicar com o banco de dados"},
+		},
+		{
+			Code:     CodeValidationFailed,
+			Status:   http.StatusBadRequest,
+			Message:  "Invalid input for one or more required attributes",
+			Messages: map[string]string{i18n.Portuguese: "Entrada inválida para um ou mais atributos obrigatórios"},
+		},
+		{
+			Code:     CodeInternal,
+			Status:   http.StatusInternalServerError,
+			Message:  "An expected error occurred and the server could not deal with it",
+			Messages: map[string]string{i18n.Portuguese: "Ocorreu um erro inesperado e o servidor não conseguiu tratá-lo"},
+		},
+		{
+			Code:     CodePermission,
+			Status:   http.StatusForbidden,
+			Message:  "User not allowed to perform this action",
+			Messages: map[string]string{i18n.Portuguese: "Usuário sem permissão para realizar esta ação"},
+		},
+		{
+			Code:     CodeTimeout,
+			Status:   http.StatusGatewayTimeout,
+			Message:  "The operation took too long to complete",
+			Messages: map[string]string{i18n.Portuguese: "A operação demorou demais para ser concluída"},
+		},
+		{
+			Code:     CodeHttp,
+			Status:   http.StatusBadRequest,
+			Message:  "The request could not be handled",
+			Messages: map[string]string{i18n.Portuguese: "A requisição não pôde ser tratada"},
+		},
 	}
+}
 
-	if !codes[code] {
+func Http(code string) *HTTPException {
+	definition, ok := Lookup(code)
+	if !ok {
 		return &HTTPException{
 			ErrorCode:     "EINVALID",
 			ErrorMessage:  "Invalid error code",
@@ -38,38 +86,15 @@ func Http(code string) *HTTPException {
 		}
 	}
 
-	return errorCodeToStruct(code)
+	return &HTTPException{
+		Ok:            false,
+		StatusCode:    definition.Status,
+		StatusMessage: http.StatusText(definition.Status),
+		ErrorCode:     definition.Code,
+		ErrorMessage:  definition.Message,
+	}
 }
 
 func (h *HTTPException) Error() string {
 	return h.ErrorMessage
 }
-
-func errorCodeToStruct(code string) *HTTPException {
-	response := HTTPException{Ok: false, ErrorCode: code}
-
-	switch code {
-	case CodeNotFound:
-		response.StatusMessage = "Not Found"
-		response.StatusCode = http.StatusNotFound
-		response.ErrorMessage = "Entity not found"
-	case CodeDatabaseFailed:
-		response.StatusMessage = "Internal Server Error"
-		response.StatusCode = http.StatusInternalServerError
-		response.ErrorMessage = "Failed to communicate with database"
-	case CodeValidationFailed:
-		response.StatusMessage = "Bad Request"
-		response.StatusCode = http.StatusBadRequest
-		response.ErrorMessage = "Invalid input for one or more required attributes"
-	case CodeInternal:
-		response.StatusMessage = "Internal Server Error"
-		response.StatusCode = http.StatusInternalServerError
-		response.ErrorMessage = "An expected error occurred and the server could not deal with it"
-	case CodePermission:
-		response.StatusMessage = "Forbidden"
-		response.StatusCode = http.StatusForbidden
-		response.ErrorMessage = "User not allowed to perform this action"
-	}
-
-	return &response
-}
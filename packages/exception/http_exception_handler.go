@@ -1,10 +1,96 @@
 package exception
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
 )
 
+// HttpExceptionHandler answers with an HTTPException, or with a Problem when
+// the client prefers application/problem+json.
 func HttpExceptionHandler(c *fiber.Ctx, err error) error {
-	httpException := Http(err.Error())
+	httpException := toHTTPException(err)
+	localize(httpException, i18n.FromContext(c.UserContext()))
+	correlationId := correlation.FromContext(c.UserContext())
+
+	if httpException.StatusCode >= http.StatusInternalServerError {
+		logger.Default().WithCtx(c.UserContext()).Error("request failed", "error_code", httpException.ErrorCode, "cause", causeOf(err))
+	}
+
+	c.Vary(fiber.HeaderAccept)
+
+	if c.Accepts(fiber.MIMEApplicationJSON, ProblemContentType) == ProblemContentType {
+		return c.Status(httpException.StatusCode).JSON(httpException.Problem(correlationId), ProblemContentType)
+	}
+
 	return c.Status(httpException.StatusCode).JSON(httpException)
 }
+
+// toHTTPException answers typed errors with their own status, Fiber errors,
+// e.g. an unknown route, with the Fiber status and plain error codes with
+// their default status. Anything else is an internal error.
+func toHTTPException(err error) *HTTPException {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.HTTP()
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return New(statusToCode(fiberError.Code)).
+			WithMessage(fiberError.Message).
+			withStatus(fiberError.Code).
+			HTTP()
+	}
+
+	if _, ok := Lookup(err.Error()); ok {
+		return New(err.Error()).HTTP()
+	}
+
+	return New(CodeInternal).HTTP()
+}
+
+// localize answers the registered message of the code in the language, unless
+// the error was given a message of its own.
+func localize(httpException *HTTPException, language string) {
+	definition, ok := Lookup(httpException.ErrorCode)
+	if !ok || httpException.ErrorMessage != definition.Message {
+		return
+	}
+
+	if message, ok := definition.Messages[language]; ok {
+		httpException.ErrorMessage = message
+	}
+}
+
+func statusToCode(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return CodePermission
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+
+	if status < http.StatusInternalServerError {
+		return CodeHttp
+	}
+
+	return CodeInternal
+}
+
+func causeOf(err error) string {
+	cause := err
+	for unwrapped := errors.Unwrap(cause); unwrapped != nil; unwrapped = errors.Unwrap(cause) {
+		cause = unwrapped
+	}
+
+	return cause.Error()
+}
@@ -1,10 +1,19 @@
 package exception
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
 )
@@ -16,4 +25,170 @@ func TestHttpExceptionHandler(t *testing.T) {
 		err := HttpExceptionHandler(ctx, errors.New(CodeValidationFailed))
 		assert.Nil(t, err, "should not return error")
 	})
+
+	t.Run("should answer with gateway timeout when an operation times out", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		err := HttpExceptionHandler(ctx, errors.New(CodeTimeout))
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 504, ctx.Response().StatusCode(), "should return the timeout status")
+	})
+
+	t.Run("should answer with the details of a validation error", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		details := []FieldError{{Field: "email", Tag: "email", Message: "email must be a valid email", Value: "google"}}
+
+		err := HttpExceptionHandler(ctx, NewValidationError(details))
+
+		var body HTTPException
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 400, ctx.Response().StatusCode(), "should return the validation status")
+		assert.Equal(t, CodeValidationFailed, body.ErrorCode, "should return the validation code")
+		assert.Equal(t, "email", body.Details[0].Field, "should return the details")
+	})
+
+	t.Run("should omit details of other errors", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+
+		HttpExceptionHandler(ctx, errors.New(CodeNotFound))
+
+		assert.NotContains(t, string(ctx.Response().Body()), "details", "should not return details")
+	})
+
+	t.Run("should answer with the status of fiber errors", func(t *testing.T) {
+		fbr := fiber.New(fiber.Config{ErrorHandler: HttpExceptionHandler})
+		fbr.Post("/", func(c *fiber.Ctx) error { return c.SendStatus(201) })
+		fbr.Post("/large", func(c *fiber.Ctx) error { return fiber.ErrRequestEntityTooLarge })
+
+		for _, tc := range []struct {
+			method string
+			path   string
+			body   string
+			status int
+			code   string
+		}{
+			{method: "GET", path: "/unknown", status: 404, code: CodeNotFound},
+			{method: "PUT", path: "/", status: 405, code: CodeHttp},
+			{method: "POST", path: "/large", body: "a body too large", status: 413, code: CodeHttp},
+		} {
+			response, err := fbr.Test(httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var body HTTPException
+			json.NewDecoder(response.Body).Decode(&body)
+
+			assert.Equal(t, tc.status, response.StatusCode, "should return the fiber status")
+			assert.Equal(t, tc.status, body.StatusCode, "should return the fiber status in the body")
+			assert.Equal(t, tc.code, body.ErrorCode, "should return the code of the status")
+		}
+	})
+
+	t.Run("should answer typed errors with their own status and message", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+
+		err := fmt.Errorf("deleting user: %w", New(CodeNotFound).WithMessage("User not found"))
+		HttpExceptionHandler(ctx, err)
+
+		var body HTTPException
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Equal(t, 404, ctx.Response().StatusCode(), "should return the error status")
+		assert.Equal(t, "User not found", body.ErrorMessage, "should return the error message")
+	})
+
+	t.Run("should answer unknown errors as internal errors logging their cause", func(t *testing.T) {
+		var logs bytes.Buffer
+		defaultLogger := logger.Default()
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Output: &logs}))
+		defer logger.SetDefault(defaultLogger)
+
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.SetUserContext(correlation.WithId(context.TODO(), "b7f1c2"))
+
+		HttpExceptionHandler(ctx, errors.New("connection refused"))
+
+		var body HTTPException
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Equal(t, 500, ctx.Response().StatusCode(), "should return internal server error")
+		assert.Equal(t, CodeInternal, body.ErrorCode, "should return the internal code")
+		assert.Contains(t, logs.String(), "connection refused", "should log the cause")
+		assert.Contains(t, logs.String(), "correlation_id=b7f1c2", "should log the correlation id as a field")
+	})
+
+	t.Run("should answer with a problem when the client prefers problem+json", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.Request().Header.Set(fiber.HeaderAccept, ProblemContentType)
+		ctx.SetUserContext(correlation.WithId(context.Background(), "b7f1c2"))
+		details := []FieldError{{Field: "email", Tag: "email", Message: "email must be a valid email", Value: "google"}}
+
+		err := HttpExceptionHandler(ctx, NewValidationError(details))
+
+		var body Problem
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, ProblemContentType, string(ctx.Response().Header.ContentType()), "should return the problem content type")
+		assert.Equal(t, 400, ctx.Response().StatusCode(), "should return the validation status")
+		assert.Equal(t, Problem{
+			Type:     ProblemTypeBase + CodeValidationFailed,
+			Title:    "Bad Request",
+			Status:   400,
+			Detail:   "Invalid input for one or more required attributes",
+			Instance: "b7f1c2",
+			Code:     CodeValidationFailed,
+			Errors:   details,
+		}, body, "should return the problem members")
+	})
+
+	t.Run("should keep the legacy shape by default", func(t *testing.T) {
+		for _, accept := range []string{"", "*/*", fiber.MIMEApplicationJSON, "application/json, application/problem+json;q=0.5"} {
+			fbr := fiber.New()
+			ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+			ctx.Request().Header.Set(fiber.HeaderAccept, accept)
+
+			HttpExceptionHandler(ctx, errors.New(CodeNotFound))
+
+			var body HTTPException
+			json.Unmarshal(ctx.Response().Body(), &body)
+
+			assert.Equal(t, fiber.MIMEApplicationJSON, string(ctx.Response().Header.ContentType()), "should return the json content type")
+			assert.Equal(t, CodeNotFound, body.ErrorCode, "should return the legacy shape")
+		}
+	})
+
+	t.Run("should answer the message of the code in the language of the request", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.SetUserContext(i18n.WithLanguage(context.Background(), i18n.Portuguese))
+
+		HttpExceptionHandler(ctx, errors.New(CodeNotFound))
+
+		var body HTTPException
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Equal(t, "Entidade não encontrada", body.ErrorMessage, "should return the translated message")
+	})
+
+	t.Run("should keep messages given to the error untranslated", func(t *testing.T) {
+		fbr := fiber.New()
+		ctx := fbr.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.SetUserContext(i18n.WithLanguage(context.Background(), i18n.Portuguese))
+
+		HttpExceptionHandler(ctx, New(CodeNotFound).WithMessage("User not found"))
+
+		var body HTTPException
+		json.Unmarshal(ctx.Response().Body(), &body)
+
+		assert.Equal(t, "User not found", body.ErrorMessage, "should return the given message")
+	})
 }
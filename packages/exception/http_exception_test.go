@@ -31,39 +31,46 @@ func TestException_Http(t *testing.T) {
 	})
 }
 
-func TestException_errorCodeToStruct(t *testing.T) {
+func TestException_RegisteredCodes(t *testing.T) {
 	t.Run("should parse error code ENOTFOUND", func(t *testing.T) {
-		structure := errorCodeToStruct(CodeNotFound)
+		structure := Http(CodeNotFound)
 
 		assert.Equal(t, structure.StatusCode, 404)
 		assert.Equal(t, structure.ErrorMessage, "Entity not found")
 	})
 
 	t.Run("should parse error code EDBFAILURE", func(t *testing.T) {
-		structure := errorCodeToStruct(CodeDatabaseFailed)
+		structure := Http(CodeDatabaseFailed)
 
 		assert.Equal(t, structure.StatusCode, 500)
 		assert.Equal(t, structure.ErrorMessage, "Failed to communicate with database")
 	})
 
 	t.Run("should parse error code EVALIDATION", func(t *testing.T) {
-		structure := errorCodeToStruct(CodeValidationFailed)
+		structure := Http(CodeValidationFailed)
 
 		assert.Equal(t, structure.StatusCode, 400)
 		assert.Equal(t, structure.ErrorMessage, "Invalid input for one or more required attributes")
 	})
 
 	t.Run("should parse error code EINTERNAL", func(t *testing.T) {
-		structure := errorCodeToStruct(CodeInternal)
+		structure := Http(CodeInternal)
 
 		assert.Equal(t, structure.StatusCode, 500)
 		assert.Equal(t, structure.ErrorMessage, "An expected error occurred and the server could not deal with it")
 	})
 
 	t.Run("should parse error code EPERMISSION", func(t *testing.T) {
-		structure := errorCodeToStruct(CodePermission)
+		structure := Http(CodePermission)
 
 		assert.Equal(t, structure.StatusCode, 403)
 		assert.Equal(t, structure.ErrorMessage, "User not allowed to perform this action")
 	})
+
+	t.Run("should parse error code ETIMEOUT", func(t *testing.T) {
+		structure := Http(CodeTimeout)
+
+		assert.Equal(t, structure.StatusCode, 504)
+		assert.Equal(t, structure.ErrorMessage, "The operation took too long to complete")
+	})
 }
@@ -0,0 +1,33 @@
+package exception
+
+const (
+	ProblemContentType = "application/problem+json"
+	// ProblemTypeBase points the problem type of a code to its entry in the
+	// error codes catalog.
+	ProblemTypeBase = "/api/errors#"
+)
+
+// Problem is the RFC 7807 shape of an HTTPException, the code and the
+// validation details are carried as extension members.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// Problem takes the correlation id of the request as the problem instance.
+func (h *HTTPException) Problem(instance string) *Problem {
+	return &Problem{
+		Type:     ProblemTypeBase + h.ErrorCode,
+		Title:    h.StatusMessage,
+		Status:   h.StatusCode,
+		Detail:   h.ErrorMessage,
+		Instance: instance,
+		Code:     h.ErrorCode,
+		Errors:   h.Details,
+	}
+}
@@ -0,0 +1,149 @@
+package exception
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+)
+
+// Definition describes an error code: the HTTP status it is answered with,
+// its default message and, optionally, the message by language tag, e.g.
+// "pt-BR".
+type Definition struct {
+	Code     string            `json:"code"`
+	Status   int               `json:"status"`
+	Message  string            `json:"message"`
+	Messages map[string]string `json:"messages,omitempty"`
+}
+
+var codePattern = regexp.MustCompile(`^E[A-Z0-9_]+$`)
+
+// Registry holds the known error codes. Services register their own codes at
+// init, besides the ones shared by every service.
+type Registry struct {
+	mutex       sync.RWMutex
+	definitions map[string]Definition
+}
+
+func NewRegistry() *Registry {
+	return &Registry{definitions: map[string]Definition{}}
+}
+
+// Register fails when a code is malformed, has no valid status or message, has
+// messages in unsupported languages or is already registered, leaving the
+// registry as it was.
+func (r *Registry) Register(definitions ...Definition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	seen := map[string]bool{}
+	for _, definition := range definitions {
+		if !codePattern.MatchString(definition.Code) {
+			return fmt.Errorf("error code %q must match %s", definition.Code, codePattern)
+		}
+
+		if http.StatusText(definition.Status) == "" || definition.Status < http.StatusBadRequest {
+			return fmt.Errorf("error code %q has an invalid status %d", definition.Code, definition.Status)
+		}
+
+		if definition.Message == "" {
+			return fmt.Errorf("error code %q has no message", definition.Code)
+		}
+
+		for language := range definition.Messages {
+			if !i18n.Supported(language) {
+				return fmt.Errorf("error code %q has a message in the unsupported language %q", definition.Code, language)
+			}
+		}
+
+		if _, ok := r.definitions[definition.Code]; ok || seen[definition.Code] {
+			return fmt.Errorf("error code %q is already registered", definition.Code)
+		}
+
+		seen[definition.Code] = true
+	}
+
+	for _, definition := range definitions {
+		r.definitions[definition.Code] = definition
+	}
+
+	return nil
+}
+
+func (r *Registry) Lookup(code string) (Definition, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	definition, ok := r.definitions[code]
+	return definition, ok
+}
+
+// Definitions returns every registered code sorted by code.
+func (r *Registry) Definitions() []Definition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	definitions := make([]Definition, 0, len(r.definitions))
+	for _, definition := range r.definitions {
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Code < definitions[j].Code
+	})
+
+	return definitions
+}
+
+// Catalog is the answer of CatalogHandler.
+type Catalog struct {
+	Errors []Definition `json:"errors"`
+}
+
+// CatalogHandler lists the registered codes, so clients can tell what each of
+// them means.
+func (r *Registry) CatalogHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(Catalog{Errors: r.Definitions()})
+	}
+}
+
+var defaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	registry := NewRegistry()
+	if err := registry.Register(sharedDefinitions()...); err != nil {
+		panic(err)
+	}
+
+	return registry
+}
+
+func Register(definitions ...Definition) error {
+	return defaultRegistry.Register(definitions...)
+}
+
+// MustRegister is Register for package init, where a duplicated code is a
+// programming error.
+func MustRegister(definitions ...Definition) {
+	if err := defaultRegistry.Register(definitions...); err != nil {
+		panic(err)
+	}
+}
+
+func Lookup(code string) (Definition, bool) {
+	return defaultRegistry.Lookup(code)
+}
+
+func Definitions() []Definition {
+	return defaultRegistry.Definitions()
+}
+
+func CatalogHandler() fiber.Handler {
+	return defaultRegistry.CatalogHandler()
+}
@@ -0,0 +1,116 @@
+package exception
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("should register and look up codes", func(t *testing.T) {
+		registry := NewRegistry()
+
+		err := registry.Register(Definition{
+			Code:     "EOUTOFSTOCK",
+			Status:   409,
+			Message:  "Product out of stock",
+			Messages: map[string]string{"pt-BR": "Produto sem estoque"},
+		})
+
+		definition, ok := registry.Lookup("EOUTOFSTOCK")
+
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, ok, "should find the code")
+		assert.Equal(t, 409, definition.Status)
+		assert.Equal(t, "Produto sem estoque", definition.Messages["pt-BR"])
+	})
+
+	t.Run("should return error when the code is already registered", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(Definition{Code: "ECONFLICT", Status: 409, Message: "Conflict"})
+
+		err := registry.Register(
+			Definition{Code: "EOTHER", Status: 400, Message: "Other"},
+			Definition{Code: "ECONFLICT", Status: 409, Message: "Conflict again"},
+		)
+
+		_, ok := registry.Lookup("EOTHER")
+		definition, _ := registry.Lookup("ECONFLICT")
+
+		assert.NotNil(t, err, "should return error")
+		assert.False(t, ok, "should not register any code of the batch")
+		assert.Equal(t, "Conflict", definition.Message, "should keep the first definition")
+	})
+
+	t.Run("should return error when the definition is invalid", func(t *testing.T) {
+		registry := NewRegistry()
+
+		for _, definition := range []Definition{
+			{Code: "conflict", Status: 409, Message: "Conflict"},
+			{Code: "ECONFLICT", Status: 200, Message: "Conflict"},
+			{Code: "ECONFLICT", Status: 999, Message: "Conflict"},
+			{Code: "ECONFLICT", Status: 409},
+			{Code: "ECONFLICT", Status: 409, Message: "Conflict", Messages: map[string]string{"fr": "Conflit"}},
+			{Code: "EDUP", Status: 409, Message: "Dup"},
+		} {
+			err := registry.Register(definition, Definition{Code: "EDUP", Status: 409, Message: "Dup"})
+			assert.NotNil(t, err, "should return error for %+v", definition)
+		}
+
+		assert.Empty(t, registry.Definitions(), "should not register invalid codes")
+	})
+
+	t.Run("should answer registered codes with their definition", func(t *testing.T) {
+		MustRegister(Definition{Code: "ETESTREGISTERED", Status: 422, Message: "Registered"})
+
+		structure := Http("ETESTREGISTERED")
+		err := New("ETESTREGISTERED")
+
+		assert.Equal(t, 422, structure.StatusCode)
+		assert.Equal(t, "Unprocessable Entity", structure.StatusMessage)
+		assert.Equal(t, "Registered", err.Message)
+		assert.Panics(t, func() {
+			MustRegister(Definition{Code: "ETESTREGISTERED", Status: 422, Message: "Registered"})
+		}, "should panic on duplicates")
+	})
+
+	t.Run("should list the codes in the catalog", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(
+			Definition{Code: "EB", Status: 400, Message: "B"},
+			Definition{Code: "EA", Status: 404, Message: "A"},
+		)
+
+		fbr := fiber.New()
+		fbr.Get("/errors", registry.CatalogHandler())
+
+		response, _ := fbr.Test(httptest.NewRequest("GET", "/errors", nil), -1)
+		body, _ := io.ReadAll(response.Body)
+
+		var catalog struct {
+			Errors []Definition `json:"errors"`
+		}
+		json.Unmarshal(body, &catalog)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, []Definition{
+			{Code: "EA", Status: 404, Message: "A"},
+			{Code: "EB", Status: 400, Message: "B"},
+		}, catalog.Errors, "should list the codes sorted")
+	})
+
+	t.Run("should register the codes shared by every service", func(t *testing.T) {
+		codes := []string{}
+		for _, definition := range Definitions() {
+			codes = append(codes, definition.Code)
+		}
+
+		assert.Subset(t, codes, []string{
+			CodeNotFound, CodeDatabaseFailed, CodeValidationFailed, CodeInternal, CodePermission, CodeTimeout, CodeHttp,
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+)
+
+const (
+	StatusHealthy      = "healthy"
+	StatusUnhealthy    = "unhealthy"
+	StatusOffline      = "offline"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Failed checks are answered with one of these reasons only, since the ready
+// endpoint is public and checker errors may tell hosts and topology. The
+// errors themselves are logged.
+const (
+	ReasonFailed   = "check failed"
+	ReasonTimedOut = "check timed out"
+)
+
+const (
+	DefaultTimeout  = time.Second * 2
+	DefaultCacheTTL = time.Second * 5
+)
+
+type Checker func(ctx context.Context) error
+
+// Check describes a dependency of the service. Readiness fails when a
+// critical check fails; non critical ones are only reported.
+type Check struct {
+	Name     string
+	Checker  Checker
+	Timeout  time.Duration
+	Critical bool
+}
+
+type CheckResult struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type entry struct {
+	mutex  sync.Mutex
+	check  Check
+	result CheckResult
+}
+
+// Registry runs the checks of the registered dependencies. Results are
+// cached for cacheTTL, so probes do not hit a dependency on every request.
+type Registry struct {
+	mutex        sync.RWMutex
+	entries      []*entry
+	cacheTTL     time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry(cacheTTL time.Duration) *Registry {
+	return &Registry{cacheTTL: cacheTTL}
+}
+
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = append(r.entries, &entry{check: check})
+}
+
+// Shutdown makes readiness fail from now on, so traffic stops being routed
+// to the service while it drains.
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mutex.RLock()
+	entries := append([]*entry{}, r.entries...)
+	r.mutex.RUnlock()
+
+	results := make([]CheckResult, len(entries))
+
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = r.run(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusHealthy, Checks: map[string]CheckResult{}}
+
+	for i, e := range entries {
+		report.Checks[e.check.Name] = results[i]
+
+		if e.check.Critical && results[i].Status != StatusHealthy {
+			report.Status = StatusUnhealthy
+		}
+	}
+
+	if r.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, e *entry) CheckResult {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.result.CheckedAt.IsZero() && time.Since(e.result.CheckedAt) < r.cacheTTL {
+		return e.result
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+
+	result := CheckResult{Status: StatusHealthy, Critical: e.check.Critical, CheckedAt: time.Now()}
+
+	if err := e.check.Checker(timeout); err != nil {
+		logger.Default().WithCtx(ctx).Error("health check failed", "check", e.check.Name, "cause", err.Error())
+
+		result.Status = StatusOffline
+		result.Error = ReasonFailed
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = ReasonTimedOut
+		}
+	}
+
+	e.result = result
+	return result
+}
+
+// LiveHandler answers whether the process is up, regardless of its
+// dependencies, so a failing database never gets the service restarted.
+func (r *Registry) LiveHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(Report{Status: StatusHealthy})
+	}
+}
+
+// ReadyHandler answers 503 when a critical check fails or the service is
+// shutting down.
+func (r *Registry) ReadyHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := r.Ready(c.UserContext())
+
+		if report.Status != StatusHealthy {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+
+		return c.JSON(report)
+	}
+}
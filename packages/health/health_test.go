@@ -0,0 +1,176 @@
+package health_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/health"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func BeforeEach_TestHealth(t *testing.T, registry *health.Registry) *fiber.App {
+	fbr := fiber.New()
+	fbr.Get("/health/live", registry.LiveHandler())
+	fbr.Get("/health/ready", registry.ReadyHandler())
+	return fbr
+}
+
+func probe(t *testing.T, fbr *fiber.App, url string) (int, health.Report) {
+	response, err := fbr.Test(httptest.NewRequest("GET", url, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report health.Report
+	json.Unmarshal(bytes, &report)
+
+	return response.StatusCode, report
+}
+
+func failing(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func healthy(ctx context.Context) error {
+	return nil
+}
+
+func TestRegistry_ReadyHandler(t *testing.T) {
+	t.Run("should answer 200 when every check is healthy", func(t *testing.T) {
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{Name: "mongodb", Checker: healthy, Critical: true})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		status, report := probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, 200, status)
+		assert.Equal(t, health.StatusHealthy, report.Status)
+		assert.Equal(t, health.StatusHealthy, report.Checks["mongodb"].Status)
+	})
+
+	t.Run("should answer 503 when a critical check fails", func(t *testing.T) {
+		var logs bytes.Buffer
+		defaultLogger := logger.Default()
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Output: &logs}))
+		defer logger.SetDefault(defaultLogger)
+
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{Name: "mongodb", Checker: failing, Critical: true})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		status, report := probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, 503, status)
+		assert.Equal(t, health.StatusUnhealthy, report.Status)
+		assert.Equal(t, health.StatusOffline, report.Checks["mongodb"].Status)
+		assert.Equal(t, health.ReasonFailed, report.Checks["mongodb"].Error, "should not answer the checker error")
+		assert.Contains(t, logs.String(), "connection refused", "should log the checker error")
+	})
+
+	t.Run("should answer 200 when only a non critical check fails", func(t *testing.T) {
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{Name: "mongodb", Checker: healthy, Critical: true})
+		registry.Register(health.Check{Name: "cache", Checker: failing})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		status, report := probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, 200, status)
+		assert.Equal(t, health.StatusOffline, report.Checks["cache"].Status, "should report the failure")
+	})
+
+	t.Run("should fail a check that exceeds its timeout", func(t *testing.T) {
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{
+			Name:     "mongodb",
+			Critical: true,
+			Timeout:  time.Millisecond,
+			Checker: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		status, report := probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, 503, status)
+		assert.Equal(t, health.ReasonTimedOut, report.Checks["mongodb"].Error)
+	})
+
+	t.Run("should answer 503 while shutting down", func(t *testing.T) {
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{Name: "mongodb", Checker: healthy, Critical: true})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		registry.Shutdown()
+		status, report := probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, 503, status)
+		assert.Equal(t, health.StatusShuttingDown, report.Status)
+	})
+
+	t.Run("should reuse cached results", func(t *testing.T) {
+		var calls atomic.Int32
+		registry := health.NewRegistry(time.Minute)
+		registry.Register(health.Check{
+			Name:     "mongodb",
+			Critical: true,
+			Checker: func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			},
+		})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		probe(t, fbr, "/health/ready")
+		probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, int32(1), calls.Load(), "should check the dependency once")
+	})
+
+	t.Run("should check again when the cache expires", func(t *testing.T) {
+		var calls atomic.Int32
+		registry := health.NewRegistry(0)
+		registry.Register(health.Check{
+			Name: "mongodb",
+			Checker: func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			},
+		})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		probe(t, fbr, "/health/ready")
+		probe(t, fbr, "/health/ready")
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestRegistry_LiveHandler(t *testing.T) {
+	t.Run("should answer 200 even when dependencies fail", func(t *testing.T) {
+		registry := health.NewRegistry(health.DefaultCacheTTL)
+		registry.Register(health.Check{Name: "mongodb", Checker: failing, Critical: true})
+		fbr := BeforeEach_TestHealth(t, registry)
+
+		status, report := probe(t, fbr, "/health/live")
+
+		assert.Equal(t, 200, status)
+		assert.Equal(t, health.StatusHealthy, report.Status)
+	})
+}
@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type contextKey struct{}
+
+const (
+	English    = "en"
+	Portuguese = "pt-BR"
+)
+
+// Languages are the supported language tags, the first one is the default.
+var Languages = []string{English, Portuguese}
+
+var universal = ut.New(en.New(), en.New(), pt_BR.New())
+
+// Translator returns the universal-translator of the language tag, or of the
+// default language when the tag is not supported.
+func Translator(language string) ut.Translator {
+	translator, _ := universal.GetTranslator(strings.ReplaceAll(language, "-", "_"))
+	return translator
+}
+
+func Supported(language string) bool {
+	for _, supported := range Languages {
+		if supported == language {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add registers the messages of a language by key, e.g. "user.not_found",
+// with params written as {0}, {1} and so on. Messages must be added at init,
+// adding a key twice fails.
+func Add(language string, messages map[string]string) error {
+	if !Supported(language) {
+		return fmt.Errorf("language %q is not supported", language)
+	}
+
+	translator := Translator(language)
+	for key, text := range messages {
+		if err := translator.Add(key, text, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MustAdd is Add for package init, where a duplicated key is a programming
+// error.
+func MustAdd(language string, messages map[string]string) {
+	if err := Add(language, messages); err != nil {
+		panic(err)
+	}
+}
+
+// T translates the key to the language, falling back to the default language
+// and then to the key itself when there is no message for it.
+func T(language string, key string, params ...string) string {
+	for _, translator := range []ut.Translator{Translator(language), Translator(English)} {
+		if text, err := translate(translator, key, params); err == nil {
+			return text
+		}
+	}
+
+	return key
+}
+
+// translate guards against messages with more params than given, which would
+// make the translator panic.
+func translate(translator ut.Translator, key string, params []string) (text string, err error) {
+	defer func() {
+		if recover() != nil {
+			text, err = "", fmt.Errorf("message %q has more params than given", key)
+		}
+	}()
+
+	return translator.T(key, params...)
+}
+
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, contextKey{}, language)
+}
+
+// FromContext returns the language of ctx or the default language.
+func FromContext(ctx context.Context) string {
+	if language, ok := ctx.Value(contextKey{}).(string); ok {
+		return language
+	}
+
+	return English
+}
+
+// New returns a middleware that picks the language of the request from the
+// Accept-Language header, answers it as Content-Language and stores it in
+// the user context. Any Portuguese variant is answered in pt-BR.
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		language := c.AcceptsLanguages(English, Portuguese, "pt")
+		switch language {
+		case "pt":
+			language = Portuguese
+		case "":
+			language = English
+		}
+
+		c.Set(fiber.HeaderContentLanguage, language)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		c.SetUserContext(WithLanguage(c.UserContext(), language))
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package i18n_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestI18n_T(t *testing.T) {
+	i18n.MustAdd(i18n.English, map[string]string{
+		"test.greeting": "Hello, {0}",
+		"test.farewell": "Bye",
+	})
+	i18n.MustAdd(i18n.Portuguese, map[string]string{"test.greeting": "Olá, {0}"})
+
+	t.Run("should translate the key to the language", func(t *testing.T) {
+		assert.Equal(t, "Olá, Italo", i18n.T(i18n.Portuguese, "test.greeting", "Italo"), "should return the pt-BR message")
+		assert.Equal(t, "Hello, Italo", i18n.T(i18n.English, "test.greeting", "Italo"), "should return the en message")
+	})
+
+	t.Run("should fall back to the default language and then to the key", func(t *testing.T) {
+		assert.Equal(t, "Bye", i18n.T(i18n.Portuguese, "test.farewell"), "should return the en message")
+		assert.Equal(t, "Hello, Italo", i18n.T("fr", "test.greeting", "Italo"), "should return the en message")
+		assert.Equal(t, "test.unknown", i18n.T(i18n.Portuguese, "test.unknown"), "should return the key")
+	})
+
+	t.Run("should not panic when params are missing", func(t *testing.T) {
+		assert.Equal(t, "test.greeting", i18n.T(i18n.English, "test.greeting"), "should return the key")
+	})
+
+	t.Run("should return error when adding a key twice or an unsupported language", func(t *testing.T) {
+		assert.NotNil(t, i18n.Add(i18n.English, map[string]string{"test.farewell": "Goodbye"}), "should not override the key")
+		assert.NotNil(t, i18n.Add("fr", map[string]string{"test.farewell": "Au revoir"}), "should not add the language")
+	})
+}
+
+func TestI18n_New(t *testing.T) {
+	var language string
+	fbr := fiber.New()
+	fbr.Use(i18n.New())
+	fbr.Get("/", func(c *fiber.Ctx) error {
+		language = i18n.FromContext(c.UserContext())
+		return c.SendStatus(200)
+	})
+
+	for _, tc := range []struct {
+		accept   string
+		language string
+	}{
+		{accept: "", language: i18n.English},
+		{accept: "pt-BR,pt;q=0.9,en;q=0.8", language: i18n.Portuguese},
+		{accept: "pt-PT", language: i18n.Portuguese},
+		{accept: "en-US,en;q=0.9", language: i18n.English},
+		{accept: "fr-FR", language: i18n.English},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, tc.accept)
+		response, err := fbr.Test(req, -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, tc.language, language, "should pick %s for %q", tc.language, tc.accept)
+		assert.Equal(t, tc.language, response.Header.Get(fiber.HeaderContentLanguage), "should answer the language")
+	}
+}
@@ -0,0 +1,185 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+)
+
+const (
+	CodeInvalidKey      = "EIDEMPOTENCYKEY"
+	CodeKeyReused       = "EIDEMPOTENCYREUSED"
+	CodeRequestInFlight = "EIDEMPOTENCYINFLIGHT"
+
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderReplayed marks the responses answered from a previous request.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+func init() {
+	exception.MustRegister(exception.Definition{
+		Code:     CodeInvalidKey,
+		Status:   http.StatusBadRequest,
+		Message:  "The Idempotency-Key header must have up to 255 characters",
+		Messages: map[string]string{i18n.Portuguese: "O cabeçalho Idempotency-Key deve ter até 255 caracteres"},
+	})
+	exception.MustRegister(exception.Definition{
+		Code:     CodeKeyReused,
+		Status:   http.StatusUnprocessableEntity,
+		Message:  "The Idempotency-Key was already used with a different request",
+		Messages: map[string]string{i18n.Portuguese: "A Idempotency-Key já foi usada com uma requisição diferente"},
+	})
+	exception.MustRegister(exception.Definition{
+		Code:     CodeRequestInFlight,
+		Status:   http.StatusConflict,
+		Message:  "A request with the same Idempotency-Key is in progress",
+		Messages: map[string]string{i18n.Portuguese: "Uma requisição com a mesma Idempotency-Key está em andamento"},
+	})
+}
+
+// Config sets how long responses are replayed, TTL, and how long a request
+// holds its key before a retry may run it again, LockTimeout, so keys of
+// requests whose process died are not held until the TTL. Keys are scoped to
+// the Caller, so a client can not replay the responses of another one by
+// reusing its key.
+type Config struct {
+	TTL         time.Duration
+	LockTimeout time.Duration
+	Caller      ratelimit.KeyFunc
+}
+
+type Idempotency struct {
+	store  Store
+	config Config
+	now    func() time.Time
+}
+
+// New fails when the config has no TTL or lock timeout. Configs without a
+// caller scope the keys by IP.
+func New(store Store, config Config) (*Idempotency, error) {
+	if config.TTL <= 0 || config.LockTimeout <= 0 {
+		return nil, fmt.Errorf("idempotency must have a ttl and a lock timeout")
+	}
+
+	if config.Caller == nil {
+		config.Caller = ratelimit.ByIP
+	}
+
+	return &Idempotency{store: store, config: config, now: time.Now}, nil
+}
+
+// Middleware runs a request once per caller, Idempotency-Key and route,
+// replaying its response to the retries. Requests without the header are let
+// through. Retries with a different method, path or body are answered with
+// EIDEMPOTENCYREUSED and the ones arriving while the first request runs with
+// EIDEMPOTENCYINFLIGHT. Errors and server failures are not replayed, their
+// key is released so the retry runs again. A request outliving LockTimeout
+// leaves the key to the retry that took it.
+func (i *Idempotency) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > maxKeyLength {
+			return exception.New(CodeInvalidKey)
+		}
+
+		ctx := c.UserContext()
+		now := i.now()
+		lock := &Record{
+			Key:         i.config.Caller(c) + " " + c.Method() + " " + c.Path() + " " + key,
+			Fingerprint: fingerprint(c),
+			ExpiresAt:   now.Add(i.config.LockTimeout).Truncate(time.Millisecond),
+		}
+
+		created, err := i.store.Create(ctx, lock, now)
+		if err != nil {
+			return exception.Wrap(exception.CodeInternal, err)
+		}
+
+		if !created {
+			return i.replay(c, lock)
+		}
+
+		if err := c.Next(); err != nil {
+			i.release(c, lock)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= http.StatusInternalServerError {
+			i.release(c, lock)
+			return nil
+		}
+
+		record := &Record{
+			Key:         lock.Key,
+			Fingerprint: lock.Fingerprint,
+			Completed:   true,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+			ExpiresAt:   now.Add(i.config.TTL),
+		}
+
+		if err := i.store.Complete(ctx, lock, record); err != nil {
+			i.log(c, "complete", err)
+		}
+
+		return nil
+	}
+}
+
+func (i *Idempotency) replay(c *fiber.Ctx, record *Record) error {
+	stored, err := i.store.Get(c.UserContext(), record.Key, i.now())
+	if err != nil {
+		return exception.Wrap(exception.CodeInternal, err)
+	}
+
+	if stored != nil && stored.Fingerprint != record.Fingerprint {
+		return exception.New(CodeKeyReused)
+	}
+
+	if stored == nil || !stored.Completed {
+		return exception.New(CodeRequestInFlight)
+	}
+
+	c.Set(HeaderReplayed, "true")
+	c.Set(fiber.HeaderContentType, stored.ContentType)
+
+	return c.Status(stored.Status).Send(stored.Body)
+}
+
+// release logs instead of answering its failure, the key is then held until
+// the lock timeout.
+func (i *Idempotency) release(c *fiber.Ctx, lock *Record) {
+	if err := i.store.Delete(c.UserContext(), lock); err != nil {
+		i.log(c, "release", err)
+	}
+}
+
+func (i *Idempotency) log(c *fiber.Ctx, operation string, err error) {
+	logger.Default().WithCtx(c.UserContext()).Error("idempotency failed", "operation", operation, "cause", err.Error())
+}
+
+// fingerprint tells the retries of a request from other requests sent with
+// the same key.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,281 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{ *MemoryStore }
+
+func (fs *failingStore) Create(ctx context.Context, record *Record, now time.Time) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+type failingCompleteStore struct{ *MemoryStore }
+
+func (fs *failingCompleteStore) Complete(ctx context.Context, lock *Record, record *Record) error {
+	return errors.New("connection refused")
+}
+
+// BeforeEach_TestIdempotency mounts the middleware before a handler that
+// creates a resource per call, answering the calls made so far. The clock is
+// moved by the returned func.
+func BeforeEach_TestIdempotency(t *testing.T, store Store, handler fiber.Handler) (*fiber.App, func(time.Duration)) {
+	idempotency, err := New(store, Config{TTL: time.Hour, LockTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	idempotency.now = func() time.Time { return now }
+
+	fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	fbr.Post("/users", idempotency.Middleware(), handler)
+
+	return fbr, func(d time.Duration) { now = now.Add(d) }
+}
+
+func creating(calls *int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(201).JSON(fiber.Map{"call": *calls})
+	}
+}
+
+func send(t *testing.T, fbr *fiber.App, key string, body string) (int, string, string) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	response, err := fbr.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, _ := io.ReadAll(response.Body)
+
+	return response.StatusCode, string(bytes), response.Header.Get(HeaderReplayed)
+}
+
+func TestIdempotency_Middleware(t *testing.T) {
+	t.Run("should replay the response to retries of a key", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, NewMemoryStore(), creating(&calls))
+
+		status, body, replayed := send(t, fbr, "key-1", `{"email":"goo@gle.com"}`)
+		assert.Equal(t, 201, status, "should return the handler status")
+		assert.Empty(t, replayed, "should not mark the first response as replayed")
+
+		retryStatus, retryBody, retryReplayed := send(t, fbr, "key-1", `{"email":"goo@gle.com"}`)
+		assert.Equal(t, 1, calls, "should run the handler once")
+		assert.Equal(t, status, retryStatus, "should replay the status")
+		assert.Equal(t, body, retryBody, "should replay the body")
+		assert.Equal(t, "true", retryReplayed, "should mark the response as replayed")
+
+		send(t, fbr, "key-2", `{"email":"goo@gle.com"}`)
+		assert.Equal(t, 2, calls, "should run requests of other keys")
+	})
+
+	t.Run("should let through requests without a key", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, NewMemoryStore(), creating(&calls))
+
+		send(t, fbr, "", `{}`)
+		send(t, fbr, "", `{}`)
+
+		assert.Equal(t, 2, calls, "should run every request")
+	})
+
+	t.Run("should refuse reusing a key with a different body", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, NewMemoryStore(), creating(&calls))
+
+		send(t, fbr, "key-1", `{"email":"goo@gle.com"}`)
+		status, body, _ := send(t, fbr, "key-1", `{"email":"other@gle.com"}`)
+
+		assert.Equal(t, 422, status, "should return unprocessable entity")
+		assert.Contains(t, body, CodeKeyReused, "should return the reuse code")
+		assert.Equal(t, 1, calls, "should not run the handler")
+	})
+
+	t.Run("should refuse keys too long", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, NewMemoryStore(), creating(&calls))
+
+		status, _, _ := send(t, fbr, strings.Repeat("k", maxKeyLength+1), `{}`)
+
+		assert.Equal(t, 400, status, "should return bad request")
+		assert.Equal(t, 0, calls, "should not run the handler")
+	})
+
+	t.Run("should refuse retries while the first request runs", func(t *testing.T) {
+		store := NewMemoryStore()
+		fbr, advance := BeforeEach_TestIdempotency(t, store, creating(new(int)))
+
+		now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		store.Create(context.TODO(), &Record{
+			Key:         "ip:0.0.0.0 POST /users key-1",
+			Fingerprint: fingerprintOf("POST", "/users", `{}`),
+			ExpiresAt:   now.Add(time.Minute),
+		}, now)
+
+		status, body, _ := send(t, fbr, "key-1", `{}`)
+		assert.Equal(t, 409, status, "should return conflict")
+		assert.Contains(t, body, CodeRequestInFlight, "should return the in flight code")
+
+		advance(time.Minute)
+		status, _, _ = send(t, fbr, "key-1", `{}`)
+		assert.Equal(t, 201, status, "should run again once the lock times out")
+	})
+
+	t.Run("should not replay the response of a key to other callers", func(t *testing.T) {
+		calls := 0
+		idempotency, err := New(NewMemoryStore(), Config{
+			TTL:         time.Hour,
+			LockTimeout: time.Minute,
+			Caller:      func(c *fiber.Ctx) string { return c.Get("X-Client") },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/users", idempotency.Middleware(), creating(&calls))
+
+		bodies := []string{}
+		for _, client := range []string{"a", "b"} {
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(HeaderIdempotencyKey, "key-1")
+			req.Header.Set("X-Client", client)
+
+			response, err := fbr.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Empty(t, response.Header.Get(HeaderReplayed), "should not replay")
+
+			body, _ := io.ReadAll(response.Body)
+			bodies = append(bodies, string(body))
+		}
+
+		assert.Equal(t, 2, calls, "should run the request of each caller")
+		assert.JSONEq(t, `{"call":2}`, bodies[1], "should answer the other caller its own response")
+	})
+
+	t.Run("should leave the key to the retry that took it once the lock timed out", func(t *testing.T) {
+		var fbr *fiber.App
+		var advance func(time.Duration)
+		calls := 0
+
+		fbr, advance = BeforeEach_TestIdempotency(t, NewMemoryStore(), func(c *fiber.Ctx) error {
+			calls++
+			if calls == 1 {
+				advance(time.Minute)
+				status, _, _ := send(t, fbr, "key-1", `{}`)
+				assert.Equal(t, 201, status, "should run the retry once the lock times out")
+			}
+
+			return c.Status(201).JSON(fiber.Map{"call": calls})
+		})
+
+		send(t, fbr, "key-1", `{}`)
+		_, body, replayed := send(t, fbr, "key-1", `{}`)
+
+		assert.Equal(t, "true", replayed, "should replay")
+		assert.JSONEq(t, `{"call":2}`, body, "should keep the response of the retry")
+	})
+
+	t.Run("should release the key when the handler fails", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, NewMemoryStore(), func(c *fiber.Ctx) error {
+			calls++
+			if calls == 1 {
+				return exception.New(exception.CodeInternal)
+			}
+
+			return c.SendStatus(201)
+		})
+
+		status, _, _ := send(t, fbr, "key-1", `{}`)
+		assert.Equal(t, 500, status, "should return the failure")
+
+		status, _, _ = send(t, fbr, "key-1", `{}`)
+		assert.Equal(t, 201, status, "should run the retry")
+		assert.Equal(t, 2, calls, "should run the handler again")
+	})
+
+	t.Run("should forget the response after the ttl", func(t *testing.T) {
+		calls := 0
+		fbr, advance := BeforeEach_TestIdempotency(t, NewMemoryStore(), creating(&calls))
+
+		send(t, fbr, "key-1", `{}`)
+		advance(time.Hour)
+		_, _, replayed := send(t, fbr, "key-1", `{}`)
+
+		assert.Empty(t, replayed, "should not replay")
+		assert.Equal(t, 2, calls, "should run the handler again")
+	})
+
+	t.Run("should answer internal error when the store fails", func(t *testing.T) {
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, &failingStore{MemoryStore: NewMemoryStore()}, creating(&calls))
+
+		status, _, _ := send(t, fbr, "key-1", `{}`)
+
+		assert.Equal(t, 500, status, "should return internal error")
+		assert.Equal(t, 0, calls, "should not run the handler")
+	})
+}
+
+func TestIdempotency_Log(t *testing.T) {
+	t.Run("should log store failures with the correlation id", func(t *testing.T) {
+		var logs bytes.Buffer
+		defaultLogger := logger.Default()
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Output: &logs}))
+		defer logger.SetDefault(defaultLogger)
+
+		calls := 0
+		fbr, _ := BeforeEach_TestIdempotency(t, &failingCompleteStore{MemoryStore: NewMemoryStore()}, creating(&calls))
+
+		status, _, _ := send(t, fbr, "key-1", `{}`)
+
+		assert.Equal(t, 201, status, "should answer the response")
+		assert.Contains(t, logs.String(), "operation=complete", "should log the failed operation as a field")
+		assert.Contains(t, logs.String(), "correlation_id=", "should log the correlation id")
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should fail without a ttl or a lock timeout", func(t *testing.T) {
+		_, err := New(NewMemoryStore(), Config{TTL: time.Hour})
+		assert.NotNil(t, err, "should return error")
+	})
+}
+
+func fingerprintOf(method string, path string, body string) string {
+	var fp string
+
+	fbr := fiber.New()
+	fbr.Add(method, path, func(c *fiber.Ctx) error {
+		fp = fingerprint(c)
+		return nil
+	})
+	fbr.Test(httptest.NewRequest(method, path, strings.NewReader(body)), -1)
+
+	return fp
+}
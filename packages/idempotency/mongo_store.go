@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore shares the records between instances. Expired records are
+// removed by the TTL index on expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(db *database.Database) *MongoStore {
+	return &MongoStore{collection: db.Collection(database.IdempotencyKeysCollection)}
+}
+
+// EnsureIndexes creates the TTL index of the collection, it is a no-op when
+// the index already exists.
+func (ms *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := ms.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+func (ms *MongoStore) Get(ctx context.Context, key string, now time.Time) (*Record, error) {
+	var record Record
+
+	err := ms.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Create upserts filtering by an expired record, as the TTL index removes
+// them a while after they expire, so a duplicated key means the key is held
+// by a record still alive.
+func (ms *MongoStore) Create(ctx context.Context, record *Record, now time.Time) (bool, error) {
+	_, err := ms.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": record.Key, "expires_at": bson.M{"$lte": now}},
+		record,
+		options.Replace().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (ms *MongoStore) Complete(ctx context.Context, lock *Record, record *Record) error {
+	result, err := ms.collection.ReplaceOne(ctx, lockFilter(lock), record)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (ms *MongoStore) Delete(ctx context.Context, lock *Record) error {
+	_, err := ms.collection.DeleteOne(ctx, lockFilter(lock))
+	return err
+}
+
+func lockFilter(lock *Record) bson.M {
+	return bson.M{
+		"_id":         lock.Key,
+		"completed":   false,
+		"fingerprint": lock.Fingerprint,
+		"expires_at":  lock.ExpiresAt,
+	}
+}
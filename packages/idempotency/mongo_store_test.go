@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const (
+	MOCK_DB_NAME = "foo"
+	MOCK_NS      = "foo.idempotency_keys"
+)
+
+func TestMongoStore(t *testing.T) {
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return nil when the key has no record", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_NS, mtest.FirstBatch))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		record, err := store.Get(context.TODO(), "POST /users key-1", time.Now())
+
+		assert.Nil(t, err, "should not return error")
+		assert.Nil(t, record, "should not return record")
+	})
+
+	rootMt.Run("should return the record of the key", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(1, MOCK_NS, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "POST /users key-1"},
+			{Key: "fingerprint", Value: "abc"},
+			{Key: "completed", Value: true},
+			{Key: "status", Value: 201},
+		}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		record, err := store.Get(context.TODO(), "POST /users key-1", time.Now())
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 201, record.Status, "should return the status")
+		assert.NotNil(t, filter.Lookup("expires_at", "$gt").Value, "should filter the expired records")
+	})
+
+	rootMt.Run("should create the record replacing an expired one", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		created, err := store.Create(context.TODO(), &Record{Key: "POST /users key-1"}, time.Now())
+
+		update := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, created, "should create the record")
+		assert.True(t, update.Lookup("upsert").Boolean(), "should upsert")
+		assert.NotNil(t, update.Lookup("q", "expires_at", "$lte").Value, "should only replace expired records")
+	})
+
+	rootMt.Run("should not create when the key is held", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "duplicate key error",
+		}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		created, err := store.Create(context.TODO(), &Record{Key: "POST /users key-1"}, time.Now())
+
+		assert.Nil(t, err, "should not return error")
+		assert.False(t, created, "should not create the record")
+	})
+
+	rootMt.Run("should complete the record only while the request holds its lock", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		lock := &Record{Key: "POST /users key-1", Fingerprint: "9f86d0", ExpiresAt: time.Now().Truncate(time.Millisecond)}
+		err := store.Complete(context.TODO(), lock, &Record{Key: lock.Key, Completed: true})
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.Equal(t, ErrLockLost, err, "should tell the lock was lost")
+		assert.Equal(t, "9f86d0", filter.Lookup("fingerprint").StringValue(), "should match the fingerprint")
+		assert.Equal(t, lock.ExpiresAt, filter.Lookup("expires_at").Time(), "should match the lock expiration")
+		assert.False(t, filter.Lookup("completed").Boolean(), "should not replace completed records")
+	})
+
+	rootMt.Run("should delete the record only while the request holds its lock", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		lock := &Record{Key: "POST /users key-1", Fingerprint: "9f86d0", ExpiresAt: time.Now().Truncate(time.Millisecond)}
+		err := store.Delete(context.TODO(), lock)
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "9f86d0", filter.Lookup("fingerprint").StringValue(), "should match the fingerprint")
+		assert.Equal(t, lock.ExpiresAt, filter.Lookup("expires_at").Time(), "should match the lock expiration")
+	})
+
+	rootMt.Run("should create the ttl index", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		err := store.EnsureIndexes(context.TODO())
+
+		index := nestedMt.GetStartedEvent().Command.Lookup("indexes").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, int32(0), index.Lookup("expireAfterSeconds").Int32(), "should expire at expires_at")
+	})
+}
@@ -0,0 +1,125 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLockLost is returned when completing a record whose lock timed out and
+// was taken by a retry.
+var ErrLockLost = errors.New("idempotency lock taken by another request")
+
+// Record is what is kept per key. Until Completed, it only holds the key for
+// the request in progress.
+type Record struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Completed   bool      `bson:"completed"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// Store keeps the records of the keys. Create stores the record only when
+// its key has none, or an expired one, telling whether it did. Get and
+// Create take expired records as gone. Complete and Delete only touch the
+// record while it is still the lock created by the request, matched by its
+// fingerprint and expiration, so a request outliving its lock leaves the
+// record of the retry that took it alone.
+type Store interface {
+	Get(ctx context.Context, key string, now time.Time) (*Record, error)
+	Create(ctx context.Context, record *Record, now time.Time) (bool, error)
+	Complete(ctx context.Context, lock *Record, record *Record) error
+	Delete(ctx context.Context, lock *Record) error
+}
+
+// MemoryStore keeps the records in the process, for single instance
+// deployments and tests.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	records map[string]Record
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, key string, now time.Time) (*Record, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	record, ok := ms.records[key]
+	if !ok || !now.Before(record.ExpiresAt) {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (ms *MemoryStore) Create(ctx context.Context, record *Record, now time.Time) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.sweep(now)
+
+	if stored, ok := ms.records[record.Key]; ok && now.Before(stored.ExpiresAt) {
+		return false, nil
+	}
+
+	ms.records[record.Key] = *record
+
+	return true, nil
+}
+
+func (ms *MemoryStore) Complete(ctx context.Context, lock *Record, record *Record) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if !ms.holds(lock) {
+		return ErrLockLost
+	}
+
+	ms.records[record.Key] = *record
+
+	return nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, lock *Record) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if ms.holds(lock) {
+		delete(ms.records, lock.Key)
+	}
+
+	return nil
+}
+
+func (ms *MemoryStore) holds(lock *Record) bool {
+	stored, ok := ms.records[lock.Key]
+
+	return ok &&
+		!stored.Completed &&
+		stored.Fingerprint == lock.Fingerprint &&
+		stored.ExpiresAt.Equal(lock.ExpiresAt)
+}
+
+// sweep drops the expired records once a minute, so replayed responses do
+// not pile up.
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.swept) < time.Minute {
+		return
+	}
+
+	for key, record := range ms.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(ms.records, key)
+		}
+	}
+
+	ms.swept = now
+}
@@ -2,53 +2,169 @@ package logger
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"runtime"
-)
+	"strings"
+	"sync/atomic"
+	"time"
 
-type ContextKey string
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+)
 
 const (
-	CorrelationId ContextKey = "X-Correlation-ID"
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
 type LoggerInterface interface {
-	Info(msg string)
-	Error(msg string)
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+	With(args ...any) *Logger
 	WithCtx(ctx context.Context) *Logger
 }
 
+type Config struct {
+	Level  string
+	Format string
+	Output io.Writer
+}
+
+// Logger is immutable: With, WithCtx and CallerSkip return a child logger and
+// never change the receiver, so a single instance can be shared by requests.
 type Logger struct {
-	CorrelationId string
+	handler    slog.Handler
+	callerSkip int
 }
 
-func NewLogger() *Logger {
+var defaultLogger atomic.Pointer[Logger]
+
+// Default returns the logger set by SetDefault, for packages logging outside
+// of an injected logger, e.g. the error handlers. Until it is set, records go
+// to the default slog handler.
+func Default() *Logger {
+	if lg := defaultLogger.Load(); lg != nil {
+		return lg
+	}
+
 	return &Logger{}
 }
 
-func (l *Logger) Info(msg string) {
-	message := fmt.Sprintf("%s - %s", l.CorrelationId, msg)
+// SetDefault makes lg the logger returned by Default.
+func SetDefault(lg *Logger) {
+	defaultLogger.Store(lg)
+}
+
+func NewLogger() *Logger {
+	return NewLoggerWithConfig(Config{})
+}
+
+func NewLoggerWithConfig(cfg Config) *Logger {
+	output := cfg.Output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	options := &slog.HandlerOptions{AddSource: true, Level: ParseLevel(cfg.Level)}
+
+	var handler slog.Handler = slog.NewTextHandler(output, options)
+	if strings.EqualFold(cfg.Format, FormatJSON) {
+		handler = slog.NewJSONHandler(output, options)
+	}
+
+	return &Logger{handler: handler}
+}
+
+// ParseLevel converts a level name to a slog level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
+
+func (l *Logger) Debug(msg string, args ...any) {
+	l.log(slog.LevelDebug, msg, args...)
+}
 
-	slog.Info(message)
+func (l *Logger) Info(msg string, args ...any) {
+	l.log(slog.LevelInfo, msg, args...)
 }
 
-func (l *Logger) Error(msg string) {
-	_, file, line, _ := runtime.Caller(1)
-	caller := fmt.Sprintf("%s:%d", file, line)
-	message := fmt.Sprintf("%s - %s at %s", l.CorrelationId, msg, caller)
+func (l *Logger) Warn(msg string, args ...any) {
+	l.log(slog.LevelWarn, msg, args...)
+}
 
-	slog.Error(message)
+func (l *Logger) Error(msg string, args ...any) {
+	l.log(slog.LevelError, msg, args...)
 }
 
+// With returns a child logger that adds the given key/value pairs to every
+// record, e.g. With("user_id", id).
+func (l *Logger) With(args ...any) *Logger {
+	if len(args) == 0 {
+		return l
+	}
+
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	record.Add(args...)
+
+	attrs := []slog.Attr{}
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	return &Logger{handler: l.getHandler().WithAttrs(attrs), callerSkip: l.callerSkip}
+}
+
+// WithCtx returns a child logger carrying the correlation id of ctx.
 func (l *Logger) WithCtx(ctx context.Context) *Logger {
-	correlationId := ctx.Value(string(CorrelationId))
+	correlationId := correlation.FromContext(ctx)
 
-	if correlationId == nil {
+	if correlationId == "" {
 		correlationId = "unknown"
 	}
 
-	l.CorrelationId = correlationId.(string)
+	return l.With("correlation_id", correlationId)
+}
+
+// CallerSkip returns a child logger that reports the caller n frames above
+// the one calling it, so helpers wrapping the logger keep the source right.
+func (l *Logger) CallerSkip(n int) *Logger {
+	return &Logger{handler: l.handler, callerSkip: l.callerSkip + n}
+}
+
+func (l *Logger) getHandler() slog.Handler {
+	if l.handler == nil {
+		return slog.Default().Handler()
+	}
+
+	return l.handler
+}
+
+func (l *Logger) log(level slog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	handler := l.getHandler()
+
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3+l.callerSkip, pcs[:])
+
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.Add(args...)
 
-	return l
+	handler.Handle(ctx, record)
 }
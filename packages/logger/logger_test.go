@@ -0,0 +1,190 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+	return sb.buffer.Write(p)
+}
+
+func (sb *syncBuffer) Lines() []string {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+	return strings.Split(strings.TrimSpace(sb.buffer.String()), "\n")
+}
+
+func decodeLine(t *testing.T, line string) map[string]any {
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestLogger_WithCtx(t *testing.T) {
+	t.Run("should not leak correlation ids between concurrent requests", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id := fmt.Sprintf("request-%d", i)
+				ctx := correlation.WithId(context.TODO(), id)
+				lg.WithCtx(ctx).Info(id)
+			}(i)
+		}
+		wg.Wait()
+
+		lines := output.Lines()
+		assert.Equal(t, 50, len(lines), "should write every line")
+
+		for _, line := range lines {
+			entry := decodeLine(t, line)
+			assert.Equal(t, entry["msg"], entry["correlation_id"], "should keep the id of its own request")
+		}
+	})
+
+	t.Run("should use unknown when context has no correlation id", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output})
+
+		lg.WithCtx(context.TODO()).Info("foo")
+
+		entry := decodeLine(t, output.Lines()[0])
+		assert.Equal(t, "unknown", entry["correlation_id"])
+	})
+}
+
+func TestLogger_With(t *testing.T) {
+	t.Run("should add fields to the child without changing the parent", func(t *testing.T) {
+		output := &syncBuffer{}
+		parent := logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output})
+
+		parent.With("user_id", "123").Info("child", "attempt", 2)
+		parent.Info("parent")
+
+		lines := output.Lines()
+		child, root := decodeLine(t, lines[0]), decodeLine(t, lines[1])
+
+		assert.Equal(t, "123", child["user_id"], "should add the field")
+		assert.Equal(t, float64(2), child["attempt"], "should add the message field")
+		assert.NotContains(t, root, "user_id", "should not change the parent")
+	})
+
+	t.Run("should return the same logger when there are no fields", func(t *testing.T) {
+		lg := logger.NewLogger()
+
+		assert.Same(t, lg, lg.With())
+	})
+}
+
+func TestLogger_Levels(t *testing.T) {
+	t.Run("should write only records at or above the configured level", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Level: "warn", Format: logger.FormatJSON, Output: output})
+
+		lg.Debug("debug")
+		lg.Info("info")
+		lg.Warn("warn")
+		lg.Error("error")
+
+		lines := output.Lines()
+		assert.Equal(t, 2, len(lines), "should skip debug and info")
+		assert.Equal(t, "WARN", decodeLine(t, lines[0])["level"])
+		assert.Equal(t, "ERROR", decodeLine(t, lines[1])["level"])
+	})
+
+	t.Run("should parse level names", func(t *testing.T) {
+		assert.Equal(t, slog.LevelDebug, logger.ParseLevel("DEBUG"))
+		assert.Equal(t, slog.LevelWarn, logger.ParseLevel("warning"))
+		assert.Equal(t, slog.LevelError, logger.ParseLevel("error"))
+		assert.Equal(t, slog.LevelInfo, logger.ParseLevel(""))
+	})
+}
+
+func TestLogger_Format(t *testing.T) {
+	t.Run("should write text records by default", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Output: output})
+
+		lg.Info("foo", "bar", "buzz")
+
+		assert.Contains(t, output.Lines()[0], "msg=foo bar=buzz")
+	})
+}
+
+func helper(lg *logger.Logger) {
+	lg.CallerSkip(1).Error("from helper")
+}
+
+func TestLogger_Source(t *testing.T) {
+	t.Run("should report the caller of the logger", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output})
+
+		lg.WithCtx(context.TODO()).Error("foo")
+
+		source := decodeLine(t, output.Lines()[0])["source"].(map[string]any)
+		assert.Equal(t, "github.com/italoservio/braz_ecommerce/packages/logger_test.TestLogger_Source.func1", source["function"])
+	})
+
+	t.Run("should report the caller of a wrapper when skipping frames", func(t *testing.T) {
+		output := &syncBuffer{}
+		lg := logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output})
+
+		helper(lg)
+
+		source := decodeLine(t, output.Lines()[0])["source"].(map[string]any)
+		assert.Equal(t, "github.com/italoservio/braz_ecommerce/packages/logger_test.TestLogger_Source.func2", source["function"])
+	})
+}
+
+func TestLogger_ZeroValue(t *testing.T) {
+	t.Run("should fall back to the default slog handler", func(t *testing.T) {
+		lg := &logger.Logger{}
+
+		assert.NotPanics(t, func() {
+			lg.WithCtx(context.TODO()).CallerSkip(1).Info("foo")
+		})
+	})
+}
+
+func TestLogger_Default(t *testing.T) {
+	t.Run("should return the logger set as default", func(t *testing.T) {
+		previous := logger.Default()
+		defer logger.SetDefault(previous)
+
+		output := &syncBuffer{}
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output}))
+
+		ctx := correlation.WithId(context.TODO(), "b7f1c2")
+		logger.Default().WithCtx(ctx).Error("foo", "cause", "bar")
+
+		entry := decodeLine(t, output.Lines()[0])
+		assert.Equal(t, "foo", entry["msg"])
+		assert.Equal(t, "b7f1c2", entry["correlation_id"], "should carry the correlation id")
+		assert.Equal(t, "bar", entry["cause"])
+	})
+}
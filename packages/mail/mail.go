@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type MailerInterface interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// LogMailer logs messages instead of sending them, bodies included, so it is
+// meant for local development only.
+type LogMailer struct {
+	logger logger.LoggerInterface
+}
+
+func NewLogMailer(lg logger.LoggerInterface) *LogMailer {
+	return &LogMailer{logger: lg}
+}
+
+func (lm *LogMailer) Send(ctx context.Context, message *Message) error {
+	lm.logger.WithCtx(ctx).Info("mail", "subject", message.Subject, "body", message.Body)
+	return nil
+}
+
+// SMTPMailer sends plain text messages through an SMTP server, authenticating
+// when a username is given.
+type SMTPMailer struct {
+	address string
+	from    string
+	auth    smtp.Auth
+}
+
+func NewSMTPMailer(address string, from string, username string, password string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(address)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{address: address, from: from, auth: auth}
+}
+
+func (sm *SMTPMailer) Send(ctx context.Context, message *Message) error {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		sm.from,
+		message.To,
+		message.Subject,
+		message.Body,
+	)
+
+	return smtp.SendMail(sm.address, sm.auth, sm.from, []string{message.To}, []byte(content))
+}
@@ -0,0 +1,33 @@
+package mail_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	mailer := mail.NewSMTPMailer("localhost:0", "no-reply@braz.com", "", "")
+
+	t.Run("should refuse line breaks in the recipient", func(t *testing.T) {
+		err := mailer.Send(context.TODO(), &mail.Message{
+			To:      "goo@gle.com\r\nBcc: evil@gle.com",
+			Subject: "Unlock",
+			Body:    "body",
+		})
+
+		assert.ErrorContains(t, err, "line breaks", "should return the header error")
+	})
+
+	t.Run("should refuse line breaks in the subject", func(t *testing.T) {
+		err := mailer.Send(context.TODO(), &mail.Message{
+			To:      "goo@gle.com",
+			Subject: "Unlock\nBcc: evil@gle.com",
+			Body:    "body",
+		})
+
+		assert.ErrorContains(t, err, "line breaks", "should return the header error")
+	})
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor counts the gRPC calls and their latency by method
+// and answered code.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		started := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		labels := []string{info.FullMethod, status.Code(err).String()}
+
+		m.grpcRequests.WithLabelValues(labels...).Inc()
+		m.grpcDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+
+		return resp, err
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Middleware counts the requests and their latency by route and status. Like
+// the Fiber logger, errors are handed to the app error handler right away so
+// the final status code is known.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		labels := []string{
+			c.Method(),
+			c.Route().Path,
+			strconv.Itoa(c.Response().StatusCode()),
+		}
+
+		m.httpRequests.WithLabelValues(labels...).Inc()
+		m.httpDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+
+		return nil
+	}
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
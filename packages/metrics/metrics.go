@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+type Counter = prometheus.Counter
+
+// Metrics owns the registry served by Handler. Every service gets the HTTP,
+// gRPC, repository, MongoDB pool and runtime metrics, and registers its
+// business counters through Counter.
+type Metrics struct {
+	registry           *prometheus.Registry
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	grpcRequests       *prometheus.CounterVec
+	grpcDuration       *prometheus.HistogramVec
+	repositoryDuration *prometheus.HistogramVec
+	repositoryErrors   *prometheus.CounterVec
+	poolOpen           prometheus.Gauge
+	poolInUse          prometheus.Gauge
+	poolFailures       prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests answered.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to answer HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Number of gRPC calls answered.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "Time taken to answer gRPC calls.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Time taken by repository operations.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"collection", "operation"}),
+		repositoryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "repository_operation_errors_total",
+			Help: "Number of failed repository operations by error code.",
+		}, []string{"collection", "operation", "code"}),
+		poolOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mongodb_pool_open_connections",
+			Help: "Number of connections open in the MongoDB pool.",
+		}),
+		poolInUse: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mongodb_pool_in_use_connections",
+			Help: "Number of MongoDB pool connections checked out.",
+		}),
+		poolFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mongodb_pool_checkout_failures_total",
+			Help: "Number of failed MongoDB pool connection check outs.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcRequests,
+		m.grpcDuration,
+		m.repositoryDuration,
+		m.repositoryErrors,
+		m.poolOpen,
+		m.poolInUse,
+		m.poolFailures,
+	)
+
+	return m
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Counter registers a business counter, e.g. Counter("users_created_total",
+// "Number of users created.").
+func (m *Metrics) Counter(name string, help string) Counter {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: name, Help: help})
+	m.registry.MustRegister(counter)
+	return counter
+}
+
+// ObserveOperation records the latency of a repository operation and, when
+// it fails, its exception code. Errors without a registered code are counted
+// as EINTERNAL, so driver messages never become label values.
+func (m *Metrics) ObserveOperation(
+	collection string,
+	operation string,
+	duration time.Duration,
+	err error,
+) {
+	m.repositoryDuration.WithLabelValues(collection, operation).Observe(duration.Seconds())
+
+	if err != nil {
+		m.repositoryErrors.WithLabelValues(collection, operation, errorCode(err)).Inc()
+	}
+}
+
+func errorCode(err error) string {
+	var typed *exception.Error
+	if errors.As(err, &typed) {
+		return typed.Code
+	}
+
+	if _, ok := exception.Lookup(err.Error()); ok {
+		return err.Error()
+	}
+
+	return exception.CodeInternal
+}
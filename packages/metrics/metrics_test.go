@@ -0,0 +1,150 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func BeforeEach_TestMetrics(t *testing.T) (*metrics.Metrics, *fiber.App) {
+	m := metrics.New()
+
+	fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	fbr.Use(m.Middleware())
+	fbr.Get("/metrics", m.Handler())
+	fbr.Get("/users/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return errors.New(exception.CodeNotFound)
+		}
+		return c.SendStatus(200)
+	})
+
+	return m, fbr
+}
+
+func scrape(t *testing.T, fbr *fiber.App) string {
+	response, err := fbr.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(bytes)
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	t.Run("should count requests by route and status", func(t *testing.T) {
+		_, fbr := BeforeEach_TestMetrics(t)
+
+		fbr.Test(httptest.NewRequest("GET", "/users/1", nil), -1)
+		fbr.Test(httptest.NewRequest("GET", "/users/2", nil), -1)
+		response, _ := fbr.Test(httptest.NewRequest("GET", "/users/missing", nil), -1)
+
+		assert.Equal(t, 404, response.StatusCode, "should keep the error status")
+
+		body := scrape(t, fbr)
+		assert.Contains(t, body, `http_requests_total{method="GET",route="/users/:id",status="200"} 2`)
+		assert.Contains(t, body, `http_requests_total{method="GET",route="/users/:id",status="404"} 1`)
+		assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`)
+		assert.Contains(t, body, "go_goroutines", "should expose the runtime metrics")
+	})
+}
+
+func TestMetrics_UnaryServerInterceptor(t *testing.T) {
+	t.Run("should count calls by method and code", func(t *testing.T) {
+		m, fbr := BeforeEach_TestMetrics(t)
+
+		interceptor := m.UnaryServerInterceptor()
+		info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UsersService/GetUserById"}
+
+		interceptor(context.TODO(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return "ok", nil
+		})
+		interceptor(context.TODO(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.NotFound, "not found")
+		})
+
+		body := scrape(t, fbr)
+		assert.Contains(t, body, `grpc_requests_total{code="OK",method="/users.v1.UsersService/GetUserById"} 1`)
+		assert.Contains(t, body, `grpc_requests_total{code="NotFound",method="/users.v1.UsersService/GetUserById"} 1`)
+		assert.Contains(t, body, `grpc_request_duration_seconds_count{code="OK",method="/users.v1.UsersService/GetUserById"} 1`)
+	})
+}
+
+func TestMetrics_ObserveOperation(t *testing.T) {
+	t.Run("should record latency and error codes by collection", func(t *testing.T) {
+		m, fbr := BeforeEach_TestMetrics(t)
+
+		m.ObserveOperation("users", "get_by_id", time.Millisecond, nil)
+		m.ObserveOperation("users", "get_by_id", time.Millisecond, errors.New(exception.CodeNotFound))
+
+		body := scrape(t, fbr)
+		assert.Contains(t, body, `repository_operation_duration_seconds_count{collection="users",operation="get_by_id"} 2`)
+		assert.Contains(t, body, `repository_operation_errors_total{code="ENOTFOUND",collection="users",operation="get_by_id"} 1`)
+	})
+
+	t.Run("should label untyped errors as internal", func(t *testing.T) {
+		m, fbr := BeforeEach_TestMetrics(t)
+
+		m.ObserveOperation("users", "get_by_id", time.Millisecond, errors.New("connection to 10.0.0.1:27017 closed"))
+		m.ObserveOperation("users", "get_by_id", time.Millisecond, exception.Wrap(exception.CodeTimeout, errors.New("timed out")))
+
+		body := scrape(t, fbr)
+		assert.Contains(t, body, `repository_operation_errors_total{code="EINTERNAL",collection="users",operation="get_by_id"} 1`)
+		assert.Contains(t, body, `repository_operation_errors_total{code="ETIMEOUT",collection="users",operation="get_by_id"} 1`)
+		assert.NotContains(t, body, "10.0.0.1", "should not label with the error message")
+	})
+}
+
+func TestMetrics_Counter(t *testing.T) {
+	t.Run("should expose business counters", func(t *testing.T) {
+		m, fbr := BeforeEach_TestMetrics(t)
+
+		counter := m.Counter("users_created_total", "Number of users created.")
+		counter.Inc()
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(counter))
+		assert.Contains(t, scrape(t, fbr), "users_created_total 1")
+	})
+}
+
+func TestMetrics_PoolMonitor(t *testing.T) {
+	t.Run("should track open and checked out connections", func(t *testing.T) {
+		m, fbr := BeforeEach_TestMetrics(t)
+		monitor := m.PoolMonitor()
+
+		for _, eventType := range []string{
+			event.ConnectionCreated,
+			event.ConnectionCreated,
+			event.GetSucceeded,
+			event.GetSucceeded,
+			event.ConnectionReturned,
+			event.ConnectionClosed,
+			event.GetFailed,
+		} {
+			monitor.Event(&event.PoolEvent{Type: eventType})
+		}
+
+		body := scrape(t, fbr)
+		assert.Contains(t, body, "mongodb_pool_open_connections 1")
+		assert.Contains(t, body, "mongodb_pool_in_use_connections 1")
+		assert.Contains(t, body, "mongodb_pool_checkout_failures_total 1")
+	})
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolMonitor keeps the MongoDB pool metrics up to date. It must be set on
+// the client options, e.g. options.Client().SetPoolMonitor(m.PoolMonitor()).
+func (m *Metrics) PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				m.poolOpen.Inc()
+			case event.ConnectionClosed:
+				m.poolOpen.Dec()
+			case event.GetSucceeded:
+				m.poolInUse.Inc()
+			case event.ConnectionReturned:
+				m.poolInUse.Dec()
+			case event.GetFailed:
+				m.poolFailures.Inc()
+			}
+		},
+	}
+}
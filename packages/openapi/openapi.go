@@ -0,0 +1,229 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+)
+
+// Route describes the operation of a named route. Query and Headers are
+// structs whose query and reqHeader tags name the params, as parsed by
+// fiber, Body and Response are the payloads marshaled as JSON. Errors are
+// the codes the route answers, documented by their registered definitions.
+type Route struct {
+	Summary  string
+	Tags     []string
+	Query    any
+	Headers  any
+	Body     any
+	Response any
+	// Status is the status of the response, 200 when not set.
+	Status int
+	Errors []string
+}
+
+// Generate documents the registered routes whose name is described, taking
+// the path params from the route paths and the schemas from the struct tags
+// of the payloads. It fails when a described route is not registered or
+// answers an unregistered error code.
+func Generate(info Info, routes []fiber.Route, described map[string]Route) (*Document, error) {
+	s := newSchemas()
+	document := &Document{OpenAPI: Version, Info: info, Paths: map[string]*PathItem{}}
+	documented := map[string]bool{}
+
+	for _, route := range routes {
+		description, ok := described[route.Name]
+		if !ok || route.Method == fiber.MethodHead {
+			continue
+		}
+
+		operation, err := s.operation(route, description)
+		if err != nil {
+			return nil, err
+		}
+
+		path := pathOf(route)
+		if document.Paths[path] == nil {
+			document.Paths[path] = &PathItem{}
+		}
+
+		(*document.Paths[path])[strings.ToLower(route.Method)] = operation
+		documented[route.Name] = true
+	}
+
+	names := make([]string, 0, len(described))
+	for name := range described {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !documented[name] {
+			return nil, fmt.Errorf("route %q is described but not registered", name)
+		}
+	}
+
+	document.Components.Schemas = s.components
+
+	return document, nil
+}
+
+func (s *schemas) operation(route fiber.Route, description Route) (*Operation, error) {
+	operation := &Operation{
+		OperationId: route.Name,
+		Summary:     description.Summary,
+		Tags:        description.Tags,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, param := range route.Params {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	operation.Parameters = append(operation.Parameters, s.parameters(description.Query, "query", "query")...)
+	operation.Parameters = append(operation.Parameters, s.parameters(description.Headers, "header", "reqHeader")...)
+
+	if description.Body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: s.of(reflect.TypeOf(description.Body))}},
+		}
+	}
+
+	status := description.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	response := &Response{Description: http.StatusText(status)}
+	if description.Response != nil {
+		response.Content = map[string]*MediaType{
+			fiber.MIMEApplicationJSON: {Schema: s.of(reflect.TypeOf(description.Response))},
+		}
+	}
+	operation.Responses[strconv.Itoa(status)] = response
+
+	if err := s.errors(operation, description.Errors); err != nil {
+		return nil, fmt.Errorf("route %q: %w", route.Name, err)
+	}
+
+	return operation, nil
+}
+
+// parameters takes the params from the fields of the struct named by the
+// tag, e.g. `query:"page"`.
+func (s *schemas) parameters(value any, in string, tag string) []*Parameter {
+	if value == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	parameters := []*Parameter{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema, required := s.field(field)
+		parameters = append(parameters, &Parameter{Name: name, In: in, Required: required, Schema: schema})
+	}
+
+	return parameters
+}
+
+// errors answers the codes grouped by status, as HTTPException or, when
+// asked for, as Problem.
+func (s *schemas) errors(operation *Operation, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	content := map[string]*MediaType{
+		fiber.MIMEApplicationJSON:    {Schema: s.of(reflect.TypeOf(exception.HTTPException{}))},
+		exception.ProblemContentType: {Schema: s.of(reflect.TypeOf(exception.Problem{}))},
+	}
+
+	for _, code := range codes {
+		definition, ok := exception.Lookup(code)
+		if !ok {
+			return fmt.Errorf("error code %q is not registered", code)
+		}
+
+		status := strconv.Itoa(definition.Status)
+		line := fmt.Sprintf("- `%s` %s", definition.Code, definition.Message)
+
+		if response, ok := operation.Responses[status]; ok {
+			response.Description += "\n" + line
+			continue
+		}
+
+		operation.Responses[status] = &Response{Description: line, Content: content}
+	}
+
+	return nil
+}
+
+// pathOf writes the path params of the route as OpenAPI does, e.g.
+// "/users/:id" as "/users/{id}".
+func pathOf(route fiber.Route) string {
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimRight(segment[1:], "?") + "}"
+		}
+	}
+
+	path := strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path
+}
+
+// Handler answers the document as JSON.
+func Handler(document *Document) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(document)
+	}
+}
+
+//go:embed viewer.html
+var viewer string
+
+var viewerTemplate = template.Must(template.New("viewer").Parse(viewer))
+
+// Viewer answers a page that renders the document served at documentURL,
+// bundled so it works without reaching any CDN.
+func Viewer(documentURL string) fiber.Handler {
+	var page bytes.Buffer
+	if err := viewerTemplate.Execute(&page, documentURL); err != nil {
+		panic(err)
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Type("html", "utf-8")
+		return c.Send(page.Bytes())
+	}
+}
@@ -0,0 +1,202 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockNested struct {
+	Street string `json:"street"`
+}
+
+type MockBody struct {
+	*database.DatabaseIdentifier
+	Name      string            `json:"name" validate:"required,min=2,max=10"`
+	Kind      string            `json:"kind" validate:"omitempty,oneof=admin customer"`
+	Password  string            `json:"password" validate:"required" secret:"true"`
+	Age       int               `json:"age" validate:"gte=18,lt=130"`
+	Emails    []string          `json:"emails" validate:"max=3,dive,email"`
+	Nested    MockNested        `json:"nested"`
+	DeletedAt *time.Time        `json:"deleted_at"`
+	Labels    map[string]string `json:"labels"`
+	Internal  string            `json:"-"`
+	NoTag     bool
+}
+
+type MockQuery struct {
+	Page int  `query:"page" validate:"required,gt=0"`
+	All  bool `query:"all"`
+}
+
+type MockHeaders struct {
+	Key string `reqHeader:"X-Key" validate:"required"`
+}
+
+// BeforeEach_TestGenerate registers named routes on a group, as the services
+// do.
+func BeforeEach_TestGenerate() *fiber.App {
+	fbr := fiber.New()
+	group := fbr.Group("/api/v1/things")
+	group.Post("/", func(c *fiber.Ctx) error { return nil }).Name("things.create")
+	group.Get("/:id", func(c *fiber.Ctx) error { return nil }).Name("things.get")
+	group.Delete("/:id", func(c *fiber.Ctx) error { return nil })
+
+	return fbr
+}
+
+func describedRoutes() map[string]openapi.Route {
+	return map[string]openapi.Route{
+		"things.create": {
+			Summary:  "Create a thing",
+			Tags:     []string{"things"},
+			Headers:  MockHeaders{},
+			Body:     MockBody{},
+			Response: database.PaginatedSlice[MockNested]{},
+			Status:   201,
+			Errors:   []string{exception.CodeValidationFailed, exception.CodeNotFound, exception.CodeInternal},
+		},
+		"things.get": {
+			Query:  MockQuery{},
+			Errors: []string{exception.CodeNotFound},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("should document the described routes", func(t *testing.T) {
+		document, err := openapi.Generate(
+			openapi.Info{Title: "Things", Version: "1.0.0"},
+			BeforeEach_TestGenerate().GetRoutes(true),
+			describedRoutes(),
+		)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, openapi.Version, document.OpenAPI, "should set the openapi version")
+		assert.Len(t, document.Paths, 2, "should skip the routes not described")
+
+		create := (*document.Paths["/api/v1/things"])["post"]
+		assert.Equal(t, "things.create", create.OperationId, "should take the route name as operation id")
+		assert.Equal(t, "Created", create.Responses["201"].Description, "should answer the route status")
+		assert.Equal(
+			t,
+			"#/components/schemas/PaginatedSlice_MockNested",
+			create.Responses["201"].Content[fiber.MIMEApplicationJSON].Schema.Ref,
+			"should name generic types without package paths",
+		)
+		assert.Equal(t, "X-Key", create.Parameters[0].Name, "should take the headers")
+		assert.Equal(t, "header", create.Parameters[0].In, "should document the headers")
+		assert.True(t, create.Parameters[0].Required, "should require the headers by their rules")
+
+		get := (*document.Paths["/api/v1/things/{id}"])["get"]
+		assert.Nil(t, (*document.Paths["/api/v1/things/{id}"])["head"], "should skip the head routes")
+		assert.Equal(t, "id", get.Parameters[0].Name, "should take the path params")
+		assert.Equal(t, "path", get.Parameters[0].In, "should document the path params")
+		assert.Equal(t, "page", get.Parameters[1].Name, "should take the query params")
+		assert.Equal(t, true, get.Parameters[1].Schema.ExclusiveMinimum, "should apply the query rules")
+		assert.Equal(t, "OK", get.Responses["200"].Description, "should answer 200 by default")
+	})
+
+	t.Run("should take the schemas from the struct tags", func(t *testing.T) {
+		document, _ := openapi.Generate(openapi.Info{}, BeforeEach_TestGenerate().GetRoutes(true), describedRoutes())
+
+		body := document.Components.Schemas["MockBody"]
+		assert.ElementsMatch(t, []string{"name", "password"}, body.Required, "should require by the rules")
+		assert.Contains(t, body.Properties, "id", "should flatten the embedded structs")
+		assert.Contains(t, body.Properties, "NoTag", "should name untagged fields as json does")
+		assert.NotContains(t, body.Properties, "-", "should skip the ignored fields")
+		assert.NotContains(t, body.Properties, "Internal", "should skip the ignored fields")
+
+		assert.Equal(t, 2, *body.Properties["name"].MinLength, "should apply min to the length")
+		assert.Equal(t, 10, *body.Properties["name"].MaxLength, "should apply max to the length")
+		assert.Equal(t, []string{"admin", "customer"}, body.Properties["kind"].Enum, "should apply oneof")
+		assert.True(t, body.Properties["password"].WriteOnly, "should not answer secrets")
+		assert.Equal(t, 18.0, *body.Properties["age"].Minimum, "should apply gte")
+		assert.True(t, body.Properties["age"].ExclusiveMaximum, "should apply lt")
+		assert.Equal(t, 3, *body.Properties["emails"].MaxItems, "should apply max to the items count")
+		assert.Equal(t, "email", body.Properties["emails"].Items.Format, "should apply the rules after dive to the items")
+		assert.Equal(t, "#/components/schemas/MockNested", body.Properties["nested"].Ref, "should reference named structs")
+		assert.Equal(t, "date-time", body.Properties["deleted_at"].Format, "should document times")
+		assert.True(t, body.Properties["deleted_at"].Nullable, "should allow null pointers")
+		assert.Equal(t, "string", body.Properties["labels"].AdditionalProperties.Type, "should document maps")
+	})
+
+	t.Run("should group the errors by status", func(t *testing.T) {
+		document, _ := openapi.Generate(openapi.Info{}, BeforeEach_TestGenerate().GetRoutes(true), describedRoutes())
+
+		create := (*document.Paths["/api/v1/things"])["post"]
+		notFound := create.Responses["404"]
+
+		assert.Contains(t, notFound.Description, exception.CodeNotFound, "should list the codes of the status")
+		assert.Equal(
+			t,
+			"#/components/schemas/HTTPException",
+			notFound.Content[fiber.MIMEApplicationJSON].Schema.Ref,
+			"should answer the http exception",
+		)
+		assert.Equal(
+			t,
+			"#/components/schemas/Problem",
+			notFound.Content[exception.ProblemContentType].Schema.Ref,
+			"should answer the problem",
+		)
+	})
+
+	t.Run("should fail when a described route is not registered", func(t *testing.T) {
+		routes := describedRoutes()
+		routes["things.update"] = openapi.Route{}
+
+		_, err := openapi.Generate(openapi.Info{}, BeforeEach_TestGenerate().GetRoutes(true), routes)
+
+		assert.ErrorContains(t, err, "things.update", "should name the route")
+	})
+
+	t.Run("should fail when a route answers an unregistered code", func(t *testing.T) {
+		routes := describedRoutes()
+		routes["things.get"] = openapi.Route{Errors: []string{"EUNKNOWN"}}
+
+		_, err := openapi.Generate(openapi.Info{}, BeforeEach_TestGenerate().GetRoutes(true), routes)
+
+		assert.ErrorContains(t, err, "EUNKNOWN", "should name the code")
+	})
+}
+
+func TestHandlers(t *testing.T) {
+	document, _ := openapi.Generate(
+		openapi.Info{Title: "Things", Version: "1.0.0"},
+		BeforeEach_TestGenerate().GetRoutes(true),
+		describedRoutes(),
+	)
+
+	fbr := fiber.New()
+	fbr.Get("/docs/openapi.json", openapi.Handler(document))
+	fbr.Get("/docs", openapi.Viewer("/docs/openapi.json"))
+
+	t.Run("should answer the document", func(t *testing.T) {
+		response, _ := fbr.Test(httptest.NewRequest("GET", "/docs/openapi.json", nil), -1)
+		bytes, _ := io.ReadAll(response.Body)
+
+		var answered openapi.Document
+		json.Unmarshal(bytes, &answered)
+
+		assert.Equal(t, 200, response.StatusCode, "should return expected status code")
+		assert.Equal(t, "Things", answered.Info.Title, "should answer the document")
+	})
+
+	t.Run("should answer the viewer of the document", func(t *testing.T) {
+		response, _ := fbr.Test(httptest.NewRequest("GET", "/docs", nil), -1)
+		bytes, _ := io.ReadAll(response.Body)
+
+		assert.True(t, strings.HasPrefix(response.Header.Get("Content-Type"), "text/html"), "should answer html")
+		assert.Contains(t, string(bytes), `"/docs/openapi.json"`, "should load the document")
+	})
+}
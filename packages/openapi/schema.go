@@ -0,0 +1,239 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const componentsPrefix = "#/components/schemas/"
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	// typeArguments matches the package paths in the names of generic types,
+	// e.g. "PaginatedSlice[github.com/x/app.Output]".
+	typeArguments = regexp.MustCompile(`[\w./-]*\.`)
+)
+
+// schemas builds the schemas of Go types, keeping the named structs as
+// components referenced by their type name.
+type schemas struct {
+	components map[string]*Schema
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}}
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return s.component(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		return s.object(t)
+	}
+
+	return &Schema{}
+}
+
+// component registers the struct once, so recursive types end in a ref.
+func (s *schemas) component(t reflect.Type) *Schema {
+	name := schemaName(t)
+
+	if _, ok := s.components[name]; !ok {
+		s.components[name] = &Schema{}
+		*s.components[name] = *s.object(t)
+	}
+
+	return &Schema{Ref: componentsPrefix + name}
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	object := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(object, t)
+
+	return object
+}
+
+// fields adds the fields of the struct as encoding/json would marshal them,
+// flattening the embedded structs.
+func (s *schemas) fields(object *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			s.fields(object, fieldType)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, required := s.field(field)
+		object.Properties[name] = schema
+
+		if required {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
+
+// field builds the schema of the field with its validate rules, telling
+// whether the field is required.
+func (s *schemas) field(field reflect.StructField) (*Schema, bool) {
+	schema := s.of(field.Type)
+
+	if field.Type.Kind() == reflect.Pointer && schema.Ref == "" {
+		schema.Nullable = true
+	}
+
+	if field.Tag.Get("secret") == "true" {
+		schema.Format = "password"
+		schema.WriteOnly = true
+	}
+
+	return schema, constrain(schema, field.Tag.Get("validate"))
+}
+
+// constrain applies the validate rules the schema can express, those after
+// dive to the items, telling whether the rules require the value.
+func constrain(schema *Schema, rules string) bool {
+	if rules == "" || schema.Ref != "" {
+		return false
+	}
+
+	required := false
+	target := schema
+
+	for _, rule := range strings.Split(rules, ",") {
+		tag, param, _ := strings.Cut(rule, "=")
+
+		switch tag {
+		case "required":
+			required = target == schema
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "min", "gte":
+			bound(target, param, true, false)
+		case "max", "lte":
+			bound(target, param, false, false)
+		case "gt":
+			bound(target, param, true, true)
+		case "lt":
+			bound(target, param, false, true)
+		case "len":
+			bound(target, param, true, false)
+			bound(target, param, false, false)
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "email":
+			target.Format = "email"
+		case "url", "uri":
+			target.Format = "uri"
+		case "mongodb":
+			target.Pattern = "^[0-9a-fA-F]{24}$"
+		}
+	}
+
+	return required
+}
+
+// bound sets the lower or upper bound of the param as a length, a count of
+// items or a value, following the type of the schema as the validator does.
+func bound(schema *Schema, param string, lower bool, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		length := int(value)
+		switch {
+		case exclusive && lower:
+			length++
+		case exclusive:
+			length--
+		}
+
+		if lower {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+	case "array":
+		count := int(value)
+		if lower {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &value, exclusive
+		} else {
+			schema.Maximum, schema.ExclusiveMaximum = &value, exclusive
+		}
+	}
+}
+
+// jsonName returns the name of the json tag, empty when there is none, and
+// false when the field is not marshaled.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+
+	return name, true
+}
+
+// schemaName strips the package paths of the type arguments, so
+// "PaginatedSlice[github.com/x/app.Output]" is named "PaginatedSlice_Output".
+func schemaName(t reflect.Type) string {
+	name := typeArguments.ReplaceAllString(t.Name(), "")
+	replacer := strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "")
+
+	return replacer.Replace(name)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+func take(policy Policy, state *State, now time.Time) (*State, *Result) {
+	if policy.Algorithm == SlidingWindow {
+		return takeSlidingWindow(policy, state, now)
+	}
+
+	return takeTokenBucket(policy, state, now)
+}
+
+// takeTokenBucket refills the tokens spent since the last request before
+// spending one. A missing state is a full bucket.
+func takeTokenBucket(policy Policy, state *State, now time.Time) (*State, *Result) {
+	limit := float64(policy.Limit)
+	perToken := policy.Window / time.Duration(policy.Limit)
+
+	tokens := limit
+	if state != nil {
+		elapsed := now.Sub(state.Start)
+		tokens = math.Min(limit, state.Tokens+elapsed.Seconds()/perToken.Seconds())
+	}
+
+	result := &Result{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((limit - tokens) * float64(perToken))
+
+	return &State{Tokens: tokens, Start: now, ExpiresAt: now.Add(policy.Window)}, result
+}
+
+// takeSlidingWindow estimates the requests of the last window from the
+// count of the current fixed window plus the share of the previous one that
+// still overlaps it.
+func takeSlidingWindow(policy Policy, state *State, now time.Time) (*State, *Result) {
+	start := now.Truncate(policy.Window)
+	next := &State{Start: start, ExpiresAt: start.Add(2 * policy.Window)}
+
+	if state != nil {
+		switch {
+		case state.Start.Equal(start):
+			next.Previous, next.Current = state.Previous, state.Current
+		case state.Start.Equal(start.Add(-policy.Window)):
+			next.Previous = state.Current
+		}
+	}
+
+	elapsed := now.Sub(start)
+	overlap := 1 - elapsed.Seconds()/policy.Window.Seconds()
+	estimated := float64(next.Previous)*overlap + float64(next.Current)
+
+	result := &Result{Limit: policy.Limit, Reset: policy.Window - elapsed}
+	if estimated+1 <= float64(policy.Limit) {
+		next.Current++
+		estimated++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = retryAfter(policy, next, elapsed)
+	}
+
+	result.Remaining = max(0, policy.Limit-int(math.Ceil(estimated)))
+
+	return next, result
+}
+
+// retryAfter is when the previous window share decays enough to let one more
+// request in, or the next window when the current count alone is the limit.
+func retryAfter(policy Policy, state *State, elapsed time.Duration) time.Duration {
+	available := float64(policy.Limit - 1 - state.Current)
+	if available < 0 || state.Previous == 0 {
+		return policy.Window - elapsed
+	}
+
+	at := time.Duration((1 - available/float64(state.Previous)) * float64(policy.Window))
+	return max(at-elapsed, time.Second)
+}
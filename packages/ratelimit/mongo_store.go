@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore shares the states between instances. Expired states are removed
+// by the TTL index on expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(db *database.Database) *MongoStore {
+	return &MongoStore{collection: db.Collection(database.RateLimitsCollection)}
+}
+
+// EnsureIndexes creates the TTL index of the collection, it is a no-op when
+// the index already exists.
+func (ms *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := ms.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+func (ms *MongoStore) Get(ctx context.Context, key string) (*State, error) {
+	var state State
+
+	err := ms.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// CompareAndSwap inserts the first state of a key, a duplicated key meaning a
+// concurrent request inserted it first, and updates the next ones filtering
+// by version.
+func (ms *MongoStore) CompareAndSwap(ctx context.Context, old *State, next *State) (bool, error) {
+	swapped := *next
+
+	if old == nil {
+		swapped.Version = 1
+
+		_, err := ms.collection.InsertOne(ctx, swapped)
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+
+		return err == nil, err
+	}
+
+	swapped.Version = old.Version + 1
+
+	result, err := ms.collection.ReplaceOne(ctx, bson.M{"_id": old.Key, "version": old.Version}, swapped)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
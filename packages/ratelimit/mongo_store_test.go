@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const (
+	MOCK_DB_NAME = "foo"
+	MOCK_NS      = "foo.rate_limits"
+)
+
+func TestMongoStore(t *testing.T) {
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return nil when the key has no state", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_NS, mtest.FirstBatch))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		state, err := store.Get(context.TODO(), "api:ip:1")
+
+		assert.Nil(t, err, "should not return error")
+		assert.Nil(t, state, "should not return state")
+	})
+
+	rootMt.Run("should return the state of the key", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(1, MOCK_NS, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "api:ip:1"},
+			{Key: "version", Value: int64(3)},
+			{Key: "tokens", Value: 1.5},
+		}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		state, err := store.Get(context.TODO(), "api:ip:1")
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, int64(3), state.Version, "should return the version")
+		assert.Equal(t, 1.5, state.Tokens, "should return the tokens")
+	})
+
+	rootMt.Run("should insert the first state of a key", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		swapped, err := store.CompareAndSwap(context.TODO(), nil, &State{Key: "api:ip:1", Tokens: 2})
+
+		document := nestedMt.GetStartedEvent().Command.Lookup("documents").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.True(t, swapped, "should swap the state")
+		assert.Equal(t, int64(1), document.Lookup("version").Int64(), "should insert the first version")
+	})
+
+	rootMt.Run("should not swap when a concurrent request inserted the key first", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "duplicate key error",
+		}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		swapped, err := store.CompareAndSwap(context.TODO(), nil, &State{Key: "api:ip:1"})
+
+		assert.Nil(t, err, "should not return error")
+		assert.False(t, swapped, "should not swap the state")
+	})
+
+	rootMt.Run("should replace the state filtering by version", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		old := &State{Key: "api:ip:1", Version: 3}
+		swapped, err := store.CompareAndSwap(context.TODO(), old, &State{Key: "api:ip:1", ExpiresAt: time.Now()})
+
+		update := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.False(t, swapped, "should not swap a changed state")
+		assert.Equal(t, int64(3), update.Lookup("q", "version").Int64(), "should filter by the old version")
+		assert.Equal(t, int64(4), update.Lookup("u", "version").Int64(), "should bump the version")
+	})
+
+	rootMt.Run("should create the ttl index", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		store := NewMongoStore(&database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)})
+
+		err := store.EnsureIndexes(context.TODO())
+
+		index := nestedMt.GetStartedEvent().Command.Lookup("indexes").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, int32(0), index.Lookup("expireAfterSeconds").Int32(), "should expire at expires_at")
+	})
+}
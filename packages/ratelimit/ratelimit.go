@@ -0,0 +1,268 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+)
+
+const (
+	CodeRateLimited = "ERATELIMIT"
+
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderPolicy     = "RateLimit-Policy"
+	HeaderRetryAfter = "Retry-After"
+
+	HeaderAPIKey = "X-API-Key"
+	// UserIdLocal is the local where authentication stores the id of the
+	// user of the request.
+	UserIdLocal = "user_id"
+)
+
+func init() {
+	exception.MustRegister(exception.Definition{
+		Code:     CodeRateLimited,
+		Status:   http.StatusTooManyRequests,
+		Message:  "Too many requests, try again later",
+		Messages: map[string]string{i18n.Portuguese: "Muitas requisições, tente novamente mais tarde"},
+	})
+}
+
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts of up to Limit requests, refilling Limit
+	// tokens per Window.
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow allows Limit requests in any Window, weighting the count
+	// of the previous window by how much of it still overlaps.
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// KeyFunc identifies who the request is counted for.
+type KeyFunc func(c *fiber.Ctx) string
+
+// Policy limits a route group. Its name scopes the keys, so two groups keyed
+// the same way do not share their counts.
+type Policy struct {
+	Name      string
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+	Key       KeyFunc
+}
+
+// Result is the outcome of taking a request from a policy. Reset is how long
+// until the whole limit is available again.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// maxAttempts bounds the compare and swap retries of concurrent requests of
+// the same key.
+const maxAttempts = 5
+
+var errConflict = errors.New("rate limit state changed concurrently")
+
+type Limiter struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+// NewLimiter fails when the policy has no name, limit, window or a known
+// algorithm. Policies without a key are keyed by IP.
+func NewLimiter(store Store, policy Policy) (*Limiter, error) {
+	if policy.Name == "" || policy.Limit <= 0 || policy.Window <= 0 {
+		return nil, fmt.Errorf("rate limit policy %q must have a name, a limit and a window", policy.Name)
+	}
+
+	if policy.Algorithm != TokenBucket && policy.Algorithm != SlidingWindow {
+		return nil, fmt.Errorf("rate limit policy %q has an unknown algorithm %q", policy.Name, policy.Algorithm)
+	}
+
+	if policy.Key == nil {
+		policy.Key = ByIP
+	}
+
+	return &Limiter{store: store, policy: policy, now: time.Now}, nil
+}
+
+// Take counts a request for the key, retrying when a concurrent request of
+// the same key changed the state in between.
+func (l *Limiter) Take(ctx context.Context, key string) (*Result, error) {
+	key = l.policy.Name + ":" + key
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		state, err := l.store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		next, result := take(l.policy, state, l.now())
+		next.Key = key
+
+		swapped, err := l.store.CompareAndSwap(ctx, state, next)
+		if err != nil {
+			return nil, err
+		}
+
+		if swapped {
+			return result, nil
+		}
+	}
+
+	return nil, errConflict
+}
+
+// Middleware answers the RateLimit headers and ERATELIMIT, with Retry-After,
+// once the limit is reached. Requests are let through when the store fails,
+// so an unavailable store does not take the API down.
+func (l *Limiter) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, err := l.Take(c.UserContext(), l.policy.Key(c))
+		if err != nil {
+			logger.Default().WithCtx(c.UserContext()).Error("rate limit failed", "policy", l.policy.Name, "cause", err.Error())
+			return c.Next()
+		}
+
+		c.Set(HeaderLimit, strconv.Itoa(result.Limit))
+		c.Set(HeaderRemaining, strconv.Itoa(result.Remaining))
+		c.Set(HeaderReset, seconds(result.Reset))
+		c.Set(HeaderPolicy, fmt.Sprintf("%d;w=%s", l.policy.Limit, seconds(l.policy.Window)))
+
+		if !result.Allowed {
+			c.Set(HeaderRetryAfter, seconds(result.RetryAfter))
+			return exception.New(CodeRateLimited)
+		}
+
+		return c.Next()
+	}
+}
+
+// ByIP counts requests by ClientIP.
+func ByIP(c *fiber.Ctx) string {
+	return IPKey(ClientIP(c))
+}
+
+// IPKey is the key ByIP counts the requests of the ip under, for callers
+// outside of HTTP to share the counts.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// ClientIP is the address of the client of the request. Requests of the
+// proxies trusted by the app, through its ProxyHeader, EnableTrustedProxyCheck
+// and TrustedProxies, are attributed to the last address of the proxy header
+// that is not a trusted proxy, since clients can send any address in front of
+// the ones the proxies add.
+func ClientIP(c *fiber.Ctx) string {
+	remote := c.Context().RemoteIP().String()
+
+	config := c.App().Config()
+	if config.ProxyHeader == "" || !c.IsProxyTrusted() {
+		return remote
+	}
+
+	trusted := parseProxies(config.TrustedProxies)
+	addresses := strings.Split(c.Get(config.ProxyHeader), ",")
+
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil {
+			break
+		}
+
+		if !trusted.contains(ip) {
+			return ip.String()
+		}
+	}
+
+	return remote
+}
+
+type proxies []*net.IPNet
+
+// parseProxies reads IPs and CIDR ranges, skipping malformed ones as Fiber
+// does.
+func parseProxies(addresses []string) proxies {
+	parsed := make(proxies, 0, len(addresses))
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			parsed = append(parsed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(address); err == nil {
+			parsed = append(parsed, ipNet)
+		}
+	}
+
+	return parsed
+}
+
+func (p proxies) contains(ip net.IP) bool {
+	for _, ipNet := range p {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ByUserId counts authenticated requests by user and the others by IP.
+func ByUserId(c *fiber.Ctx) string {
+	if id, ok := c.Locals(UserIdLocal).(string); ok && id != "" {
+		return "user:" + id
+	}
+
+	return ByIP(c)
+}
+
+// ByAPIKey counts requests by the X-API-Key header when it is one of the
+// keys, and the others by IP, so sending made up keys does not open new
+// buckets. The key is hashed, so it is not stored along with the counts.
+func ByAPIKey(keys ...string) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		key := c.Get(HeaderAPIKey)
+
+		for _, known := range keys {
+			if known != "" && subtle.ConstantTimeCompare([]byte(key), []byte(known)) == 1 {
+				hash := sha256.Sum256([]byte(key))
+				return "key:" + hex.EncodeToString(hash[:])
+			}
+		}
+
+		return ByIP(c)
+	}
+}
+
+func seconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
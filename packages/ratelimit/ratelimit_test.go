@@ -0,0 +1,262 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (fs failingStore) Get(ctx context.Context, key string) (*State, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (fs failingStore) CompareAndSwap(ctx context.Context, old *State, next *State) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+// BeforeEach_TestLimiter returns a limiter whose clock is moved by the
+// returned func.
+func BeforeEach_TestLimiter(t *testing.T, store Store, policy Policy) (*Limiter, func(time.Duration)) {
+	limiter, err := NewLimiter(store, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	return limiter, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	t.Run("should allow a burst up to the limit and refill over the window", func(t *testing.T) {
+		limiter, advance := BeforeEach_TestLimiter(t, NewMemoryStore(), Policy{
+			Name: "api", Algorithm: TokenBucket, Limit: 3, Window: 3 * time.Second,
+		})
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			result, err := limiter.Take(context.TODO(), "ip:1")
+			assert.Nil(t, err, "should not return error")
+			assert.True(t, result.Allowed, "should allow the burst")
+			assert.Equal(t, remaining, result.Remaining, "should spend a token")
+		}
+
+		result, _ := limiter.Take(context.TODO(), "ip:1")
+		assert.False(t, result.Allowed, "should deny once the bucket is empty")
+		assert.Equal(t, time.Second, result.RetryAfter, "should retry when a token is refilled")
+
+		other, _ := limiter.Take(context.TODO(), "ip:2")
+		assert.True(t, other.Allowed, "should count other keys apart")
+
+		advance(time.Second)
+		result, _ = limiter.Take(context.TODO(), "ip:1")
+		assert.True(t, result.Allowed, "should allow after the refill")
+	})
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	t.Run("should weight the previous window by its overlap", func(t *testing.T) {
+		limiter, advance := BeforeEach_TestLimiter(t, NewMemoryStore(), Policy{
+			Name: "signup", Algorithm: SlidingWindow, Limit: 4, Window: time.Minute,
+		})
+
+		for i := 0; i < 4; i++ {
+			result, _ := limiter.Take(context.TODO(), "ip:1")
+			assert.True(t, result.Allowed, "should allow up to the limit")
+		}
+
+		result, _ := limiter.Take(context.TODO(), "ip:1")
+		assert.False(t, result.Allowed, "should deny over the limit")
+		assert.Equal(t, time.Minute, result.RetryAfter, "should retry in the next window")
+
+		advance(time.Minute + 15*time.Second)
+		result, _ = limiter.Take(context.TODO(), "ip:1")
+		assert.True(t, result.Allowed, "should allow as the previous window leaves")
+		assert.Equal(t, 0, result.Remaining, "should count 3 of the previous window and the current one")
+
+		result, _ = limiter.Take(context.TODO(), "ip:1")
+		assert.False(t, result.Allowed, "should deny over the estimated limit")
+		assert.Equal(t, 15*time.Second, result.RetryAfter, "should retry when the previous window share decays")
+
+		advance(2 * time.Minute)
+		result, _ = limiter.Take(context.TODO(), "ip:1")
+		assert.True(t, result.Allowed, "should forget windows older than the previous one")
+		assert.Equal(t, 3, result.Remaining, "should count only the current request")
+	})
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	BeforeEach_TestMiddleware := func(t *testing.T, store Store) *fiber.App {
+		limiter, _ := BeforeEach_TestLimiter(t, store, Policy{
+			Name: "signup", Algorithm: SlidingWindow, Limit: 1, Window: time.Minute,
+		})
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Use(limiter.Middleware())
+		fbr.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(200) })
+		return fbr
+	}
+
+	t.Run("should answer the rate limit headers and ERATELIMIT over the limit", func(t *testing.T) {
+		fbr := BeforeEach_TestMiddleware(t, NewMemoryStore())
+
+		response, err := fbr.Test(httptest.NewRequest("GET", "/", nil), -1)
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 200, response.StatusCode, "should allow the first request")
+		assert.Equal(t, "1", response.Header.Get(HeaderLimit), "should answer the limit")
+		assert.Equal(t, "0", response.Header.Get(HeaderRemaining), "should answer the remaining requests")
+		assert.Equal(t, "60", response.Header.Get(HeaderReset), "should answer when the window resets")
+		assert.Equal(t, "1;w=60", response.Header.Get(HeaderPolicy), "should answer the policy")
+
+		response, _ = fbr.Test(httptest.NewRequest("GET", "/", nil), -1)
+		assert.Equal(t, 429, response.StatusCode, "should deny the second request")
+		assert.Equal(t, "60", response.Header.Get(HeaderRetryAfter), "should answer when to retry")
+	})
+
+	t.Run("should let requests through when the store fails", func(t *testing.T) {
+		var logs bytes.Buffer
+		defaultLogger := logger.Default()
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Output: &logs}))
+		defer logger.SetDefault(defaultLogger)
+
+		fbr := BeforeEach_TestMiddleware(t, failingStore{})
+
+		response, _ := fbr.Test(httptest.NewRequest("GET", "/", nil), -1)
+
+		assert.Equal(t, 200, response.StatusCode, "should allow the request")
+		assert.Empty(t, response.Header.Get(HeaderLimit), "should not answer the rate limit headers")
+		assert.Contains(t, logs.String(), "policy=signup", "should log the failed policy as a field")
+		assert.Contains(t, logs.String(), "correlation_id=", "should log the correlation id")
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	BeforeEach_TestClientIP := func(config fiber.Config) *fiber.App {
+		fbr := fiber.New(config)
+		fbr.Get("/", func(c *fiber.Ctx) error { return c.SendString(ClientIP(c)) })
+		return fbr
+	}
+
+	clientIP := func(t *testing.T, fbr *fiber.App, forwardedFor string) string {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+
+		response, err := fbr.Test(request, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+
+	t.Run("should take the remote address without a proxy header", func(t *testing.T) {
+		fbr := BeforeEach_TestClientIP(fiber.Config{})
+
+		assert.Equal(t, "0.0.0.0", clientIP(t, fbr, "203.0.113.7"), "should ignore the header")
+	})
+
+	t.Run("should take the last address not added by a trusted proxy", func(t *testing.T) {
+		fbr := BeforeEach_TestClientIP(fiber.Config{
+			ProxyHeader:             fiber.HeaderXForwardedFor,
+			EnableTrustedProxyCheck: true,
+			TrustedProxies:          []string{"0.0.0.0", "10.0.0.0/8"},
+		})
+
+		assert.Equal(t, "203.0.113.7", clientIP(t, fbr, "203.0.113.7"))
+		assert.Equal(t, "203.0.113.7", clientIP(t, fbr, "198.51.100.1, 203.0.113.7, 10.0.0.2"), "should skip forged and trusted addresses")
+		assert.Equal(t, "0.0.0.0", clientIP(t, fbr, ""), "should take the proxy without the header")
+	})
+
+	t.Run("should take the remote address of untrusted proxies", func(t *testing.T) {
+		fbr := BeforeEach_TestClientIP(fiber.Config{
+			ProxyHeader:             fiber.HeaderXForwardedFor,
+			EnableTrustedProxyCheck: true,
+			TrustedProxies:          []string{"10.0.0.1"},
+		})
+
+		assert.Equal(t, "0.0.0.0", clientIP(t, fbr, "203.0.113.7"), "should not trust the header")
+	})
+}
+
+func TestLimiter_NewLimiter(t *testing.T) {
+	t.Run("should return error when the policy is invalid", func(t *testing.T) {
+		for _, policy := range []Policy{
+			{Algorithm: TokenBucket, Limit: 1, Window: time.Second},
+			{Name: "api", Algorithm: TokenBucket, Window: time.Second},
+			{Name: "api", Algorithm: TokenBucket, Limit: 1},
+			{Name: "api", Algorithm: "fixed_window", Limit: 1, Window: time.Second},
+		} {
+			_, err := NewLimiter(NewMemoryStore(), policy)
+			assert.NotNil(t, err, "should return error for %+v", policy)
+		}
+	})
+}
+
+func TestLimiter_Keys(t *testing.T) {
+	fbr := fiber.New()
+
+	t.Run("should key by user id or api key falling back to ip", func(t *testing.T) {
+		var keys []string
+		fbr.Get("/", func(c *fiber.Ctx) error {
+			keys = append(keys, ByUserId(c), ByAPIKey("secret")(c))
+			c.Locals(UserIdLocal, "65e1f0a2")
+			keys = append(keys, ByUserId(c))
+			return nil
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		fbr.Test(req, -1)
+		req.Header.Set(HeaderAPIKey, "secret")
+		fbr.Test(req, -1)
+
+		assert.Equal(t, "ip:0.0.0.0", keys[0], "should key anonymous requests by ip")
+		assert.Equal(t, "ip:0.0.0.0", keys[1], "should key requests without api key by ip")
+		assert.Equal(t, "user:65e1f0a2", keys[2], "should key by user id")
+		assert.Equal(t, "key:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", keys[4], "should key by the api key hash")
+	})
+
+	t.Run("should key unknown api keys by ip", func(t *testing.T) {
+		var key string
+		fbr.Get("/unknown", func(c *fiber.Ctx) error {
+			key = ByAPIKey("secret")(c)
+			return nil
+		})
+
+		req := httptest.NewRequest("GET", "/unknown", nil)
+		req.Header.Set(HeaderAPIKey, "made-up")
+		fbr.Test(req, -1)
+
+		assert.Equal(t, "ip:0.0.0.0", key, "should not open a bucket for the key")
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("should not swap a state changed concurrently", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.CompareAndSwap(context.TODO(), nil, &State{Key: "api:ip:1"})
+		old, _ := store.Get(context.TODO(), "api:ip:1")
+
+		first, _ := store.CompareAndSwap(context.TODO(), old, &State{Key: "api:ip:1", Current: 1})
+		second, _ := store.CompareAndSwap(context.TODO(), old, &State{Key: "api:ip:1", Current: 2})
+		inserted, _ := store.CompareAndSwap(context.TODO(), nil, &State{Key: "api:ip:1"})
+
+		state, _ := store.Get(context.TODO(), "api:ip:1")
+		assert.True(t, first, "should swap the first update")
+		assert.False(t, second, "should not swap the stale update")
+		assert.False(t, inserted, "should not insert an existing key")
+		assert.Equal(t, 1, state.Current, "should keep the first update")
+		assert.Equal(t, int64(2), state.Version, "should bump the version")
+	})
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is what a policy keeps per key. Token buckets use Tokens and Start as
+// the last refill, sliding windows use the counts and Start as the current
+// window start. Version guards concurrent updates of the same key.
+type State struct {
+	Key       string    `bson:"_id"`
+	Version   int64     `bson:"version"`
+	Tokens    float64   `bson:"tokens"`
+	Previous  int       `bson:"previous"`
+	Current   int       `bson:"current"`
+	Start     time.Time `bson:"start"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Store keeps the states of the keys. CompareAndSwap replaces old by next
+// only when the stored state is still old, nil meaning there is none, and
+// bumps the version.
+type Store interface {
+	Get(ctx context.Context, key string) (*State, error)
+	CompareAndSwap(ctx context.Context, old *State, next *State) (bool, error)
+}
+
+// MemoryStore keeps the states in the process, for single instance
+// deployments and tests.
+type MemoryStore struct {
+	mutex  sync.Mutex
+	states map[string]State
+	swept  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]State{}}
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, key string) (*State, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	state, ok := ms.states[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (ms *MemoryStore) CompareAndSwap(ctx context.Context, old *State, next *State) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.sweep(time.Now())
+
+	stored, ok := ms.states[next.Key]
+	if ok != (old != nil) || (ok && stored.Version != old.Version) {
+		return false, nil
+	}
+
+	swapped := *next
+	swapped.Version = stored.Version + 1
+	ms.states[next.Key] = swapped
+
+	return true, nil
+}
+
+// sweep drops the expired states once a minute, so keys that stopped sending
+// requests do not pile up.
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.swept) < time.Minute {
+		return
+	}
+
+	for key, state := range ms.states {
+		if now.After(state.ExpiresAt) {
+			delete(ms.states, key)
+		}
+	}
+
+	ms.swept = now
+}
@@ -0,0 +1,260 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys are the HTTP headers lowercased, as gRPC sends them.
+const (
+	MetadataCorrelationId  = "x-correlation-id"
+	MetadataAPIKey         = "x-api-key"
+	MetadataAcceptLanguage = "accept-language"
+	MetadataLanguage       = "content-language"
+	MetadataRetryAfter     = "retry-after"
+)
+
+type Config struct {
+	Logger logger.LoggerInterface
+	// APIKey is the key callers must send in the x-api-key metadata. With no
+	// key set, every call is refused.
+	APIKey string
+	// Metrics counts the calls along with the HTTP requests, when set.
+	Metrics *metrics.Metrics
+	// Limiter counts the calls by the address of the caller, when set. Its
+	// policy should be keyed by ratelimit.ByIP to share the HTTP counts.
+	Limiter *ratelimit.Limiter
+}
+
+// NewServer returns a gRPC server whose calls go through Recovery,
+// Correlation, Language, tracing, metrics, Logging, Errors, RateLimit and
+// APIKey, in this order. Metrics and RateLimit are skipped when the config
+// has none.
+func NewServer(config Config, options ...grpc.ServerOption) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		Recovery(config.Logger),
+		Correlation(),
+		Language(),
+		tracing.UnaryServerInterceptor(),
+	}
+
+	if config.Metrics != nil {
+		interceptors = append(interceptors, config.Metrics.UnaryServerInterceptor())
+	}
+
+	interceptors = append(interceptors, Logging(config.Logger), Errors(config.Logger))
+
+	if config.Limiter != nil {
+		interceptors = append(interceptors, RateLimit(config.Limiter))
+	}
+
+	interceptors = append(interceptors, APIKey(config.APIKey))
+
+	chain := grpc.ChainUnaryInterceptor(interceptors...)
+
+	return grpc.NewServer(append([]grpc.ServerOption{chain}, options...)...)
+}
+
+// Shutdown stops the server once the calls in progress finish, or right away
+// when ctx is done before.
+func Shutdown(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// Recovery answers the calls whose handler panics as internal errors, logging
+// the panic along with its stack, so the process keeps serving.
+func Recovery(log logger.LoggerInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			log.WithCtx(ctx).Error(
+				"grpc call panicked",
+				"method", info.FullMethod,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+
+			resp, err = nil, Status(ctx, exception.New(exception.CodeInternal)).Err()
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// Correlation accepts the inbound correlation id or creates one, answers it
+// in the header metadata and stores it in the context handed to the handler.
+func Correlation() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		carrier := map[string]string{correlation.Header: first(ctx, MetadataCorrelationId)}
+		ctx = correlation.Extract(ctx, carrier)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataCorrelationId, correlation.FromContext(ctx)))
+
+		return handler(ctx, req)
+	}
+}
+
+// Language picks the first supported language of the accept-language
+// metadata, answers it as content-language and stores it in the context.
+// Any Portuguese variant is answered in pt-BR.
+func Language() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		language := acceptedLanguage(first(ctx, MetadataAcceptLanguage))
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataLanguage, language))
+
+		return handler(i18n.WithLanguage(ctx, language), req)
+	}
+}
+
+// Logging logs every call with its method, answered code and latency.
+func Logging(log logger.LoggerInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		started := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		log.WithCtx(ctx).Info(
+			"grpc call",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency", time.Since(started).String(),
+		)
+
+		return resp, err
+	}
+}
+
+// Errors answers the errors returned by the handlers through Status, logging
+// the cause of the ones answered as server errors.
+func Errors(log logger.LoggerInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if _, ok := status.FromError(err); !ok {
+			if typed := toError(err); typed.Status >= http.StatusInternalServerError {
+				log.WithCtx(ctx).Error("grpc call failed", "error_code", typed.Code, "cause", causeOf(err))
+			}
+		}
+
+		return nil, Status(ctx, err).Err()
+	}
+}
+
+// RateLimit counts the calls by the address of the caller under
+// ratelimit.IPKey, so given the limiter of a policy keyed by ratelimit.ByIP a
+// client is counted once over HTTP and gRPC. Once the limit is reached, calls
+// are answered ERATELIMIT with the retry-after metadata. Calls are let
+// through when the store fails.
+func RateLimit(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		result, err := limiter.Take(ctx, ratelimit.IPKey(peerIP(ctx)))
+		if err != nil {
+			logger.Default().WithCtx(ctx).Error("rate limit failed", "method", info.FullMethod, "cause", err.Error())
+			return handler(ctx, req)
+		}
+
+		if !result.Allowed {
+			retryAfter := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+			_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRetryAfter, retryAfter))
+
+			return nil, exception.New(ratelimit.CodeRateLimited)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// APIKey lets through calls carrying the key in the x-api-key metadata. With
+// no key set, every call is refused.
+func APIKey(apiKey string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := first(ctx, MetadataAPIKey)
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			return nil, exception.New(exception.CodePermission)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// first returns the first value of the incoming metadata key, or an empty
+// string.
+func first(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// peerIP returns the address of the caller without its port.
+func peerIP(ctx context.Context) string {
+	caller, ok := peer.FromContext(ctx)
+	if !ok || caller.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(caller.Addr.String())
+	if err != nil {
+		return caller.Addr.String()
+	}
+
+	return host
+}
+
+// acceptedLanguage returns the first language of an Accept-Language value
+// that is supported, ignoring weights, or the default language.
+func acceptedLanguage(accepted string) string {
+	for _, tag := range strings.Split(accepted, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		switch {
+		case tag == "pt" || strings.HasPrefix(tag, "pt-"):
+			return i18n.Portuguese
+		case tag == "en" || strings.HasPrefix(tag, "en-") || tag == "*":
+			return i18n.English
+		}
+	}
+
+	return i18n.English
+}
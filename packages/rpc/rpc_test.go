@@ -0,0 +1,297 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/packages/rpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// stream records the header metadata set by the interceptors.
+type stream struct {
+	header metadata.MD
+}
+
+func (s *stream) Method() string { return "/test.Service/Method" }
+
+func (s *stream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *stream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *stream) SetTrailer(md metadata.MD) error { return nil }
+
+type TestingDependencies_TestInterceptors struct {
+	ctx    context.Context
+	stream *stream
+	info   *grpc.UnaryServerInfo
+}
+
+func BeforeEach_TestInterceptors(t *testing.T, pairs ...string) *TestingDependencies_TestInterceptors {
+	s := &stream{}
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(pairs...))
+
+	return &TestingDependencies_TestInterceptors{
+		ctx:    grpc.NewContextWithServerTransportStream(ctx, s),
+		stream: s,
+		info:   &grpc.UnaryServerInfo{FullMethod: s.Method()},
+	}
+}
+
+func TestCode(t *testing.T) {
+	cases := map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusUnauthorized:        codes.Unauthenticated,
+		http.StatusForbidden:           codes.PermissionDenied,
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusConflict:            codes.Aborted,
+		http.StatusUnprocessableEntity: codes.FailedPrecondition,
+		http.StatusLocked:              codes.FailedPrecondition,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+		http.StatusNotImplemented:      codes.Unimplemented,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+		http.StatusTeapot:              codes.Internal,
+	}
+
+	for httpStatus, code := range cases {
+		t.Run("should map "+http.StatusText(httpStatus), func(t *testing.T) {
+			assert.Equal(t, code, rpc.Code(httpStatus), "should return the gRPC code")
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("should answer typed errors with their code and details", func(t *testing.T) {
+		err := exception.NewValidationError([]exception.FieldError{{Field: "email", Message: "invalid"}})
+
+		answered := rpc.Status(context.TODO(), err)
+
+		assert.Equal(t, codes.InvalidArgument, answered.Code(), "should answer the code of the status")
+		assert.Equal(t, exception.CodeValidationFailed, rpc.ErrorCode(answered), "should answer the error code")
+		assert.Len(t, answered.Details(), 2, "should answer the error info and the bad request")
+	})
+
+	t.Run("should answer plain registered codes", func(t *testing.T) {
+		answered := rpc.Status(context.TODO(), errors.New(exception.CodeNotFound))
+
+		assert.Equal(t, codes.NotFound, answered.Code(), "should answer not found")
+		assert.Equal(t, exception.CodeNotFound, rpc.ErrorCode(answered), "should answer the error code")
+	})
+
+	t.Run("should answer deadlines as timeouts", func(t *testing.T) {
+		answered := rpc.Status(context.TODO(), context.DeadlineExceeded)
+
+		assert.Equal(t, codes.DeadlineExceeded, answered.Code(), "should answer deadline exceeded")
+		assert.Equal(t, exception.CodeTimeout, rpc.ErrorCode(answered), "should answer the error code")
+	})
+
+	t.Run("should answer anything else as internal", func(t *testing.T) {
+		answered := rpc.Status(context.TODO(), errors.New("boom"))
+
+		assert.Equal(t, codes.Internal, answered.Code(), "should answer internal")
+		assert.Equal(t, exception.CodeInternal, rpc.ErrorCode(answered), "should answer the error code")
+		assert.NotContains(t, answered.Message(), "boom", "should not leak the cause")
+	})
+
+	t.Run("should keep gRPC statuses", func(t *testing.T) {
+		answered := rpc.Status(context.TODO(), status.Error(codes.Canceled, "canceled"))
+
+		assert.Equal(t, codes.Canceled, answered.Code(), "should keep the code")
+		assert.Empty(t, rpc.ErrorCode(answered), "should not add an error code")
+	})
+
+	t.Run("should answer in the language of the context", func(t *testing.T) {
+		ctx := i18n.WithLanguage(context.TODO(), i18n.Portuguese)
+
+		answered := rpc.Status(ctx, exception.New(exception.CodeNotFound))
+
+		assert.Equal(t, "Entidade não encontrada", answered.Message(), "should answer in Portuguese")
+	})
+}
+
+func TestCorrelation(t *testing.T) {
+	t.Run("should pass the inbound id to the context and the header", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t, rpc.MetadataCorrelationId, "abc-123")
+
+		var fromContext string
+		_, err := rpc.Correlation()(deps.ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+			fromContext = correlation.FromContext(ctx)
+			return nil, nil
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "abc-123", fromContext, "should inject the inbound id")
+		assert.Equal(t, []string{"abc-123"}, deps.stream.header.Get(rpc.MetadataCorrelationId), "should answer the id")
+	})
+
+	t.Run("should create an id when the inbound one is invalid", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t, rpc.MetadataCorrelationId, "not valid!")
+
+		var fromContext string
+		rpc.Correlation()(deps.ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+			fromContext = correlation.FromContext(ctx)
+			return nil, nil
+		})
+
+		assert.NotEmpty(t, fromContext, "should create an id")
+		assert.NotEqual(t, "not valid!", fromContext, "should not accept the invalid id")
+	})
+}
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                  i18n.English,
+		"pt-PT,en;q=0.8":    i18n.Portuguese,
+		"fr, en-US;q=0.5":   i18n.English,
+		"fr, PT;q=0.5":      i18n.Portuguese,
+		"de":                i18n.English,
+		"en-GB, pt-BR;q=.9": i18n.English,
+	}
+
+	for accepted, language := range cases {
+		t.Run("should pick the language of "+accepted, func(t *testing.T) {
+			deps := BeforeEach_TestInterceptors(t, rpc.MetadataAcceptLanguage, accepted)
+
+			var fromContext string
+			rpc.Language()(deps.ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+				fromContext = i18n.FromContext(ctx)
+				return nil, nil
+			})
+
+			assert.Equal(t, language, fromContext, "should store the language")
+			assert.Equal(t, []string{language}, deps.stream.header.Get(rpc.MetadataLanguage), "should answer the language")
+		})
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	cases := []struct {
+		name   string
+		apiKey string
+		pairs  []string
+		allow  bool
+	}{
+		{name: "should let through the configured key", apiKey: "key", pairs: []string{rpc.MetadataAPIKey, "key"}, allow: true},
+		{name: "should refuse a wrong key", apiKey: "key", pairs: []string{rpc.MetadataAPIKey, "other"}},
+		{name: "should refuse a missing key", apiKey: "key"},
+		{name: "should refuse every call without a configured key", pairs: []string{rpc.MetadataAPIKey, ""}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deps := BeforeEach_TestInterceptors(t, c.pairs...)
+
+			resp, err := rpc.APIKey(c.apiKey)(deps.ctx, nil, deps.info, handler)
+
+			if c.allow {
+				assert.Nil(t, err, "should not return error")
+				assert.Equal(t, "ok", resp, "should call the handler")
+				return
+			}
+
+			assert.ErrorIs(t, err, exception.ErrPermission, "should return a permission error")
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("should answer the handler errors as statuses", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t)
+
+		_, err := rpc.Errors(logger.NewLoggerWithConfig(logger.Config{Output: io.Discard}))(deps.ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+			return nil, exception.New(exception.CodePermission)
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), "should answer permission denied")
+	})
+
+	t.Run("should log the cause of server errors", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t)
+
+		var logs bytes.Buffer
+		log := logger.NewLoggerWithConfig(logger.Config{Output: &logs})
+		ctx := correlation.WithId(deps.ctx, "b7f1c2")
+
+		_, err := rpc.Errors(log)(ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+			return nil, exception.Wrap(exception.CodeDatabaseFailed, errors.New("connection refused"))
+		})
+
+		assert.Equal(t, codes.Internal, status.Code(err), "should answer internal")
+		assert.Contains(t, logs.String(), "grpc call failed", "should log the failure")
+		assert.Contains(t, logs.String(), "connection refused", "should log the cause")
+		assert.Contains(t, logs.String(), "correlation_id=b7f1c2", "should log the correlation id")
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	t.Run("should answer panics as internal errors", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t)
+
+		var logs bytes.Buffer
+		log := logger.NewLoggerWithConfig(logger.Config{Output: &logs})
+
+		resp, err := rpc.Recovery(log)(deps.ctx, nil, deps.info, func(ctx context.Context, req any) (any, error) {
+			panic("boom")
+		})
+
+		assert.Nil(t, resp, "should not answer a response")
+		assert.Equal(t, codes.Internal, status.Code(err), "should answer internal")
+		assert.Equal(t, exception.CodeInternal, rpc.ErrorCode(status.Convert(err)), "should answer the internal code")
+		assert.Contains(t, logs.String(), "grpc call panicked", "should log the panic")
+		assert.Contains(t, logs.String(), "boom", "should log the panic value")
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("should answer resource exhausted once the limit is reached", func(t *testing.T) {
+		deps := BeforeEach_TestInterceptors(t)
+		ctx := peer.NewContext(deps.ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 5000}})
+
+		limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Policy{
+			Name:      "api",
+			Algorithm: ratelimit.TokenBucket,
+			Limit:     1,
+			Window:    time.Minute,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		interceptor := rpc.RateLimit(limiter)
+		handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+		_, allowed := interceptor(ctx, nil, deps.info, handler)
+		_, limited := interceptor(ctx, nil, deps.info, handler)
+
+		assert.Nil(t, allowed, "should let the first call through")
+		assert.ErrorIs(t, limited, exception.New(ratelimit.CodeRateLimited), "should answer the rate limit error")
+		assert.NotEmpty(t, deps.stream.header.Get(rpc.MetadataRetryAfter), "should answer when to retry")
+
+		result, err := limiter.Take(context.TODO(), ratelimit.IPKey("203.0.113.7"))
+		assert.Nil(t, err, "should not return error")
+		assert.False(t, result.Allowed, "should count the calls by the address of the caller")
+	})
+}
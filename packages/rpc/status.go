@@ -0,0 +1,137 @@
+// Package rpc serves the app services over gRPC the way the HTTP layer does:
+// errors are answered by their exception code, and requests carry a
+// correlation id, a language and an API key.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the domain of the ErrorInfo details, whose reason is the
+// exception code, e.g. ENOTFOUND.
+const Domain = "braz_ecommerce"
+
+// Code returns the gRPC code of an exception status.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusUnprocessableEntity, http.StatusLocked, http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+
+	return codes.Internal
+}
+
+// Status answers err as HttpExceptionHandler does: typed errors and plain
+// registered codes with their own status and message, in the language of
+// ctx, and anything else as an internal error. The code goes in an
+// ErrorInfo detail and the failed fields in a BadRequest detail.
+func Status(ctx context.Context, err error) *status.Status {
+	if _, ok := status.FromError(err); ok {
+		return status.Convert(err)
+	}
+
+	typed := toError(err)
+	message := localize(typed, i18n.FromContext(ctx))
+
+	answered := status.New(Code(typed.Status), message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: typed.Code, Domain: Domain}}
+	if len(typed.Details) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(typed.Details))
+		for _, detail := range typed.Details {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       detail.Field,
+				Description: detail.Message,
+			})
+		}
+
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	withDetails, detailsErr := answered.WithDetails(details...)
+	if detailsErr != nil {
+		return answered
+	}
+
+	return withDetails
+}
+
+// ErrorCode returns the exception code of a status answered by Status, or an
+// empty string.
+func ErrorCode(answered *status.Status) string {
+	for _, detail := range answered.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == Domain {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
+
+func toError(err error) *exception.Error {
+	var typed *exception.Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return exception.New(exception.CodeTimeout)
+	}
+
+	if _, ok := exception.Lookup(err.Error()); ok {
+		return exception.New(err.Error())
+	}
+
+	return exception.New(exception.CodeInternal)
+}
+
+// localize answers the registered message of the code in the language, unless
+// the error was given a message of its own.
+func localize(typed *exception.Error, language string) string {
+	definition, ok := exception.Lookup(typed.Code)
+	if !ok || typed.Message != definition.Message {
+		return typed.Message
+	}
+
+	if message, ok := definition.Messages[language]; ok {
+		return message
+	}
+
+	return typed.Message
+}
+
+func causeOf(err error) string {
+	cause := err
+	for unwrapped := errors.Unwrap(cause); unwrapped != nil; unwrapped = errors.Unwrap(cause) {
+		cause = unwrapped
+	}
+
+	return cause.Error()
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	values := metadata.MD(mc).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (mc metadataCarrier) Set(key string, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UnaryServerInterceptor continues the trace of the inbound traceparent
+// metadata, or starts a new one, in a server span per call named after its
+// method. Calls answered with a server error code mark the span as failed.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md.Copy()))
+
+		ctx, span := otel.Tracer(TracerName).Start(
+			ctx,
+			info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCMethod(info.FullMethod),
+				attribute.String("correlation_id", correlation.FromContext(ctx)),
+			),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		if serverError(code) {
+			span.SetStatus(codes.Error, code.String())
+		}
+
+		return resp, err
+	}
+}
+
+// serverError tells the codes answered for failures of the server rather
+// than of the call.
+func serverError(code grpccodes.Code) bool {
+	switch code {
+	case grpccodes.Unknown,
+		grpccodes.DeadlineExceeded,
+		grpccodes.Unimplemented,
+		grpccodes.Internal,
+		grpccodes.Unavailable,
+		grpccodes.DataLoss:
+		return true
+	}
+
+	return false
+}
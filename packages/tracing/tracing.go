@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracerName = "github.com/italoservio/braz_ecommerce"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+type Config struct {
+	ServiceName string
+	Exporter    string
+	File        string
+}
+
+// Setup registers the global tracer provider and the W3C trace context
+// propagator. The returned function flushes the pending spans and must be
+// called before the process exits.
+func Setup(cfg Config) (func(ctx context.Context) error, error) {
+	output, err := exporterOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+		)),
+	}
+
+	if output != nil {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(output))
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+
+		if closer, ok := output.(io.Closer); ok && output != os.Stdout {
+			closer.Close()
+		}
+
+		return err
+	}, nil
+}
+
+func exporterOutput(cfg Config) (io.Writer, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return os.Stdout, nil
+	case ExporterFile:
+		return os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	}
+
+	return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+}
+
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, when there is one, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// FilterShape describes a database filter keeping its fields and operators
+// but hiding every value, so spans never carry personal data, e.g.
+// {"email":"?","deleted_at":"?"}.
+func FilterShape(filter any) string {
+	if filter == nil {
+		return "{}"
+	}
+
+	bytes, err := bson.Marshal(filter)
+	if err != nil {
+		return "?"
+	}
+
+	var document bson.D
+	if err := bson.Unmarshal(bytes, &document); err != nil {
+		return "?"
+	}
+
+	return shape(document)
+}
+
+func shape(value any) string {
+	switch typed := value.(type) {
+	case bson.D:
+		fields := make([]string, 0, len(typed))
+		for _, element := range typed {
+			fields = append(fields, fmt.Sprintf("%q:%s", element.Key, shape(element.Value)))
+		}
+
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, ",") + "}"
+	case bson.A:
+		if len(typed) == 0 {
+			return "[]"
+		}
+
+		return "[" + shape(typed[0]) + "]"
+	}
+
+	return `"?"`
+}
@@ -0,0 +1,225 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	traceId     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceId + "-00f067aa0ba902b7-01"
+)
+
+func BeforeEach_TestTracing(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func findAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing_New(t *testing.T) {
+	t.Run("should continue the inbound trace and name the span after the route", func(t *testing.T) {
+		recorder := BeforeEach_TestTracing(t)
+
+		var childTraceId string
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Use(correlation.New())
+		fbr.Use(tracing.New())
+		fbr.Get("/users/:id", func(c *fiber.Ctx) error {
+			_, span := tracing.Start(c.UserContext(), "app.GetUserById")
+			childTraceId = span.SpanContext().TraceID().String()
+			span.End()
+			return c.SendStatus(200)
+		})
+
+		req := httptest.NewRequest("GET", "/users/123", nil)
+		req.Header.Set("traceparent", traceparent)
+		req.Header.Set(correlation.Header, "abc-123")
+		_, err := fbr.Test(req, -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, traceId, childTraceId, "should pass the trace to the app services")
+
+		spans := recorder.Ended()
+		assert.Equal(t, 2, len(spans), "should record the server and the app spans")
+
+		server := spans[1]
+		assert.Equal(t, "GET /users/:id", server.Name(), "should name the span after the route")
+		assert.Equal(t, traceId, server.SpanContext().TraceID().String(), "should continue the inbound trace")
+		assert.Equal(t, int64(200), findAttribute(server, "http.response.status_code").AsInt64())
+		assert.Equal(t, "abc-123", findAttribute(server, "correlation_id").AsString())
+	})
+
+	t.Run("should record the status answered by the error handler", func(t *testing.T) {
+		recorder := BeforeEach_TestTracing(t)
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Use(tracing.New())
+		fbr.Get("/", func(c *fiber.Ctx) error {
+			return errors.New(exception.CodeDatabaseFailed)
+		})
+
+		response, err := fbr.Test(httptest.NewRequest("GET", "/", nil), -1)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 500, response.StatusCode, "should answer the error")
+
+		server := recorder.Ended()[0]
+		assert.Equal(t, int64(500), findAttribute(server, "http.response.status_code").AsInt64())
+		assert.Equal(t, codes.Error, server.Status().Code, "should mark the span as failed")
+	})
+}
+
+func TestTracing_UnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UsersService/GetUserById"}
+
+	t.Run("should continue the inbound trace in a span named after the method", func(t *testing.T) {
+		recorder := BeforeEach_TestTracing(t)
+
+		ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("traceparent", traceparent))
+
+		var childTraceId string
+		_, err := tracing.UnaryServerInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			childTraceId = trace.SpanContextFromContext(ctx).TraceID().String()
+			return "ok", nil
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, traceId, childTraceId, "should pass the trace to the handler")
+
+		server := recorder.Ended()[0]
+		assert.Equal(t, info.FullMethod, server.Name(), "should name the span after the method")
+		assert.Equal(t, traceId, server.SpanContext().TraceID().String(), "should continue the inbound trace")
+		assert.Equal(t, int64(0), findAttribute(server, "rpc.grpc.status_code").AsInt64())
+	})
+
+	t.Run("should mark the span as failed on server errors only", func(t *testing.T) {
+		recorder := BeforeEach_TestTracing(t)
+		interceptor := tracing.UnaryServerInterceptor()
+
+		for _, code := range []grpccodes.Code{grpccodes.Internal, grpccodes.NotFound} {
+			interceptor(context.TODO(), nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, status.Error(code, code.String())
+			})
+		}
+
+		spans := recorder.Ended()
+		assert.Equal(t, codes.Error, spans[0].Status().Code, "should mark internal errors as failed")
+		assert.Equal(t, codes.Unset, spans[1].Status().Code, "should not mark client errors as failed")
+	})
+}
+
+func TestTracing_Transport(t *testing.T) {
+	t.Run("should send the traceparent on outbound requests", func(t *testing.T) {
+		BeforeEach_TestTracing(t)
+
+		var received string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Get("traceparent")
+		}))
+		defer server.Close()
+
+		ctx, span := tracing.Start(context.TODO(), "parent")
+		defer span.End()
+
+		client := &http.Client{Transport: tracing.NewTransport(nil)}
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+		response, err := client.Do(req)
+
+		assert.Nil(t, err, "should not return error")
+		response.Body.Close()
+		assert.Contains(t, received, span.SpanContext().TraceID().String(), "should propagate the trace")
+	})
+}
+
+func TestTracing_End(t *testing.T) {
+	t.Run("should mark the span as failed when there is an error", func(t *testing.T) {
+		recorder := BeforeEach_TestTracing(t)
+
+		_, span := tracing.Start(context.TODO(), "foo")
+		tracing.End(span, errors.New(exception.CodeNotFound))
+
+		ended := recorder.Ended()[0]
+		assert.Equal(t, codes.Error, ended.Status().Code)
+		assert.Equal(t, exception.CodeNotFound, ended.Status().Description)
+	})
+}
+
+func TestTracing_FilterShape(t *testing.T) {
+	t.Run("should keep fields and operators but hide values", func(t *testing.T) {
+		shape := tracing.FilterShape(bson.M{
+			"email":      "goo@gle.com",
+			"deleted_at": nil,
+			"type":       bson.M{"$in": []string{"admin", "customer"}},
+		})
+
+		assert.Equal(t, `{"deleted_at":"?","email":"?","type":{"$in":["?"]}}`, shape)
+	})
+
+	t.Run("should describe an empty filter", func(t *testing.T) {
+		assert.Equal(t, "{}", tracing.FilterShape(nil))
+		assert.Equal(t, "{}", tracing.FilterShape(bson.M{}))
+	})
+}
+
+func TestTracing_Setup(t *testing.T) {
+	t.Run("should write spans to a file", func(t *testing.T) {
+		previous := otel.GetTracerProvider()
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		file := filepath.Join(t.TempDir(), "traces.json")
+		shutdown, err := tracing.Setup(tracing.Config{ServiceName: "users", Exporter: tracing.ExporterFile, File: file})
+		assert.Nil(t, err, "should not return error")
+
+		_, span := tracing.Start(context.TODO(), "foo")
+		span.End()
+		shutdown(context.TODO())
+
+		bytes, _ := os.ReadFile(file)
+		assert.Contains(t, string(bytes), `"Name":"foo"`, "should export the span")
+	})
+
+	t.Run("should return error when exporter is unknown", func(t *testing.T) {
+		_, err := tracing.Setup(tracing.Config{Exporter: "zipkin"})
+
+		assert.NotNil(t, err)
+	})
+}
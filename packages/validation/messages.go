@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+)
+
+// Messages of the validation tags, {0} is the field and {1} the tag param.
+// Services add messages of their own tags as "validation.<tag>".
+func init() {
+	i18n.MustAdd(i18n.English, map[string]string{
+		"validation.required": "{0} is required",
+		"validation.min.text": "{0} must have at least {1} characters",
+		"validation.min":      "{0} must be at least {1}",
+		"validation.max.text": "{0} must have at most {1} characters",
+		"validation.max":      "{0} must be at most {1}",
+		"validation.gt":       "{0} must be greater than {1}",
+		"validation.gte":      "{0} must be greater than or equal to {1}",
+		"validation.lt":       "{0} must be less than {1}",
+		"validation.lte":      "{0} must be less than or equal to {1}",
+		"validation.oneof":    "{0} must be one of: {1}",
+		"validation.number":   "{0} must be a number",
+		"validation.email":    "{0} must be a valid email",
+		"validation.mongodb":  "{0} must be a valid id",
+		"validation.url":      "{0} must be a valid URL",
+		"validation.invalid":  "{0} is invalid",
+	})
+
+	i18n.MustAdd(i18n.Portuguese, map[string]string{
+		"validation.required": "{0} é obrigatório",
+		"validation.min.text": "{0} deve ter pelo menos {1} caracteres",
+		"validation.min":      "{0} deve ser no mínimo {1}",
+		"validation.max.text": "{0} deve ter no máximo {1} caracteres",
+		"validation.max":      "{0} deve ser no máximo {1}",
+		"validation.gt":       "{0} deve ser maior que {1}",
+		"validation.gte":      "{0} deve ser maior ou igual a {1}",
+		"validation.lt":       "{0} deve ser menor que {1}",
+		"validation.lte":      "{0} deve ser menor ou igual a {1}",
+		"validation.oneof":    "{0} deve ser um dos valores: {1}",
+		"validation.number":   "{0} deve ser um número",
+		"validation.email":    "{0} deve ser um email válido",
+		"validation.mongodb":  "{0} deve ser um id válido",
+		"validation.url":      "{0} deve ser uma URL válida",
+		"validation.invalid":  "{0} é inválido",
+	})
+}
@@ -1,42 +1,151 @@
 package validation
 
 import (
-	"errors"
-	"fmt"
-	"log/slog"
+	"context"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/config"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
 	"github.com/italoservio/braz_ecommerce/packages/logger"
 )
 
-type ErrorResponse struct {
-	Error       bool
-	FailedField string
-	Tag         string
-	Value       interface{}
+var validate = newValidator()
+
+// newValidator reports fields by the name the client sent them, the json or
+// query tag, instead of the Go field name.
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+
+		return field.Name
+	})
+
+	return v
 }
 
+// ValidateRequest returns an exception.Error detailing every failed
+// field. Values of fields tagged with secret:"true" are masked.
 func ValidateRequest(c *fiber.Ctx, payload any) error {
-	correlationId := c.Locals(string(logger.CorrelationId))
-	validate := validator.New()
-	validationErrors := []ErrorResponse{}
+	return Validate(c.UserContext(), payload)
+}
+
+// Validate is ValidateRequest for payloads not received over HTTP, taking
+// the correlation id and the language from ctx.
+func Validate(ctx context.Context, payload any) error {
+	language := i18n.FromContext(ctx)
+
 	errs := validate.Struct(payload)
-	if errs != nil {
-		for _, err := range errs.(validator.ValidationErrors) {
-			slog.Error(fmt.Sprintf("%s %s", correlationId, err.Error()))
-
-			validationErrors = append(validationErrors, ErrorResponse{
-				Error:       true,
-				FailedField: err.Field(),
-				Tag:         err.Tag(),
-				Value:       err.Value(),
-			})
+	if errs == nil {
+		return nil
+	}
+
+	validationErrors, ok := errs.(validator.ValidationErrors)
+	if !ok {
+		logger.Default().WithCtx(ctx).Error("validation failed", "cause", errs.Error())
+		return exception.NewValidationError(nil)
+	}
+
+	details := make([]exception.FieldError, 0, len(validationErrors))
+	for _, err := range validationErrors {
+		logger.Default().WithCtx(ctx).Error("validation failed", "cause", err.Error())
+
+		field := fieldName(err)
+		value := err.Value()
+		if isSecret(reflect.TypeOf(payload), err.StructNamespace()) {
+			value = config.Redaction
+		}
+
+		details = append(details, exception.FieldError{
+			Field:   field,
+			Tag:     err.Tag(),
+			Message: message(language, field, err),
+			Value:   value,
+		})
+	}
+
+	return exception.NewValidationError(details)
+}
+
+// fieldName is the namespace without the payload struct name, so nested and
+// list fields keep their path, e.g. "email[1]".
+func fieldName(err validator.FieldError) string {
+	_, name, found := strings.Cut(err.Namespace(), ".")
+	if !found {
+		return err.Field()
+	}
+
+	return name
+}
+
+// message translates the tag to the language of the request. Tags without a
+// "validation.<tag>" message, such as custom tags services did not add a
+// message for, are reported as invalid.
+func message(language string, field string, err validator.FieldError) string {
+	param := err.Param()
+	key := "validation." + err.Tag()
+
+	switch err.Tag() {
+	case "required_if":
+		key = "validation.required"
+	case "min", "max":
+		if err.Kind() == reflect.String {
+			key += ".text"
 		}
+	case "oneof":
+		param = strings.ReplaceAll(param, " ", ", ")
+	case "numeric":
+		key = "validation.number"
+	case "uri":
+		key = "validation.url"
+	}
+
+	text := i18n.T(language, key, field, param)
+	if text == key {
+		return i18n.T(language, "validation.invalid", field)
 	}
-	if len(validationErrors) > 0 {
-		return errors.New(exception.CodeValidationFailed)
+
+	return text
+}
+
+// isSecret follows the Go field path of the error, e.g.
+// "Payload.Address.Password", looking for a secret:"true" tag.
+func isSecret(payloadType reflect.Type, structNamespace string) bool {
+	current := payloadType
+	path := strings.Split(structNamespace, ".")
+
+	for _, name := range path[1:] {
+		name, _, _ = strings.Cut(name, "[")
+
+		for current.Kind() == reflect.Pointer || current.Kind() == reflect.Slice || current.Kind() == reflect.Map {
+			current = current.Elem()
+		}
+
+		if current.Kind() != reflect.Struct {
+			return false
+		}
+
+		field, ok := current.FieldByName(name)
+		if !ok {
+			return false
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			return true
+		}
+
+		current = field.Type
 	}
-	return nil
+
+	return false
 }
@@ -0,0 +1,128 @@
+package validation_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/correlation"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type mockBody struct {
+	FirstName string `json:"first_name" validate:"required,min=5"`
+	Age       int    `json:"age" validate:"gt=17"`
+	Password  string `json:"password" validate:"min=8" secret:"true"`
+	Internal  string `json:"-" validate:"omitempty,max=1"`
+}
+
+type mockQuery struct {
+	PerPage int      `query:"per_page" validate:"lte=100"`
+	Emails  []string `query:"email" validate:"omitempty,dive,email"`
+}
+
+func BeforeEach_TestValidateRequest() *fiber.Ctx {
+	return fiber.New().AcquireCtx(&fasthttp.RequestCtx{})
+}
+
+func TestValidateRequest(t *testing.T) {
+	t.Run("should return nil when the payload is valid", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+
+		err := validation.ValidateRequest(c, &mockBody{FirstName: "Italo", Age: 18, Password: "12345678"})
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	t.Run("should detail every failed body field by its json name", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+
+		err := validation.ValidateRequest(c, &mockBody{FirstName: "Ita", Age: 10, Password: "123"})
+
+		var validationError *exception.Error
+		assert.True(t, errors.As(err, &validationError), "should return a validation error")
+		assert.Equal(t, exception.CodeValidationFailed, err.Error(), "should keep the error code")
+		assert.Equal(t, []exception.FieldError{
+			{Field: "first_name", Tag: "min", Message: "first_name must have at least 5 characters", Value: "Ita"},
+			{Field: "age", Tag: "gt", Message: "age must be greater than 17", Value: 10},
+			{Field: "password", Tag: "min", Message: "password must have at least 8 characters", Value: "******"},
+		}, validationError.Details, "should describe the failed fields masking secrets")
+	})
+
+	t.Run("should detail failed query fields by their query name", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+
+		err := validation.ValidateRequest(c, mockQuery{PerPage: 101, Emails: []string{"goo@gle.com", "google"}})
+
+		var validationError *exception.Error
+		errors.As(err, &validationError)
+		assert.Equal(t, []exception.FieldError{
+			{Field: "per_page", Tag: "lte", Message: "per_page must be less than or equal to 100", Value: 101},
+			{Field: "email[1]", Tag: "email", Message: "email[1] must be a valid email", Value: "google"},
+		}, validationError.Details, "should describe the failed fields")
+	})
+
+	t.Run("should report a required field without value", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+
+		err := validation.ValidateRequest(c, &mockBody{Age: 18, Password: "12345678"})
+
+		var validationError *exception.Error
+		errors.As(err, &validationError)
+		assert.Equal(t, "first_name is required", validationError.Details[0].Message, "should describe the rule")
+	})
+
+	t.Run("should describe the failed fields in the language of the request", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+		c.SetUserContext(i18n.WithLanguage(context.Background(), i18n.Portuguese))
+
+		err := validation.ValidateRequest(c, &mockBody{Age: 10, Password: "123"})
+
+		var validationError *exception.Error
+		errors.As(err, &validationError)
+		assert.Equal(t, "first_name é obrigatório", validationError.Details[0].Message, "should translate the rule")
+		assert.Equal(t, "age deve ser maior que 17", validationError.Details[1].Message, "should translate the param rule")
+	})
+
+	t.Run("should report tags without message as invalid", func(t *testing.T) {
+		c := BeforeEach_TestValidateRequest()
+
+		err := validation.ValidateRequest(c, &struct {
+			Color string `json:"color" validate:"hexcolor"`
+		}{Color: "blue"})
+
+		var validationError *exception.Error
+		errors.As(err, &validationError)
+		assert.Equal(t, "color is invalid", validationError.Details[0].Message, "should describe the field as invalid")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("should log the failed fields with the correlation id", func(t *testing.T) {
+		previous := logger.Default()
+		defer logger.SetDefault(previous)
+
+		output := &bytes.Buffer{}
+		logger.SetDefault(logger.NewLoggerWithConfig(logger.Config{Format: logger.FormatJSON, Output: output}))
+
+		ctx := correlation.WithId(context.TODO(), "b7f1c2")
+		validation.Validate(ctx, &mockBody{FirstName: "Italo", Age: 18, Password: "123"})
+
+		var entry map[string]any
+		if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "validation failed", entry["msg"])
+		assert.Equal(t, "b7f1c2", entry["correlation_id"], "should carry the correlation id")
+		assert.NotContains(t, output.String(), `"123"`, "should not log the values")
+	})
+}
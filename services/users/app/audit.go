@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+)
+
+type AuditEvent string
+
+const (
+	AuditLoginSucceeded   AuditEvent = "login.succeeded"
+	AuditLoginFailed      AuditEvent = "login.failed"
+	AuditLoginBlocked     AuditEvent = "login.blocked"
+	AuditAccountLocked    AuditEvent = "account.locked"
+	AuditAccountUnlocked  AuditEvent = "account.unlocked"
+	AuditUnlockMailFailed AuditEvent = "account.unlock_mail_failed"
+)
+
+// AuditInterface records security relevant events. Events never carry
+// personal data in plaintext, accounts are told by their id or email index.
+type AuditInterface interface {
+	Record(ctx context.Context, event AuditEvent, args ...any)
+}
+
+// AuditImpl records the events as "audit" log records, so they can be routed
+// apart from the application logs.
+type AuditImpl struct {
+	logger logger.LoggerInterface
+}
+
+func NewAuditImpl(lg logger.LoggerInterface) *AuditImpl {
+	return &AuditImpl{logger: lg}
+}
+
+func (au *AuditImpl) Record(ctx context.Context, event AuditEvent, args ...any) {
+	au.logger.WithCtx(ctx).Info("audit", append([]any{"event", string(event)}, args...)...)
+}
@@ -2,13 +2,12 @@ package app
 
 import (
 	"context"
-	"errors"
-	"os"
 	"time"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/encryption"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
 )
@@ -19,19 +18,28 @@ type CreateUserInterface interface {
 
 type CreateUserImpl struct {
 	encryption     encryption.EncryptionInterface
+	fields         encryption.FieldEncryptionInterface
 	crudRepository database.CrudRepositoryInterface
 	userRepository storage.UserRepositoryInterface
+	transaction    database.TransactionInterface
+	metrics        *UsersMetrics
 }
 
 func NewCreateUserImpl(
 	en encryption.EncryptionInterface,
+	fe encryption.FieldEncryptionInterface,
 	cr database.CrudRepositoryInterface,
 	ur storage.UserRepositoryInterface,
+	tx database.TransactionInterface,
+	um *UsersMetrics,
 ) *CreateUserImpl {
 	return &CreateUserImpl{
 		encryption:     en,
+		fields:         fe,
 		crudRepository: cr,
 		userRepository: ur,
+		transaction:    tx,
+		metrics:        um,
 	}
 }
 
@@ -44,62 +52,90 @@ type CreateUserInput struct {
 	LastName  string `json:"last_name" validate:"required,min=1,max=100"`
 	Email     string `json:"email" validate:"required,min=1,max=100"`
 	Type      string `json:"type" validate:"required,min=5,max=100"`
-	Password  string `json:"password" validate:"required,min=5,max=100"`
+	Password  string `json:"password" validate:"required,min=5,max=100" secret:"true"`
 }
 
 type CreateUserDatabase struct {
 	domain.User                `bson:",inline"`
+	domain.UserEmailIndex      `bson:",inline"`
 	domain.UserPassword        `bson:",inline"`
 	database.DatabaseTimestamp `bson:",inline"`
 }
 
-func (gu *CreateUserImpl) Do(ctx context.Context, input *CreateUserInput) (*CreateUserOutput, error) {
-	secret := os.Getenv("ENC_SECRET")
-	encryptionData, err := gu.encryption.Encrypt(ctx, secret, input.Password)
+// Do answers already registered emails with a permission error, also when
+// another request registers it meanwhile and the unique email index rejects
+// the insert.
+func (gu *CreateUserImpl) Do(ctx context.Context, input *CreateUserInput) (_ *CreateUserOutput, err error) {
+	ctx, span := tracing.Start(ctx, "app.CreateUser")
+	defer func() { tracing.End(span, err) }()
+
+	encryptionData, err := gu.encryption.Encrypt(ctx, input.Password)
 
 	if err != nil {
-		return nil, errors.New(exception.CodeInternal)
+		return nil, exception.Wrap(exception.CodeInternal, err)
 	}
 
-	var existentUser domain.UserDatabaseNoPassword
+	user := domain.User{
+		Type:      input.Type,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Email:     input.Email,
+		Addresses: []domain.UserAddress{},
+	}
 
-	err = gu.userRepository.GetByEmail(
-		ctx,
-		database.UsersCollection,
-		input.Email,
-		&existentUser,
-	)
+	emailIndex := gu.fields.BlindIndex(input.Email)
 
+	err = encryptFields(ctx, gu.fields, user.PersonalFields()...)
 	if err != nil {
-		return nil, err
+		return nil, exception.Wrap(exception.CodeInternal, err)
 	}
 
-	if existentUser != (domain.UserDatabaseNoPassword{}) {
-		return nil, errors.New(exception.CodePermission)
-	}
-
-	id, err := gu.crudRepository.CreateOne(ctx, database.UsersCollection, &CreateUserDatabase{
-		User: domain.User{
-			Type:      input.Type,
-			FirstName: input.FirstName,
-			LastName:  input.LastName,
-			Email:     input.Email,
-			Addresses: []domain.UserAddress{},
-		},
-		UserPassword: domain.UserPassword{
-			Password:  encryptionData.EncryptedText,
-			CipherKey: encryptionData.Salt,
-		},
-		DatabaseTimestamp: database.DatabaseTimestamp{
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			DeletedAt: nil,
-		},
+	var id string
+
+	err = gu.transaction.WithTransaction(ctx, func(txCtx context.Context) error {
+		var existentUser domain.UserDatabaseNoPassword
+
+		err := gu.userRepository.GetByEmail(
+			txCtx,
+			database.UsersCollection,
+			emailIndex,
+			&existentUser,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if existentUser != (domain.UserDatabaseNoPassword{}) {
+			return exception.New(exception.CodePermission)
+		}
+
+		id, err = gu.crudRepository.CreateOne(txCtx, database.UsersCollection, &CreateUserDatabase{
+			User:           user,
+			UserEmailIndex: domain.UserEmailIndex{EmailIndex: emailIndex},
+			UserPassword: domain.UserPassword{
+				Password:  encryptionData.EncryptedText,
+				CipherKey: encryptionData.Salt,
+			},
+			DatabaseTimestamp: database.DatabaseTimestamp{
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				DeletedAt: nil,
+			},
+		})
+
+		if database.IsDuplicateKeyError(err) {
+			return exception.New(exception.CodePermission)
+		}
+
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	gu.metrics.UsersCreated.Inc()
+
 	return &CreateUserOutput{DatabaseIdentifier: &database.DatabaseIdentifier{Id: id}}, nil
 }
@@ -4,16 +4,19 @@ import (
 	"context"
 	"errors"
 	"log"
-	"os"
 	"testing"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
 	"github.com/italoservio/braz_ecommerce/services/users/app"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/mock/gomock"
 )
 
@@ -23,6 +26,8 @@ type TestingDependencies_TestCreateUser struct {
 	encryption         *mocks.MockEncryptionInterface
 	mockCrudRepository *mocks.MockCrudRepositoryInterface
 	mockUserRepository *mocks.MockUserRepositoryInterface
+	mockTransaction    *mocks.MockTransactionInterface
+	usersMetrics       *app.UsersMetrics
 	createUserImpl     *app.CreateUserImpl
 }
 
@@ -32,8 +37,27 @@ func BeforeEach_TestCreateUser(t *testing.T) *TestingDependencies_TestCreateUser
 	encryption := mocks.NewMockEncryptionInterface(ctrl)
 	mockCrudRepository := mocks.NewMockCrudRepositoryInterface(ctrl)
 	mockUserRepository := mocks.NewMockUserRepositoryInterface(ctrl)
-
-	createUserImpl := app.NewCreateUserImpl(encryption, mockCrudRepository, mockUserRepository)
+	mockTransaction := mocks.NewMockTransactionInterface(ctrl)
+	fieldEncryption := BeforeEach_FieldEncryption(ctrl)
+
+	mockTransaction.
+		EXPECT().
+		WithTransaction(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, fn func(txCtx context.Context) error) error {
+			return fn(ctx)
+		})
+
+	usersMetrics := app.NewUsersMetrics(metrics.New())
+
+	createUserImpl := app.NewCreateUserImpl(
+		encryption,
+		fieldEncryption,
+		mockCrudRepository,
+		mockUserRepository,
+		mockTransaction,
+		usersMetrics,
+	)
 
 	return &TestingDependencies_TestCreateUser{
 		ctx:                ctx,
@@ -41,6 +65,8 @@ func BeforeEach_TestCreateUser(t *testing.T) *TestingDependencies_TestCreateUser
 		encryption:         encryption,
 		mockCrudRepository: mockCrudRepository,
 		mockUserRepository: mockUserRepository,
+		mockTransaction:    mockTransaction,
+		usersMetrics:       usersMetrics,
 		createUserImpl:     createUserImpl,
 	}
 }
@@ -56,18 +82,18 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{}
@@ -81,8 +107,6 @@ func TestCreateUser_Do(t *testing.T) {
 			Times(1).
 			Return("", mockExpectedError)
 
-		os.Setenv("ENC_SECRET", "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6")
-
 		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{Password: mockPassword, Email: mockEmail})
 		if err == nil {
 			t.Fail()
@@ -100,7 +124,7 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
 
@@ -110,8 +134,6 @@ func TestCreateUser_Do(t *testing.T) {
 			Times(1).
 			Return(mockExpectedError)
 
-		os.Setenv("ENC_SECRET", "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6")
-
 		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{Password: mockPassword})
 		if err == nil {
 			t.Fail()
@@ -129,18 +151,18 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{
@@ -152,8 +174,6 @@ func TestCreateUser_Do(t *testing.T) {
 				return nil
 			})
 
-		os.Setenv("ENC_SECRET", "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6")
-
 		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{Password: mockPassword, Email: mockEmail})
 		if err == nil {
 			t.Fail()
@@ -163,6 +183,81 @@ func TestCreateUser_Do(t *testing.T) {
 		assert.Equal(t, "EPERMISSION", err.Error(), "should return the expected error code")
 	})
 
+	t.Run("should return a permission error when the email is registered meanwhile", func(t *testing.T) {
+		deps := BeforeEach_TestCreateUser(t)
+		defer deps.ctrl.Finish()
+
+		deps.encryption.
+			EXPECT().
+			Encrypt(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
+
+		deps.mockUserRepository.
+			EXPECT().
+			GetByEmail(gomock.Any(), database.UsersCollection, gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		deps.mockCrudRepository.
+			EXPECT().
+			CreateOne(gomock.Any(), database.UsersCollection, gomock.Any()).
+			Times(1).
+			Return("", database.ParseToDatabaseError(mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}))
+
+		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{Password: "test", Email: "goo@gle.com"})
+
+		assert.Equal(t, exception.CodePermission, err.Error(), "should return permission error")
+	})
+
+	t.Run("should return error when failed to start the transaction", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCause := errors.New("server selection timeout")
+		mockPassword := "test"
+
+		mockEncryption := mocks.NewMockEncryptionInterface(ctrl)
+		mockCrudRepository := mocks.NewMockCrudRepositoryInterface(ctrl)
+		mockUserRepository := mocks.NewMockUserRepositoryInterface(ctrl)
+		mockTransaction := mocks.NewMockTransactionInterface(ctrl)
+
+		mockEncryption.
+			EXPECT().
+			Encrypt(gomock.Any(), mockPassword).
+			Times(1).
+			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
+
+		mockUserRepository.EXPECT().GetByEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockCrudRepository.EXPECT().CreateOne(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		mockTransaction.
+			EXPECT().
+			WithTransaction(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, fn func(txCtx context.Context) error) error {
+				return exception.Wrap(exception.CodeDatabaseFailed, mockCause)
+			})
+
+		createUserImpl := app.NewCreateUserImpl(
+			mockEncryption,
+			BeforeEach_FieldEncryption(ctrl),
+			mockCrudRepository,
+			mockUserRepository,
+			mockTransaction,
+			app.NewUsersMetrics(metrics.New()),
+		)
+
+		_, err := createUserImpl.Do(ctx, &app.CreateUserInput{Password: mockPassword})
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return the transaction error")
+		assert.ErrorIs(t, err, mockCause, "should keep the cause of the session failure")
+	})
+
 	t.Run("should return error when failed to encrypt password", func(t *testing.T) {
 		deps := BeforeEach_TestCreateUser(t)
 		defer deps.ctrl.Finish()
@@ -172,7 +267,7 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(nil, mockExpectedError)
 
@@ -193,12 +288,12 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{}
@@ -208,7 +303,7 @@ func TestCreateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
 
@@ -218,13 +313,58 @@ func TestCreateUser_Do(t *testing.T) {
 			Times(1).
 			Return("", nil)
 
-		os.Setenv("ENC_SECRET", "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6")
-
 		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{Password: mockPassword, Email: mockEmail})
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		assert.Nil(t, err, "should not return an error")
+		assert.Equal(t, float64(1), testutil.ToFloat64(deps.usersMetrics.UsersCreated), "should count the created user")
+	})
+
+	t.Run("should store the personal fields encrypted along with the email index", func(t *testing.T) {
+		deps := BeforeEach_TestCreateUser(t)
+		defer deps.ctrl.Finish()
+
+		mockPassword := "test"
+		mockEmail := "Goo@gle.com"
+
+		deps.encryption.
+			EXPECT().
+			Encrypt(gomock.Any(), mockPassword).
+			Times(1).
+			Return(&encryption.EncryptedText{EncryptedText: "v1:abc", Salt: "def"}, nil)
+
+		deps.mockUserRepository.
+			EXPECT().
+			GetByEmail(gomock.Any(), database.UsersCollection, "index:goo@gle.com", gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		var stored *app.CreateUserDatabase
+
+		deps.mockCrudRepository.
+			EXPECT().
+			CreateOne(gomock.Any(), database.UsersCollection, gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, collection string, structure any) (string, error) {
+				stored = structure.(*app.CreateUserDatabase)
+				return "", nil
+			})
+
+		_, err := deps.createUserImpl.Do(deps.ctx, &app.CreateUserInput{
+			FirstName: "Italo",
+			LastName:  "Servio",
+			Email:     mockEmail,
+			Type:      "customer",
+			Password:  mockPassword,
+		})
+
+		assert.Nil(t, err, "should not return an error")
+		assert.Equal(t, "encrypted:Italo", stored.FirstName, "should encrypt the first name")
+		assert.Equal(t, "encrypted:Servio", stored.LastName, "should encrypt the last name")
+		assert.Equal(t, "encrypted:"+mockEmail, stored.Email, "should encrypt the email")
+		assert.Equal(t, "index:goo@gle.com", stored.EmailIndex, "should store the email index")
+		assert.Equal(t, "customer", stored.Type, "should not encrypt the type")
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
 )
 
@@ -14,20 +15,27 @@ type DeleteUserByIdInterface interface {
 type DeleteUserByIdImpl struct {
 	crudRepository database.CrudRepositoryInterface
 	userRepository storage.UserRepositoryInterface
+	metrics        *UsersMetrics
 }
 
 func NewDeleteUserByIdImpl(
 	cr database.CrudRepositoryInterface,
 	ur storage.UserRepositoryInterface,
+	um *UsersMetrics,
 ) *DeleteUserByIdImpl {
-	return &DeleteUserByIdImpl{crudRepository: cr, userRepository: ur}
+	return &DeleteUserByIdImpl{crudRepository: cr, userRepository: ur, metrics: um}
 }
 
-func (gu *DeleteUserByIdImpl) Do(ctx context.Context, id string) error {
-	err := gu.crudRepository.DeleteById(ctx, database.UsersCollection, id)
+func (gu *DeleteUserByIdImpl) Do(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "app.DeleteUserById")
+	defer func() { tracing.End(span, err) }()
+
+	err = gu.crudRepository.DeleteById(ctx, database.UsersCollection, id)
 	if err != nil {
 		return err
 	}
 
+	gu.metrics.UsersDeleted.Inc()
+
 	return nil
 }
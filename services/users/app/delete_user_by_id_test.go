@@ -7,10 +7,16 @@ import (
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
 	"github.com/italoservio/braz_ecommerce/services/users/app"
 	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/mock/gomock"
 )
 
@@ -19,6 +25,7 @@ type TestingDependencies_TestDeleteUserById struct {
 	ctrl               *gomock.Controller
 	mockCrudRepository *mocks.MockCrudRepositoryInterface
 	mockUserRepository *mocks.MockUserRepositoryInterface
+	usersMetrics       *app.UsersMetrics
 	deleteUserByIdImpl *app.DeleteUserByIdImpl
 }
 
@@ -28,13 +35,16 @@ func BeforeEach_TestDeleteUserById(t *testing.T) *TestingDependencies_TestDelete
 	mockCrudRepository := mocks.NewMockCrudRepositoryInterface(ctrl)
 	mockUserRepository := mocks.NewMockUserRepositoryInterface(ctrl)
 
-	deleteUserByIdImpl := app.NewDeleteUserByIdImpl(mockCrudRepository, mockUserRepository)
+	usersMetrics := app.NewUsersMetrics(metrics.New())
+
+	deleteUserByIdImpl := app.NewDeleteUserByIdImpl(mockCrudRepository, mockUserRepository, usersMetrics)
 
 	return &TestingDependencies_TestDeleteUserById{
 		ctx:                ctx,
 		ctrl:               ctrl,
 		mockCrudRepository: mockCrudRepository,
 		mockUserRepository: mockUserRepository,
+		usersMetrics:       usersMetrics,
 		deleteUserByIdImpl: deleteUserByIdImpl,
 	}
 }
@@ -53,6 +63,7 @@ func TestDeleteUserById_Do(t *testing.T) {
 		err := deps.deleteUserByIdImpl.Do(deps.ctx, id)
 
 		assert.Nil(t, err, "should return nil")
+		assert.Equal(t, float64(1), testutil.ToFloat64(deps.usersMetrics.UsersDeleted), "should count the deleted user")
 	})
 
 	t.Run("should return the error when failed to delete", func(t *testing.T) {
@@ -69,4 +80,25 @@ func TestDeleteUserById_Do(t *testing.T) {
 
 		assert.NotNil(t, err, "should return the error")
 	})
+	t.Run("should record the error on the span when failed to delete", func(t *testing.T) {
+		deps := BeforeEach_TestDeleteUserById(t)
+
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		defer otel.SetTracerProvider(previous)
+
+		id := primitive.NewObjectID().Hex()
+
+		deps.mockCrudRepository.EXPECT().
+			DeleteById(gomock.Any(), database.UsersCollection, id).
+			Times(1).
+			Return(errors.New(exception.CodeDatabaseFailed))
+
+		deps.deleteUserByIdImpl.Do(deps.ctx, id)
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 1, "should record the use case span")
+		assert.Equal(t, codes.Error, spans[0].Status().Code, "should record the error")
+	})
 }
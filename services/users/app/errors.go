@@ -0,0 +1,47 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+)
+
+const (
+	CodeInvalidCredentials = "EINVALIDCREDENTIALS"
+	CodeAccountLocked      = "EACCOUNTLOCKED"
+	CodeLoginThrottled     = "ELOGINTHROTTLED"
+)
+
+func init() {
+	exception.MustRegister(
+		exception.Definition{
+			Code:     CodeInvalidCredentials,
+			Status:   http.StatusUnauthorized,
+			Message:  "Invalid email or password",
+			Messages: map[string]string{i18n.Portuguese: "Email ou senha inválidos"},
+		},
+		exception.Definition{
+			Code:     CodeAccountLocked,
+			Status:   http.StatusLocked,
+			Message:  "Too many failed logins, the account is temporarily locked",
+			Messages: map[string]string{i18n.Portuguese: "Muitas tentativas de login, a conta está temporariamente bloqueada"},
+		},
+		exception.Definition{
+			Code:     CodeLoginThrottled,
+			Status:   http.StatusTooManyRequests,
+			Message:  "Too many failed logins, try again later",
+			Messages: map[string]string{i18n.Portuguese: "Muitas tentativas de login, tente novamente mais tarde"},
+		},
+	)
+
+	i18n.MustAdd(i18n.English, map[string]string{
+		"users.unlock_mail.subject": "Your account was locked",
+		"users.unlock_mail.body":    "We locked your account after too many failed logins. If it was you, unlock it at {0}, otherwise consider changing your password.",
+	})
+
+	i18n.MustAdd(i18n.Portuguese, map[string]string{
+		"users.unlock_mail.subject": "Sua conta foi bloqueada",
+		"users.unlock_mail.body":    "Bloqueamos sua conta após muitas tentativas de login sem sucesso. Se foi você, desbloqueie em {0}, caso contrário considere trocar sua senha.",
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
 )
@@ -13,15 +15,17 @@ type GetUserByIdInterface interface {
 }
 
 type GetUserByIdImpl struct {
+	fields         encryption.FieldEncryptionInterface
 	crudRepository database.CrudRepositoryInterface
 	userRepository storage.UserRepositoryInterface
 }
 
 func NewGetUserByIdImpl(
+	fe encryption.FieldEncryptionInterface,
 	cr database.CrudRepositoryInterface,
 	ur storage.UserRepositoryInterface,
 ) *GetUserByIdImpl {
-	return &GetUserByIdImpl{crudRepository: cr, userRepository: ur}
+	return &GetUserByIdImpl{fields: fe, crudRepository: cr, userRepository: ur}
 }
 
 type GetUserByIdInput struct {
@@ -33,11 +37,19 @@ type GetUserByIdOutput struct {
 	*domain.UserDatabaseNoPassword `bson:",inline"`
 }
 
-func (gu *GetUserByIdImpl) Do(ctx context.Context, input *GetUserByIdInput) (*GetUserByIdOutput, error) {
+func (gu *GetUserByIdImpl) Do(ctx context.Context, input *GetUserByIdInput) (_ *GetUserByIdOutput, err error) {
+	ctx, span := tracing.Start(ctx, "app.GetUserById")
+	defer func() { tracing.End(span, err) }()
+
 	var output GetUserByIdOutput
 
-	err := gu.crudRepository.GetById(ctx, database.UsersCollection, input.Id, input.Deleted, &output)
+	err = gu.crudRepository.GetById(ctx, database.UsersCollection, input.Id, input.Deleted, &output)
+
+	if err != nil {
+		return nil, err
+	}
 
+	err = decryptUser(ctx, gu.fields, output.UserDatabaseNoPassword)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/mocks"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -28,7 +29,7 @@ func BeforeEach_TestGetUserById(t *testing.T) *TestingDependencies_TestGetUserBy
 	mockCrudRepository := mocks.NewMockCrudRepositoryInterface(ctrl)
 	mockUserRepository := mocks.NewMockUserRepositoryInterface(ctrl)
 
-	getUserByIdImpl := app.NewGetUserByIdImpl(mockCrudRepository, mockUserRepository)
+	getUserByIdImpl := app.NewGetUserByIdImpl(BeforeEach_FieldEncryption(ctrl), mockCrudRepository, mockUserRepository)
 
 	return &TestingDependencies_TestGetUserById{
 		ctx:                ctx,
@@ -81,4 +82,44 @@ func TestGetUserById_Do(t *testing.T) {
 
 		assert.Nil(t, err, "should not return an error")
 	})
+
+	t.Run("should decrypt the personal fields of the user", func(t *testing.T) {
+		deps := BeforeEach_TestGetUserById(t)
+		id := primitive.NewObjectID().Hex()
+		complement := "encrypted:apto 2"
+
+		deps.mockCrudRepository.
+			EXPECT().
+			GetById(gomock.Any(), database.UsersCollection, id, false, gomock.Any()).
+			Times(1).
+			DoAndReturn(func(
+				ctx context.Context,
+				collection string,
+				id string,
+				deleted bool,
+				structure any,
+			) error {
+				structure.(*app.GetUserByIdOutput).UserDatabaseNoPassword = &domain.UserDatabaseNoPassword{
+					User: &domain.User{
+						Type:      "customer",
+						FirstName: "encrypted:Italo",
+						Email:     "encrypted:goo@gle.com",
+						Addresses: []domain.UserAddress{
+							{Street: "encrypted:Rua A", State: "SP", Complement: &complement},
+						},
+					},
+				}
+
+				return nil
+			})
+
+		output, err := deps.getUserByIdImpl.Do(deps.ctx, &app.GetUserByIdInput{Id: id, Deleted: false})
+
+		assert.Nil(t, err, "should not return an error")
+		assert.Equal(t, "Italo", output.FirstName, "should decrypt the first name")
+		assert.Equal(t, "goo@gle.com", output.Email, "should decrypt the email")
+		assert.Equal(t, "Rua A", output.Addresses[0].Street, "should decrypt the address")
+		assert.Equal(t, "apto 2", *output.Addresses[0].Complement, "should decrypt the complement")
+		assert.Equal(t, "SP", output.Addresses[0].State, "should keep the state")
+	})
 }
@@ -2,10 +2,11 @@ package app
 
 import (
 	"context"
-	"errors"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 )
 
@@ -14,23 +15,28 @@ type GetUserPaginatedInterface interface {
 }
 
 type GetUserPaginatedImpl struct {
+	fields         encryption.FieldEncryptionInterface
 	crudRepository database.CrudRepositoryInterface
 }
 
 func NewGetUserPaginatedImpl(
+	fe encryption.FieldEncryptionInterface,
 	cr database.CrudRepositoryInterface,
 ) *GetUserPaginatedImpl {
 	return &GetUserPaginatedImpl{
+		fields:         fe,
 		crudRepository: cr,
 	}
 }
 
+// GetUserPaginatedInput is validated by both the HTTP and the gRPC servers,
+// its query tags name the HTTP params.
 type GetUserPaginatedInput struct {
-	Page    int
-	PerPage int
-	Emails  []string
-	Ids     []string
-	Deleted bool
+	Page    int      `query:"page" validate:"required,number,gt=0"`
+	PerPage int      `query:"per_page" validate:"required,number,gt=0,lte=100"`
+	Emails  []string `query:"email" validate:"omitempty,dive,email"`
+	Ids     []string `query:"id" validate:"omitempty,dive,mongodb"`
+	Deleted bool     `query:"deleted"`
 }
 
 type GetUserPaginatedOutput struct {
@@ -40,10 +46,13 @@ type GetUserPaginatedOutput struct {
 func (gup *GetUserPaginatedImpl) Do(
 	ctx context.Context,
 	input *GetUserPaginatedInput,
-) (*database.PaginatedSlice[GetUserPaginatedOutput], error) {
+) (_ *database.PaginatedSlice[GetUserPaginatedOutput], err error) {
+	ctx, span := tracing.Start(ctx, "app.GetUserPaginated")
+	defer func() { tracing.End(span, err) }()
+
 	sorting := mountSorting()
 	projection := mountProjection()
-	filters, err := mountFilters(input)
+	filters, err := mountFilters(input, gup.fields)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +73,13 @@ func (gup *GetUserPaginatedImpl) Do(
 		return nil, err
 	}
 
+	for _, user := range users {
+		err = decryptUser(ctx, gup.fields, user.UserDatabaseNoPassword)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	output := database.NewPaginatedSlice[GetUserPaginatedOutput](
 		input.Page,
 		input.PerPage,
@@ -73,16 +89,24 @@ func (gup *GetUserPaginatedImpl) Do(
 	return output, nil
 }
 
-func mountFilters(input *GetUserPaginatedInput) (map[string]any, error) {
+func mountFilters(
+	input *GetUserPaginatedInput,
+	fe encryption.FieldEncryptionInterface,
+) (map[string]any, error) {
 	filters := make(map[string]any)
 	if len(input.Emails) > 0 {
-		filters["email"] = input.Emails
+		emailIndexes := make([]string, 0, len(input.Emails))
+		for _, email := range input.Emails {
+			emailIndexes = append(emailIndexes, fe.BlindIndex(email))
+		}
+
+		filters["email_index"] = emailIndexes
 	}
 
 	if len(input.Ids) > 0 {
 		ids, err := database.ParseToDatabaseId(input.Ids...)
 		if err != nil {
-			return nil, errors.New(exception.CodeValidationFailed)
+			return nil, exception.Wrap(exception.CodeValidationFailed, err)
 		}
 
 		filters["_id"] = ids
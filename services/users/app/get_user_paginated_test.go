@@ -27,7 +27,7 @@ func BeforeEach_TestGetUserPaginated(t *testing.T) *TestingDependencies_TestGetU
 	ctrl := gomock.NewController(t)
 	mockCrudRepository := mocks.NewMockCrudRepositoryInterface(ctrl)
 
-	getUserPaginatedImpl := app.NewGetUserPaginatedImpl(mockCrudRepository)
+	getUserPaginatedImpl := app.NewGetUserPaginatedImpl(BeforeEach_FieldEncryption(ctrl), mockCrudRepository)
 
 	return &TestingDependencies_TestGetUserPaginated{
 		ctx:                  ctx,
@@ -82,8 +82,8 @@ func TestGetUserPaginated_Do(t *testing.T) {
 		}
 
 		filters := map[string]any{
-			"_id":   []primitive.ObjectID{objId1, objId2},
-			"email": []string{"foo@bar.net"},
+			"_id":         []primitive.ObjectID{objId1, objId2},
+			"email_index": []string{"index:foo@bar.net"},
 		}
 		projection := map[string]int{
 			"password":   0,
@@ -142,9 +142,9 @@ func TestGetUserPaginated_Do(t *testing.T) {
 		}
 
 		filters := map[string]any{
-			"_id":        []primitive.ObjectID{objId1, objId2},
-			"email":      []string{"foo@bar.net"},
-			"deleted_at": nil,
+			"_id":         []primitive.ObjectID{objId1, objId2},
+			"email_index": []string{"index:foo@bar.net"},
+			"deleted_at":  nil,
 		}
 		projection := map[string]int{
 			"password":   0,
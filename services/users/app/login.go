@@ -0,0 +1,339 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/i18n"
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+)
+
+// LoginPolicy sets how failed logins are throttled. Past DelayAfter failures
+// of an account or an IP, each attempt must wait twice as long as the
+// previous one, from BaseDelay up to MaxDelay. Accounts are locked for
+// LockDuration at MaxAccountFailures and IPs are throttled at MaxIPFailures.
+// Failures are forgotten after Window without new ones.
+type LoginPolicy struct {
+	DelayAfter         int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	MaxAccountFailures int
+	MaxIPFailures      int
+	LockDuration       time.Duration
+	Window             time.Duration
+	// UnlockURL is the page the unlock email links to, along with the token
+	// in the "token" query param.
+	UnlockURL string
+}
+
+// delay is how long an attempt must wait after the last failure.
+func (lp LoginPolicy) delay(failures int) time.Duration {
+	if failures < lp.DelayAfter {
+		return 0
+	}
+
+	exponent := failures - lp.DelayAfter
+	if exponent >= 32 {
+		return lp.MaxDelay
+	}
+
+	return min(lp.BaseDelay<<exponent, lp.MaxDelay)
+}
+
+type LoginInterface interface {
+	Do(ctx context.Context, input *LoginInput) (*LoginOutput, error)
+}
+
+type LoginImpl struct {
+	encryption     encryption.EncryptionInterface
+	fields         encryption.FieldEncryptionInterface
+	userRepository storage.UserRepositoryInterface
+	attempts       storage.LoginAttemptsStoreInterface
+	mailer         mail.MailerInterface
+	audit          AuditInterface
+	metrics        *UsersMetrics
+	policy         LoginPolicy
+	now            func() time.Time
+	dummyMutex     sync.Mutex
+	dummy          *encryption.EncryptedText
+}
+
+func NewLoginImpl(
+	en encryption.EncryptionInterface,
+	fe encryption.FieldEncryptionInterface,
+	ur storage.UserRepositoryInterface,
+	ls storage.LoginAttemptsStoreInterface,
+	ml mail.MailerInterface,
+	au AuditInterface,
+	um *UsersMetrics,
+	policy LoginPolicy,
+) *LoginImpl {
+	return &LoginImpl{
+		encryption:     en,
+		fields:         fe,
+		userRepository: ur,
+		attempts:       ls,
+		mailer:         ml,
+		audit:          au,
+		metrics:        um,
+		policy:         policy,
+		now:            time.Now,
+	}
+}
+
+// WithClock replaces the clock the delays and locks are measured with.
+func (lg *LoginImpl) WithClock(now func() time.Time) *LoginImpl {
+	lg.now = now
+	return lg
+}
+
+type LoginInput struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required,max=100" secret:"true"`
+	IP       string `json:"-"`
+}
+
+type LoginOutput struct {
+	*database.DatabaseIdentifier
+}
+
+// Do answers unknown emails and wrong passwords alike, and counts and locks
+// unknown emails as it does with accounts, so the answers never tell whether
+// an email is registered.
+func (lg *LoginImpl) Do(ctx context.Context, input *LoginInput) (_ *LoginOutput, err error) {
+	ctx, span := tracing.Start(ctx, "app.Login")
+	defer func() { tracing.End(span, err) }()
+
+	now := lg.now()
+	emailIndex := lg.fields.BlindIndex(input.Email)
+	accountKey := domain.AccountAttemptsKey(emailIndex)
+	ipKey := domain.IPAttemptsKey(input.IP)
+
+	if err := lg.checkAttempts(ctx, now, accountKey, ipKey); err != nil {
+		lg.audit.Record(ctx, AuditLoginBlocked, "email_index", emailIndex, "ip", input.IP, "code", err.Error())
+		return nil, err
+	}
+
+	var user domain.UserDatabase
+
+	err = lg.userRepository.GetCredentialsByEmail(ctx, database.UsersCollection, emailIndex, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := lg.verify(ctx, &user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, lg.fail(ctx, now, &user, emailIndex, input.IP)
+	}
+
+	if err := lg.attempts.Reset(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	lg.audit.Record(ctx, AuditLoginSucceeded, "user_id", user.Id, "ip", input.IP)
+
+	return &LoginOutput{DatabaseIdentifier: &database.DatabaseIdentifier{Id: user.Id}}, nil
+}
+
+func (lg *LoginImpl) checkAttempts(ctx context.Context, now time.Time, accountKey string, ipKey string) error {
+	for _, key := range []string{accountKey, ipKey} {
+		attempts, err := lg.attempts.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if attempts == nil {
+			continue
+		}
+
+		if attempts.Locked(now) {
+			return exception.New(CodeAccountLocked)
+		}
+
+		if key == ipKey && attempts.Failures >= lg.policy.MaxIPFailures {
+			return exception.New(CodeLoginThrottled)
+		}
+
+		if now.Before(attempts.LastFailure.Add(lg.policy.delay(attempts.Failures))) {
+			return exception.New(CodeLoginThrottled)
+		}
+	}
+
+	return nil
+}
+
+// verify decrypts a dummy password for unknown emails, so they take as long
+// as registered ones, e.g. with a key manager round trip.
+func (lg *LoginImpl) verify(ctx context.Context, user *domain.UserDatabase, password string) (bool, error) {
+	registered := exists(user)
+
+	encrypted := &encryption.EncryptedText{}
+	if registered {
+		encrypted.EncryptedText = user.Password
+		encrypted.Salt = user.CipherKey
+	} else {
+		dummy, err := lg.dummyPassword(ctx)
+		if err != nil {
+			return false, exception.Wrap(exception.CodeInternal, err)
+		}
+
+		encrypted = dummy
+	}
+
+	stored, err := lg.encryption.Decrypt(ctx, encrypted)
+	if err != nil {
+		return false, exception.Wrap(exception.CodeInternal, err)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1 && registered, nil
+}
+
+// dummyPassword encrypts a random password once, retrying on the next login
+// when it fails.
+func (lg *LoginImpl) dummyPassword(ctx context.Context) (*encryption.EncryptedText, error) {
+	lg.dummyMutex.Lock()
+	defer lg.dummyMutex.Unlock()
+
+	if lg.dummy != nil {
+		return lg.dummy, nil
+	}
+
+	password := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, password); err != nil {
+		return nil, err
+	}
+
+	dummy, err := lg.encryption.Encrypt(ctx, hex.EncodeToString(password))
+	if err != nil {
+		return nil, err
+	}
+
+	lg.dummy = dummy
+	return dummy, nil
+}
+
+// fail counts the failure for the IP and the account, locking the account
+// once it reaches the limit.
+func (lg *LoginImpl) fail(
+	ctx context.Context,
+	now time.Time,
+	user *domain.UserDatabase,
+	emailIndex string,
+	ip string,
+) error {
+	lg.metrics.LoginFailures.Inc()
+	lg.audit.Record(ctx, AuditLoginFailed, "email_index", emailIndex, "ip", ip)
+
+	if _, err := lg.attempts.Fail(ctx, domain.IPAttemptsKey(ip), now, lg.policy.Window); err != nil {
+		return err
+	}
+
+	account, err := lg.attempts.Fail(ctx, domain.AccountAttemptsKey(emailIndex), now, lg.policy.Window)
+	if err != nil {
+		return err
+	}
+
+	if account.Failures == lg.policy.MaxAccountFailures {
+		if err := lg.lock(ctx, now, user, emailIndex); err != nil {
+			return err
+		}
+	}
+
+	return exception.New(CodeInvalidCredentials)
+}
+
+// lock mails the unlock token to registered accounts only. Failing to send
+// it is audited instead of answered, which would tell the account exists.
+func (lg *LoginImpl) lock(ctx context.Context, now time.Time, user *domain.UserDatabase, emailIndex string) error {
+	token, err := newUnlockToken()
+	if err != nil {
+		return exception.Wrap(exception.CodeInternal, err)
+	}
+
+	until := now.Add(lg.policy.LockDuration)
+
+	err = lg.attempts.Lock(ctx, domain.AccountAttemptsKey(emailIndex), until, hashUnlockToken(token))
+	if err != nil {
+		return err
+	}
+
+	lg.audit.Record(ctx, AuditAccountLocked, "email_index", emailIndex, "until", until)
+
+	if !exists(user) {
+		return nil
+	}
+
+	if err := lg.sendUnlockMail(ctx, user, token); err != nil {
+		lg.audit.Record(ctx, AuditUnlockMailFailed, "user_id", user.Id, "cause", err.Error())
+	}
+
+	return nil
+}
+
+func (lg *LoginImpl) sendUnlockMail(ctx context.Context, user *domain.UserDatabase, token string) error {
+	email, err := lg.fields.DecryptField(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(lg.policy.UnlockURL)
+	if err != nil {
+		return err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	language := i18n.FromContext(ctx)
+
+	return lg.mailer.Send(ctx, &mail.Message{
+		To:      email,
+		Subject: i18n.T(language, "users.unlock_mail.subject"),
+		Body:    i18n.T(language, "users.unlock_mail.body", link.String()),
+	})
+}
+
+// exists tells registered users, not deleted, from the empty user of an
+// unknown email.
+func exists(user *domain.UserDatabase) bool {
+	if user.DatabaseIdentifier == nil || user.User == nil || user.UserPassword == nil {
+		return false
+	}
+
+	return user.DatabaseTimestamp == nil || user.DeletedAt == nil
+}
+
+func newUnlockToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// hashUnlockToken is what is stored of the token, so reading the stored
+// attempts does not unlock accounts.
+func hashUnlockToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,279 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	MOCK_LOGIN_ID       = "65e1f0a2b3c4d5e6f7a8b9c0"
+	MOCK_LOGIN_EMAIL    = "goo@gle.com"
+	MOCK_LOGIN_PASSWORD = "12345678"
+	MOCK_LOGIN_IP       = "10.0.0.1"
+)
+
+type TestingDependencies_TestLogin struct {
+	ctx                context.Context
+	ctrl               *gomock.Controller
+	mockUserRepository *mocks.MockUserRepositoryInterface
+	mockMailer         *mocks.MockMailerInterface
+	attempts           *storage.LoginAttemptsMemoryStoreImpl
+	usersMetrics       *app.UsersMetrics
+	advance            func(time.Duration)
+	decrypted          *[]string
+	loginImpl          *app.LoginImpl
+}
+
+// BeforeEach_TestLogin registers MOCK_LOGIN_EMAIL with MOCK_LOGIN_PASSWORD
+// and returns a login whose clock is moved by advance.
+func BeforeEach_TestLogin(t *testing.T) *TestingDependencies_TestLogin {
+	ctx := context.TODO()
+	ctrl := gomock.NewController(t)
+	mockEncryption := mocks.NewMockEncryptionInterface(ctrl)
+	mockUserRepository := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockMailer := mocks.NewMockMailerInterface(ctrl)
+	mockAudit := mocks.NewMockAuditInterface(ctrl)
+	fieldEncryption := BeforeEach_FieldEncryption(ctrl)
+	attempts := storage.NewLoginAttemptsMemoryStoreImpl()
+	usersMetrics := app.NewUsersMetrics(metrics.New())
+
+	decrypted := &[]string{}
+
+	mockEncryption.
+		EXPECT().
+		Encrypt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, text string) (*encryption.EncryptedText, error) {
+			return &encryption.EncryptedText{EncryptedText: MOCK_ENCRYPTED_PREFIX + text}, nil
+		})
+
+	mockEncryption.
+		EXPECT().
+		Decrypt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, encrypted *encryption.EncryptedText) (string, error) {
+			*decrypted = append(*decrypted, encrypted.EncryptedText)
+			return strings.TrimPrefix(encrypted.EncryptedText, MOCK_ENCRYPTED_PREFIX), nil
+		})
+
+	mockUserRepository.
+		EXPECT().
+		GetCredentialsByEmail(gomock.Any(), database.UsersCollection, gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, collection string, emailIndex string, user *domain.UserDatabase) error {
+			if emailIndex != MOCK_INDEX_PREFIX+MOCK_LOGIN_EMAIL {
+				return nil
+			}
+
+			*user = domain.UserDatabase{
+				DatabaseIdentifier: &database.DatabaseIdentifier{Id: MOCK_LOGIN_ID},
+				User:               &domain.User{Email: MOCK_ENCRYPTED_PREFIX + MOCK_LOGIN_EMAIL},
+				UserPassword:       &domain.UserPassword{Password: MOCK_ENCRYPTED_PREFIX + MOCK_LOGIN_PASSWORD},
+				DatabaseTimestamp:  &database.DatabaseTimestamp{},
+			}
+			return nil
+		})
+
+	mockAudit.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	now := time.Now()
+
+	loginImpl := app.NewLoginImpl(
+		mockEncryption,
+		fieldEncryption,
+		mockUserRepository,
+		attempts,
+		mockMailer,
+		mockAudit,
+		usersMetrics,
+		app.LoginPolicy{
+			DelayAfter:         2,
+			BaseDelay:          time.Second,
+			MaxDelay:           4 * time.Second,
+			MaxAccountFailures: 5,
+			MaxIPFailures:      8,
+			LockDuration:       time.Minute,
+			Window:             time.Hour,
+			UnlockURL:          "https://braz.com/unlock",
+		},
+	).WithClock(func() time.Time { return now })
+
+	return &TestingDependencies_TestLogin{
+		ctx:                ctx,
+		ctrl:               ctrl,
+		mockUserRepository: mockUserRepository,
+		mockMailer:         mockMailer,
+		attempts:           attempts,
+		usersMetrics:       usersMetrics,
+		advance:            func(d time.Duration) { now = now.Add(d) },
+		decrypted:          decrypted,
+		loginImpl:          loginImpl,
+	}
+}
+
+// failLogins fails n logins of the email, waiting out the delays in between.
+func failLogins(t *testing.T, deps *TestingDependencies_TestLogin, email string, ip string, n int) {
+	for i := 0; i < n; i++ {
+		_, err := deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: email, Password: "wrong", IP: ip})
+		if !errors.Is(err, exception.New(app.CodeInvalidCredentials)) {
+			t.Fatalf("failed login %d answered %v", i, err)
+		}
+
+		deps.advance(4 * time.Second)
+	}
+}
+
+// unlockToken is the token of the link in the unlock mail.
+func unlockToken(message *mail.Message) string {
+	for _, word := range strings.Fields(message.Body) {
+		if link, err := url.Parse(strings.TrimRight(word, ",.")); err == nil && link.Scheme == "https" {
+			return link.Query().Get("token")
+		}
+	}
+
+	return ""
+}
+
+func TestLogin_Do(t *testing.T) {
+	t.Run("should return the user id when the credentials are valid", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		output, err := deps.loginImpl.Do(deps.ctx, &app.LoginInput{
+			Email:    MOCK_LOGIN_EMAIL,
+			Password: MOCK_LOGIN_PASSWORD,
+			IP:       MOCK_LOGIN_IP,
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, MOCK_LOGIN_ID, output.Id, "should return the user id")
+	})
+
+	t.Run("should answer unknown emails and wrong passwords alike", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		_, wrongPassword := deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: "wrong"})
+		_, unknownEmail := deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: "unknown@gle.com", Password: "wrong"})
+
+		assert.Equal(t, exception.New(app.CodeInvalidCredentials), wrongPassword, "should return invalid credentials")
+		assert.Equal(t, wrongPassword, unknownEmail, "should return the same error")
+		assert.Equal(t, 2.0, testutil.ToFloat64(deps.usersMetrics.LoginFailures), "should count the failures")
+	})
+
+	t.Run("should decrypt a dummy password for unknown emails", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: "unknown@gle.com", Password: "wrong"})
+		deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: "other@gle.com", Password: "wrong"})
+
+		assert.Len(t, *deps.decrypted, 2, "should decrypt as registered emails do")
+		assert.Equal(t, (*deps.decrypted)[0], (*deps.decrypted)[1], "should encrypt the dummy password once")
+		assert.NotEqual(t, MOCK_ENCRYPTED_PREFIX+"wrong", (*deps.decrypted)[0], "should not match any password")
+	})
+
+	t.Run("should delay attempts progressively after some failures", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		input := &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: "wrong", IP: MOCK_LOGIN_IP}
+		deps.loginImpl.Do(deps.ctx, input)
+		_, err := deps.loginImpl.Do(deps.ctx, input)
+		assert.Equal(t, exception.New(app.CodeInvalidCredentials), err, "should not delay the first failures")
+
+		_, err = deps.loginImpl.Do(deps.ctx, input)
+		assert.Equal(t, exception.New(app.CodeLoginThrottled), err, "should delay after the second failure")
+
+		deps.advance(time.Second)
+		deps.loginImpl.Do(deps.ctx, input)
+
+		deps.advance(time.Second)
+		_, err = deps.loginImpl.Do(deps.ctx, input)
+		assert.Equal(t, exception.New(app.CodeLoginThrottled), err, "should double the delay")
+
+		deps.advance(time.Second)
+		_, err = deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: MOCK_LOGIN_PASSWORD})
+		assert.Nil(t, err, "should allow after the delay")
+
+		attempts, _ := deps.attempts.Get(deps.ctx, domain.AccountAttemptsKey(MOCK_INDEX_PREFIX+MOCK_LOGIN_EMAIL))
+		assert.Nil(t, attempts, "should reset the account failures on success")
+	})
+
+	t.Run("should lock the account and mail the unlock link", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		var message *mail.Message
+		deps.mockMailer.
+			EXPECT().
+			Send(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, m *mail.Message) error {
+				message = m
+				return nil
+			})
+
+		failLogins(t, deps, MOCK_LOGIN_EMAIL, MOCK_LOGIN_IP, 5)
+
+		_, err := deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: MOCK_LOGIN_PASSWORD})
+		assert.Equal(t, exception.New(app.CodeAccountLocked), err, "should refuse even valid credentials")
+
+		assert.Equal(t, MOCK_LOGIN_EMAIL, message.To, "should mail the decrypted email")
+		assert.NotEmpty(t, unlockToken(message), "should link the unlock token")
+
+		deps.advance(time.Minute)
+		_, err = deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: MOCK_LOGIN_PASSWORD})
+		assert.Nil(t, err, "should unlock once the lock expires")
+	})
+
+	t.Run("should lock unknown emails without mailing", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		failLogins(t, deps, "unknown@gle.com", MOCK_LOGIN_IP, 5)
+
+		_, err := deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: "unknown@gle.com", Password: "wrong"})
+		assert.Equal(t, exception.New(app.CodeAccountLocked), err, "should answer as a locked account")
+	})
+
+	t.Run("should throttle an ip failing across accounts", func(t *testing.T) {
+		deps := BeforeEach_TestLogin(t)
+		defer deps.ctrl.Finish()
+
+		for i := 0; i < 8; i++ {
+			failLogins(t, deps, strings.Repeat("a", i+1)+"@gle.com", MOCK_LOGIN_IP, 1)
+		}
+
+		_, err := deps.loginImpl.Do(deps.ctx, &app.LoginInput{
+			Email:    MOCK_LOGIN_EMAIL,
+			Password: MOCK_LOGIN_PASSWORD,
+			IP:       MOCK_LOGIN_IP,
+		})
+		assert.Equal(t, exception.New(app.CodeLoginThrottled), err, "should throttle the ip")
+
+		_, err = deps.loginImpl.Do(deps.ctx, &app.LoginInput{
+			Email:    MOCK_LOGIN_EMAIL,
+			Password: MOCK_LOGIN_PASSWORD,
+			IP:       "10.0.0.2",
+		})
+		assert.Nil(t, err, "should allow other ips")
+	})
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+)
+
+// UsersMetrics holds the business counters of the users service.
+type UsersMetrics struct {
+	UsersCreated  metrics.Counter
+	UsersDeleted  metrics.Counter
+	LoginFailures metrics.Counter
+}
+
+func NewUsersMetrics(m *metrics.Metrics) *UsersMetrics {
+	return &UsersMetrics{
+		UsersCreated:  m.Counter("users_created_total", "Number of users created."),
+		UsersDeleted:  m.Counter("users_deleted_total", "Number of users deleted."),
+		LoginFailures: m.Counter("users_login_failures_total", "Number of failed login attempts."),
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+)
+
+type UnlockAccountInterface interface {
+	Do(ctx context.Context, input *UnlockAccountInput) error
+}
+
+// UnlockAccountImpl unlocks the account locked by the token of the unlock
+// email.
+type UnlockAccountImpl struct {
+	attempts storage.LoginAttemptsStoreInterface
+	audit    AuditInterface
+}
+
+func NewUnlockAccountImpl(ls storage.LoginAttemptsStoreInterface, au AuditInterface) *UnlockAccountImpl {
+	return &UnlockAccountImpl{attempts: ls, audit: au}
+}
+
+type UnlockAccountInput struct {
+	Token string `json:"token" validate:"required,max=100" secret:"true"`
+}
+
+func (ua *UnlockAccountImpl) Do(ctx context.Context, input *UnlockAccountInput) (err error) {
+	ctx, span := tracing.Start(ctx, "app.UnlockAccount")
+	defer func() { tracing.End(span, err) }()
+
+	attempts, err := ua.attempts.GetByUnlockToken(ctx, hashUnlockToken(input.Token))
+	if err != nil {
+		return err
+	}
+
+	if attempts == nil {
+		return exception.New(exception.CodeNotFound)
+	}
+
+	if err := ua.attempts.Reset(ctx, attempts.Key); err != nil {
+		return err
+	}
+
+	ua.audit.Record(ctx, AuditAccountUnlocked, "key", attempts.Key, "by", "email")
+
+	return nil
+}
+
+type UnlockUserByIdInterface interface {
+	Do(ctx context.Context, id string) error
+}
+
+// UnlockUserByIdImpl lets admins unlock an account before the lock expires.
+type UnlockUserByIdImpl struct {
+	crudRepository database.CrudRepositoryInterface
+	attempts       storage.LoginAttemptsStoreInterface
+	audit          AuditInterface
+}
+
+func NewUnlockUserByIdImpl(
+	cr database.CrudRepositoryInterface,
+	ls storage.LoginAttemptsStoreInterface,
+	au AuditInterface,
+) *UnlockUserByIdImpl {
+	return &UnlockUserByIdImpl{crudRepository: cr, attempts: ls, audit: au}
+}
+
+func (uu *UnlockUserByIdImpl) Do(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "app.UnlockUserById")
+	defer func() { tracing.End(span, err) }()
+
+	var user domain.UserEmailIndex
+
+	err = uu.crudRepository.GetById(ctx, database.UsersCollection, id, false, &user)
+	if err != nil {
+		return err
+	}
+
+	if err := uu.attempts.Reset(ctx, domain.AccountAttemptsKey(user.EmailIndex)); err != nil {
+		return err
+	}
+
+	uu.audit.Record(ctx, AuditAccountUnlocked, "user_id", id, "by", "admin")
+
+	return nil
+}
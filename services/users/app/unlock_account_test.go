@@ -0,0 +1,101 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/mail"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// BeforeEach_TestUnlockAccount locks MOCK_LOGIN_EMAIL and returns the token
+// of the unlock mail.
+func BeforeEach_TestUnlockAccount(t *testing.T) (*TestingDependencies_TestLogin, string) {
+	deps := BeforeEach_TestLogin(t)
+
+	var token string
+	deps.mockMailer.
+		EXPECT().
+		Send(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, message *mail.Message) error {
+			token = unlockToken(message)
+			return nil
+		})
+
+	failLogins(t, deps, MOCK_LOGIN_EMAIL, MOCK_LOGIN_IP, 5)
+
+	return deps, token
+}
+
+func TestUnlockAccount_Do(t *testing.T) {
+	t.Run("should unlock the account of the token", func(t *testing.T) {
+		deps, token := BeforeEach_TestUnlockAccount(t)
+		defer deps.ctrl.Finish()
+
+		mockAudit := mocks.NewMockAuditInterface(deps.ctrl)
+		mockAudit.EXPECT().Record(gomock.Any(), app.AuditAccountUnlocked, gomock.Any()).Times(1)
+
+		err := app.NewUnlockAccountImpl(deps.attempts, mockAudit).Do(deps.ctx, &app.UnlockAccountInput{Token: token})
+		assert.Nil(t, err, "should not return error")
+
+		_, err = deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: MOCK_LOGIN_PASSWORD})
+		assert.Nil(t, err, "should allow the login")
+	})
+
+	t.Run("should return not found when no account is locked by the token", func(t *testing.T) {
+		deps, _ := BeforeEach_TestUnlockAccount(t)
+		defer deps.ctrl.Finish()
+
+		err := app.NewUnlockAccountImpl(deps.attempts, mocks.NewMockAuditInterface(deps.ctrl)).
+			Do(deps.ctx, &app.UnlockAccountInput{Token: "unknown"})
+
+		assert.Equal(t, exception.New(exception.CodeNotFound), err, "should return not found")
+	})
+}
+
+func TestUnlockUserById_Do(t *testing.T) {
+	t.Run("should unlock the account of the user", func(t *testing.T) {
+		deps, _ := BeforeEach_TestUnlockAccount(t)
+		defer deps.ctrl.Finish()
+
+		mockCrudRepository := mocks.NewMockCrudRepositoryInterface(deps.ctrl)
+		mockCrudRepository.
+			EXPECT().
+			GetById(gomock.Any(), database.UsersCollection, MOCK_LOGIN_ID, false, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, collection string, id string, deleted bool, user any) error {
+				user.(*domain.UserEmailIndex).EmailIndex = MOCK_INDEX_PREFIX + MOCK_LOGIN_EMAIL
+				return nil
+			})
+
+		mockAudit := mocks.NewMockAuditInterface(deps.ctrl)
+		mockAudit.EXPECT().Record(gomock.Any(), app.AuditAccountUnlocked, gomock.Any()).Times(1)
+
+		err := app.NewUnlockUserByIdImpl(mockCrudRepository, deps.attempts, mockAudit).Do(deps.ctx, MOCK_LOGIN_ID)
+		assert.Nil(t, err, "should not return error")
+
+		_, err = deps.loginImpl.Do(deps.ctx, &app.LoginInput{Email: MOCK_LOGIN_EMAIL, Password: MOCK_LOGIN_PASSWORD})
+		assert.Nil(t, err, "should allow the login")
+	})
+
+	t.Run("should return error when the user does not exist", func(t *testing.T) {
+		deps, _ := BeforeEach_TestUnlockAccount(t)
+		defer deps.ctrl.Finish()
+
+		mockCrudRepository := mocks.NewMockCrudRepositoryInterface(deps.ctrl)
+		mockCrudRepository.
+			EXPECT().
+			GetById(gomock.Any(), database.UsersCollection, MOCK_LOGIN_ID, false, gomock.Any()).
+			Return(exception.New(exception.CodeNotFound))
+
+		err := app.NewUnlockUserByIdImpl(mockCrudRepository, deps.attempts, mocks.NewMockAuditInterface(deps.ctrl)).
+			Do(deps.ctx, MOCK_LOGIN_ID)
+
+		assert.Equal(t, exception.New(exception.CodeNotFound), err, "should return not found")
+	})
+}
@@ -2,13 +2,12 @@ package app
 
 import (
 	"context"
-	"errors"
-	"os"
 	"time"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/encryption"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/tracing"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
 )
@@ -19,29 +18,34 @@ type UpdateUserByIdInterface interface {
 
 type UpdateUserByIdImpl struct {
 	encryption     encryption.EncryptionInterface
+	fields         encryption.FieldEncryptionInterface
 	crudRepository database.CrudRepositoryInterface
 	userRepository storage.UserRepositoryInterface
 }
 
 func NewUpdateUserByIdImpl(
 	en encryption.EncryptionInterface,
+	fe encryption.FieldEncryptionInterface,
 	cr database.CrudRepositoryInterface,
 	ur storage.UserRepositoryInterface,
 ) *UpdateUserByIdImpl {
 	return &UpdateUserByIdImpl{
 		encryption:     en,
+		fields:         fe,
 		crudRepository: cr,
 		userRepository: ur,
 	}
 }
 
 type UpdateUserByIdInput struct {
-	FirstName string    `json:"first_name" validate:"omitempty,min=1,max=100" bson:"first_name,omitempty"`
-	LastName  string    `json:"last_name" validate:"omitempty,min=1,max=100" bson:"last_name,omitempty"`
-	Email     string    `json:"email" validate:"omitempty,min=1,max=100" bson:"email,omitempty"`
-	Type      string    `json:"type" validate:"omitempty,min=1,max=100" bson:"type,omitempty"`
-	Password  string    `json:"password" validate:"omitempty,min=1,max=100" bson:"password,omitempty"`
-	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+	FirstName  string    `json:"first_name" validate:"omitempty,min=1,max=100" bson:"first_name,omitempty"`
+	LastName   string    `json:"last_name" validate:"omitempty,min=1,max=100" bson:"last_name,omitempty"`
+	Email      string    `json:"email" validate:"omitempty,min=1,max=100" bson:"email,omitempty"`
+	EmailIndex string    `json:"-" bson:"email_index,omitempty"`
+	Type       string    `json:"type" validate:"omitempty,min=1,max=100" bson:"type,omitempty"`
+	Password   string    `json:"password" validate:"omitempty,min=1,max=100" bson:"password,omitempty" secret:"true"`
+	CipherKey  string    `json:"-" bson:"cipher_key,omitempty"`
+	UpdatedAt  time.Time `json:"-" bson:"updated_at,omitempty"`
 }
 
 type UpdateUserByIdOutput struct {
@@ -52,14 +56,19 @@ func (gu *UpdateUserByIdImpl) Do(
 	ctx context.Context,
 	id string,
 	input *UpdateUserByIdInput,
-) (*UpdateUserByIdOutput, error) {
+) (_ *UpdateUserByIdOutput, err error) {
+	ctx, span := tracing.Start(ctx, "app.UpdateUserById")
+	defer func() { tracing.End(span, err) }()
+
 	var existentUser domain.UserDatabaseNoPassword
 
 	if input.Email != "" {
+		input.EmailIndex = gu.fields.BlindIndex(input.Email)
+
 		err := gu.userRepository.GetByEmail(
 			ctx,
 			database.UsersCollection,
-			input.Email,
+			input.EmailIndex,
 			&existentUser,
 		)
 
@@ -68,28 +77,41 @@ func (gu *UpdateUserByIdImpl) Do(
 		}
 
 		if existentUser != (domain.UserDatabaseNoPassword{}) && existentUser.Id != id {
-			return nil, errors.New(exception.CodePermission)
+			return nil, exception.New(exception.CodePermission)
 		}
 	}
 
 	if input.Password != "" {
-		secret := os.Getenv("ENC_SECRET")
-		encryptionData, err := gu.encryption.Encrypt(ctx, secret, input.Password)
+		encryptionData, err := gu.encryption.Encrypt(ctx, input.Password)
 
 		if err != nil {
 			return nil, err
 		}
 
 		input.Password = encryptionData.EncryptedText
-		input.Password = encryptionData.Salt
+		input.CipherKey = encryptionData.Salt
+	}
+
+	err = encryptFields(ctx, gu.fields, &input.FirstName, &input.LastName, &input.Email)
+	if err != nil {
+		return nil, err
 	}
 
 	input.UpdatedAt = time.Now()
 
 	var output = UpdateUserByIdOutput{}
 
-	err := gu.crudRepository.UpdateById(ctx, database.UsersCollection, id, &input, &output)
+	err = gu.crudRepository.UpdateById(ctx, database.UsersCollection, id, &input, &output)
+
+	if database.IsDuplicateKeyError(err) {
+		return nil, exception.New(exception.CodePermission)
+	}
+
+	if err != nil {
+		return nil, err
+	}
 
+	err = decryptUser(ctx, gu.fields, output.UserDatabaseNoPassword)
 	if err != nil {
 		return nil, err
 	}
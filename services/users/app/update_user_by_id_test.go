@@ -3,16 +3,17 @@ package app_test
 import (
 	"context"
 	"errors"
-	"os"
 	"testing"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
 	"github.com/italoservio/braz_ecommerce/services/users/app"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"github.com/italoservio/braz_ecommerce/services/users/mocks"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/mock/gomock"
 )
 
@@ -34,6 +35,7 @@ func BeforeEach_TestUpdateUserById(t *testing.T) *TestingDependencies_TestUpdate
 
 	updateUserByIdImpl := app.NewUpdateUserByIdImpl(
 		encryption,
+		BeforeEach_FieldEncryption(ctrl),
 		mockCrudRepository,
 		mockUserRepository,
 	)
@@ -60,7 +62,7 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			Return(mockExpectedError)
 
@@ -82,12 +84,12 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{
@@ -118,12 +120,12 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{
@@ -152,6 +154,27 @@ func TestUpdateUser_Do(t *testing.T) {
 		assert.NotNil(t, err, "should return error")
 	})
 
+	t.Run("should return a permission error when the email is registered meanwhile", func(t *testing.T) {
+		deps := BeforeEach_TestUpdateUserById(t)
+		defer deps.ctrl.Finish()
+
+		deps.mockUserRepository.
+			EXPECT().
+			GetByEmail(gomock.Any(), database.UsersCollection, gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		deps.mockCrudRepository.
+			EXPECT().
+			UpdateById(gomock.Any(), database.UsersCollection, gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(database.ParseToDatabaseError(mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}))
+
+		_, err := deps.updateUserByIdImpl.Do(deps.ctx, primitive.NewObjectID().Hex(), &app.UpdateUserByIdInput{Email: "goo@gle.com"})
+
+		assert.Equal(t, exception.CodePermission, err.Error(), "should return permission error")
+	})
+
 	t.Run("should return error when failed to call Encrypt", func(t *testing.T) {
 		deps := BeforeEach_TestUpdateUserById(t)
 		defer deps.ctrl.Finish()
@@ -162,12 +185,12 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{
@@ -184,7 +207,7 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(nil, mockExpectedError)
 
@@ -206,12 +229,12 @@ func TestUpdateUser_Do(t *testing.T) {
 
 		deps.mockUserRepository.
 			EXPECT().
-			GetByEmail(gomock.Any(), database.UsersCollection, mockEmail, gomock.Any()).
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
 			Times(1).
 			DoAndReturn(func(
 				ctx context.Context,
 				collection string,
-				email string,
+				emailIndex string,
 				structure *domain.UserDatabaseNoPassword,
 			) error {
 				*structure = domain.UserDatabaseNoPassword{
@@ -229,12 +252,11 @@ func TestUpdateUser_Do(t *testing.T) {
 			Times(1).
 			Return(nil)
 
-		os.Setenv("ENC_SECRET", "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6")
 		mockPassword := "test"
 
 		deps.encryption.
 			EXPECT().
-			Encrypt(gomock.Any(), gomock.Any(), mockPassword).
+			Encrypt(gomock.Any(), mockPassword).
 			Times(1).
 			Return(&encryption.EncryptedText{EncryptedText: "", Salt: ""}, nil)
 
@@ -246,4 +268,61 @@ func TestUpdateUser_Do(t *testing.T) {
 		assert.Nil(t, err, "should not return an error")
 	})
 
+	t.Run("should store the encrypted password along with its salt", func(t *testing.T) {
+		deps := BeforeEach_TestUpdateUserById(t)
+		defer deps.ctrl.Finish()
+
+		id := primitive.NewObjectID().Hex()
+		mockPassword := "test"
+
+		deps.encryption.
+			EXPECT().
+			Encrypt(gomock.Any(), mockPassword).
+			Times(1).
+			Return(&encryption.EncryptedText{EncryptedText: "v1:abc", Salt: "def"}, nil)
+
+		input := &app.UpdateUserByIdInput{Password: mockPassword}
+
+		deps.mockCrudRepository.
+			EXPECT().
+			UpdateById(gomock.Any(), database.UsersCollection, id, gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		_, err := deps.updateUserByIdImpl.Do(deps.ctx, id, input)
+
+		assert.Nil(t, err, "should not return an error")
+		assert.Equal(t, "v1:abc", input.Password, "should store the encrypted password")
+		assert.Equal(t, "def", input.CipherKey, "should store the salt")
+	})
+
+	t.Run("should store the personal fields encrypted along with the email index", func(t *testing.T) {
+		deps := BeforeEach_TestUpdateUserById(t)
+		defer deps.ctrl.Finish()
+
+		id := primitive.NewObjectID().Hex()
+		mockEmail := "goo@gle.com"
+
+		deps.mockUserRepository.
+			EXPECT().
+			GetByEmail(gomock.Any(), database.UsersCollection, MOCK_INDEX_PREFIX+mockEmail, gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		deps.mockCrudRepository.
+			EXPECT().
+			UpdateById(gomock.Any(), database.UsersCollection, id, gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		input := &app.UpdateUserByIdInput{FirstName: "Italo", Email: mockEmail}
+
+		_, err := deps.updateUserByIdImpl.Do(deps.ctx, id, input)
+
+		assert.Nil(t, err, "should not return an error")
+		assert.Equal(t, "encrypted:Italo", input.FirstName, "should encrypt the first name")
+		assert.Equal(t, "", input.LastName, "should not set fields that were not sent")
+		assert.Equal(t, "encrypted:"+mockEmail, input.Email, "should encrypt the email")
+		assert.Equal(t, MOCK_INDEX_PREFIX+mockEmail, input.EmailIndex, "should store the email index")
+	})
 }
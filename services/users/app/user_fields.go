@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+)
+
+func encryptFields(ctx context.Context, fe encryption.FieldEncryptionInterface, fields ...*string) error {
+	for _, field := range fields {
+		encrypted, err := fe.EncryptField(ctx, *field)
+		if err != nil {
+			return err
+		}
+
+		*field = encrypted
+	}
+
+	return nil
+}
+
+func decryptFields(ctx context.Context, fe encryption.FieldEncryptionInterface, fields ...*string) error {
+	for _, field := range fields {
+		decrypted, err := fe.DecryptField(ctx, *field)
+		if err != nil {
+			return err
+		}
+
+		*field = decrypted
+	}
+
+	return nil
+}
+
+func decryptUser(ctx context.Context, fe encryption.FieldEncryptionInterface, user *domain.UserDatabaseNoPassword) error {
+	if user == nil || user.User == nil {
+		return nil
+	}
+
+	return decryptFields(ctx, fe, user.PersonalFields()...)
+}
@@ -0,0 +1,49 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	MOCK_ENCRYPTED_PREFIX = "encrypted:"
+	MOCK_INDEX_PREFIX     = "index:"
+)
+
+// BeforeEach_FieldEncryption returns a field encryption that marks fields as
+// encrypted with a prefix, so assertions can tell what was encrypted.
+func BeforeEach_FieldEncryption(ctrl *gomock.Controller) *mocks.MockFieldEncryptionInterface {
+	fieldEncryption := mocks.NewMockFieldEncryptionInterface(ctrl)
+
+	fieldEncryption.
+		EXPECT().
+		EncryptField(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, text string) (string, error) {
+			if text == "" {
+				return "", nil
+			}
+			return MOCK_ENCRYPTED_PREFIX + text, nil
+		})
+
+	fieldEncryption.
+		EXPECT().
+		DecryptField(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, field string) (string, error) {
+			return strings.TrimPrefix(field, MOCK_ENCRYPTED_PREFIX), nil
+		})
+
+	fieldEncryption.
+		EXPECT().
+		BlindIndex(gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(text string) string {
+			return MOCK_INDEX_PREFIX + strings.ToLower(text)
+		})
+
+	return fieldEncryption
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+)
+
+// LoginAttempts counts the failed logins of a key, an account by the blind
+// index of its email or an IP, until ExpiresAt. Locked accounts carry the
+// hash of the token that unlocks them by email.
+type LoginAttempts struct {
+	Key         string     `bson:"_id"`
+	Failures    int        `bson:"failures"`
+	LastFailure time.Time  `bson:"last_failure"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+	UnlockToken string     `bson:"unlock_token,omitempty"`
+	ExpiresAt   time.Time  `bson:"expires_at"`
+}
+
+func (la *LoginAttempts) Locked(now time.Time) bool {
+	return la.LockedUntil != nil && now.Before(*la.LockedUntil)
+}
+
+func AccountAttemptsKey(emailIndex string) string {
+	return "account:" + emailIndex
+}
+
+func IPAttemptsKey(ip string) string {
+	return "ip:" + ip
+}
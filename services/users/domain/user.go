@@ -12,6 +12,29 @@ type User struct {
 	Addresses []UserAddress `json:"addresses" bson:"addresses"`
 }
 
+// PersonalFields lists the personal data of the user that is encrypted at
+// rest. Type, state and country are kept in plaintext.
+func (u *User) PersonalFields() []*string {
+	fields := []*string{&u.FirstName, &u.LastName, &u.Email}
+
+	for i := range u.Addresses {
+		address := &u.Addresses[i]
+		fields = append(fields, &address.Cep, &address.Street, &address.Neighborhood, &address.Number)
+
+		if address.Complement != nil {
+			fields = append(fields, address.Complement)
+		}
+	}
+
+	return fields
+}
+
+// UserEmailIndex holds the blind index of the encrypted email, the field used
+// to look users up by email.
+type UserEmailIndex struct {
+	EmailIndex string `json:"-" bson:"email_index"`
+}
+
 type UserPassword struct {
 	Password  string `json:"password" bson:"password"`
 	CipherKey string `bson:"cipher_key"`
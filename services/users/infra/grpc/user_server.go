@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/validation"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/grpc/pb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative pb/users.proto
+
+// UserServerImpl serves the use cases of UserControllerImpl over gRPC. Errors
+// are returned as they are and answered by the rpc.Errors interceptor.
+type UserServerImpl struct {
+	pb.UnimplementedUsersServiceServer
+	getUserByIdImpl      app.GetUserByIdInterface
+	deleteUserByIdImpl   app.DeleteUserByIdInterface
+	createUserImpl       app.CreateUserInterface
+	getUserPaginatedImpl app.GetUserPaginatedInterface
+	updateUserByIdImpl   app.UpdateUserByIdInterface
+}
+
+func NewUserServerImpl(
+	getUserByIdImpl app.GetUserByIdInterface,
+	deleteUserByIdImpl app.DeleteUserByIdInterface,
+	createUserImpl app.CreateUserInterface,
+	getUserPaginatedImpl app.GetUserPaginatedInterface,
+	updateUserByIdImpl app.UpdateUserByIdInterface,
+) *UserServerImpl {
+	return &UserServerImpl{
+		getUserByIdImpl:      getUserByIdImpl,
+		deleteUserByIdImpl:   deleteUserByIdImpl,
+		createUserImpl:       createUserImpl,
+		getUserPaginatedImpl: getUserPaginatedImpl,
+		updateUserByIdImpl:   updateUserByIdImpl,
+	}
+}
+
+func (us *UserServerImpl) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	input := &app.CreateUserInput{
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Email:     req.GetEmail(),
+		Type:      req.GetType(),
+		Password:  req.GetPassword(),
+	}
+
+	if err := validation.Validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	output, err := us.createUserImpl.Do(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateUserResponse{Id: output.Id}, nil
+}
+
+func (us *UserServerImpl) GetUserById(ctx context.Context, req *pb.GetUserByIdRequest) (*pb.User, error) {
+	output, err := us.getUserByIdImpl.Do(ctx, &app.GetUserByIdInput{
+		Id:      req.GetId(),
+		Deleted: req.GetDeleted(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(output.UserDatabaseNoPassword), nil
+}
+
+func (us *UserServerImpl) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	input := &app.GetUserPaginatedInput{
+		Page:    int(req.GetPage()),
+		PerPage: int(req.GetPerPage()),
+		Emails:  req.GetEmails(),
+		Ids:     req.GetIds(),
+		Deleted: req.GetDeleted(),
+	}
+
+	if err := validation.Validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	output, err := us.getUserPaginatedImpl.Do(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.ListUsersResponse{Page: int32(output.Page), PerPage: int32(output.PerPage)}
+	if output.Items != nil {
+		for _, item := range *output.Items {
+			response.Items = append(response.Items, toUser(item.UserDatabaseNoPassword))
+		}
+	}
+
+	return response, nil
+}
+
+func (us *UserServerImpl) UpdateUserById(ctx context.Context, req *pb.UpdateUserByIdRequest) (*pb.User, error) {
+	input := &app.UpdateUserByIdInput{
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Email:     req.GetEmail(),
+		Type:      req.GetType(),
+		Password:  req.GetPassword(),
+	}
+
+	if err := validation.Validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	output, err := us.updateUserByIdImpl.Do(ctx, req.GetId(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(output.UserDatabaseNoPassword), nil
+}
+
+func (us *UserServerImpl) DeleteUserById(ctx context.Context, req *pb.DeleteUserByIdRequest) (*emptypb.Empty, error) {
+	if err := us.deleteUserByIdImpl.Do(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func toUser(user *domain.UserDatabaseNoPassword) *pb.User {
+	answered := &pb.User{}
+	if user == nil {
+		return answered
+	}
+
+	if user.DatabaseIdentifier != nil {
+		answered.Id = user.Id
+	}
+
+	if user.User != nil {
+		answered.Type = user.Type
+		answered.FirstName = user.FirstName
+		answered.LastName = user.LastName
+		answered.Email = user.Email
+
+		for _, address := range user.Addresses {
+			answered.Addresses = append(answered.Addresses, &pb.Address{
+				Cep:          address.Cep,
+				Street:       address.Street,
+				Neighborhood: address.Neighborhood,
+				State:        address.State,
+				Country:      address.Country,
+				Number:       address.Number,
+				Complement:   address.Complement,
+			})
+		}
+	}
+
+	if user.DatabaseTimestamp != nil {
+		answered.CreatedAt = timestamppb.New(user.CreatedAt)
+		answered.UpdatedAt = timestamppb.New(user.UpdatedAt)
+		if user.DeletedAt != nil {
+			answered.DeletedAt = timestamppb.New(*user.DeletedAt)
+		}
+	}
+
+	return answered
+}
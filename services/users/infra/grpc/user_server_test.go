@@ -0,0 +1,324 @@
+package grpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/rpc"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	usersgrpc "github.com/italoservio/braz_ecommerce/services/users/infra/grpc"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/grpc/pb"
+	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const MOCK_API_KEY = "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6"
+
+type TestingDependencies_TestUserServer struct {
+	ctx                      context.Context
+	mockGetUserByIdImpl      *mocks.MockGetUserByIdInterface
+	mockDeleteUserByIdImpl   *mocks.MockDeleteUserByIdInterface
+	mockCreateUserImpl       *mocks.MockCreateUserInterface
+	mockGetUserPaginatedImpl *mocks.MockGetUserPaginatedInterface
+	mockUpdateUserByIdImpl   *mocks.MockUpdateUserByIdInterface
+	client                   pb.UsersServiceClient
+}
+
+// BeforeEach_TestUserServer serves the users server through the interceptors
+// of rpc.NewServer over an in-memory connection.
+func BeforeEach_TestUserServer(t *testing.T) *TestingDependencies_TestUserServer {
+	ctrl := gomock.NewController(t)
+
+	mockGetUserByIdImpl := mocks.NewMockGetUserByIdInterface(ctrl)
+	mockDeleteUserByIdImpl := mocks.NewMockDeleteUserByIdInterface(ctrl)
+	mockCreateUserImpl := mocks.NewMockCreateUserInterface(ctrl)
+	mockGetUserPaginatedImpl := mocks.NewMockGetUserPaginatedInterface(ctrl)
+	mockUpdateUserByIdImpl := mocks.NewMockUpdateUserByIdInterface(ctrl)
+
+	server := rpc.NewServer(rpc.Config{
+		Logger: logger.NewLoggerWithConfig(logger.Config{Output: io.Discard}),
+		APIKey: MOCK_API_KEY,
+	})
+	pb.RegisterUsersServiceServer(server, usersgrpc.NewUserServerImpl(
+		mockGetUserByIdImpl,
+		mockDeleteUserByIdImpl,
+		mockCreateUserImpl,
+		mockGetUserPaginatedImpl,
+		mockUpdateUserByIdImpl,
+	))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &TestingDependencies_TestUserServer{
+		ctx:                      metadata.AppendToOutgoingContext(context.TODO(), rpc.MetadataAPIKey, MOCK_API_KEY),
+		mockGetUserByIdImpl:      mockGetUserByIdImpl,
+		mockDeleteUserByIdImpl:   mockDeleteUserByIdImpl,
+		mockCreateUserImpl:       mockCreateUserImpl,
+		mockGetUserPaginatedImpl: mockGetUserPaginatedImpl,
+		mockUpdateUserByIdImpl:   mockUpdateUserByIdImpl,
+		client:                   pb.NewUsersServiceClient(conn),
+	}
+}
+
+func mockUser(id string) *domain.UserDatabaseNoPassword {
+	complement := "apt 1"
+	now := time.Now()
+
+	return &domain.UserDatabaseNoPassword{
+		DatabaseIdentifier: &database.DatabaseIdentifier{Id: id},
+		User: &domain.User{
+			Type:      "customer",
+			FirstName: "Italo",
+			LastName:  "Servio",
+			Email:     "goo@gle.com",
+			Addresses: []domain.UserAddress{{Cep: "01001000", Complement: &complement}},
+		},
+		DatabaseTimestamp: &database.DatabaseTimestamp{CreatedAt: now, UpdatedAt: now},
+	}
+}
+
+func errorCode(err error) string {
+	return rpc.ErrorCode(status.Convert(err))
+}
+
+func TestUserServer_CreateUser(t *testing.T) {
+	t.Run("should create the user", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+		id := primitive.NewObjectID().Hex()
+
+		deps.mockCreateUserImpl.
+			EXPECT().
+			Do(gomock.Any(), &app.CreateUserInput{
+				FirstName: "Italo",
+				LastName:  "Servio",
+				Email:     "goo@gle.com",
+				Type:      "customer",
+				Password:  "12345678",
+			}).
+			Return(&app.CreateUserOutput{DatabaseIdentifier: &database.DatabaseIdentifier{Id: id}}, nil)
+
+		response, err := deps.client.CreateUser(deps.ctx, &pb.CreateUserRequest{
+			FirstName: "Italo",
+			LastName:  "Servio",
+			Email:     "goo@gle.com",
+			Type:      "customer",
+			Password:  "12345678",
+		})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, id, response.GetId(), "should return the id")
+	})
+
+	t.Run("should answer invalid argument with the failed fields", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		_, err := deps.client.CreateUser(deps.ctx, &pb.CreateUserRequest{Email: "goo@gle.com"})
+
+		answered := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, answered.Code(), "should answer invalid argument")
+		assert.Equal(t, exception.CodeValidationFailed, rpc.ErrorCode(answered), "should answer the error code")
+
+		var fields []string
+		for _, detail := range answered.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				for _, violation := range badRequest.GetFieldViolations() {
+					fields = append(fields, violation.GetField())
+				}
+			}
+		}
+		assert.Contains(t, fields, "first_name", "should answer the failed fields by their proto name")
+	})
+
+	t.Run("should answer permission denied when the email is taken", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockCreateUserImpl.
+			EXPECT().
+			Do(gomock.Any(), gomock.Any()).
+			Return(nil, exception.New(exception.CodePermission))
+
+		_, err := deps.client.CreateUser(deps.ctx, &pb.CreateUserRequest{
+			FirstName: "Italo",
+			LastName:  "Servio",
+			Email:     "goo@gle.com",
+			Type:      "customer",
+			Password:  "12345678",
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), "should answer permission denied")
+		assert.Equal(t, exception.CodePermission, errorCode(err), "should answer the error code")
+	})
+}
+
+func TestUserServer_GetUserById(t *testing.T) {
+	t.Run("should return the user", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+		id := primitive.NewObjectID().Hex()
+
+		deps.mockGetUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), &app.GetUserByIdInput{Id: id, Deleted: true}).
+			Return(&app.GetUserByIdOutput{UserDatabaseNoPassword: mockUser(id)}, nil)
+
+		user, err := deps.client.GetUserById(deps.ctx, &pb.GetUserByIdRequest{Id: id, Deleted: true})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, id, user.GetId(), "should return the id")
+		assert.Equal(t, "goo@gle.com", user.GetEmail(), "should return the email")
+		assert.Equal(t, "apt 1", user.GetAddresses()[0].GetComplement(), "should return the addresses")
+		assert.NotNil(t, user.GetCreatedAt(), "should return the timestamps")
+		assert.Nil(t, user.GetDeletedAt(), "should not return a deletion time for users not deleted")
+	})
+
+	t.Run("should answer internal when the app service panics", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockGetUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *app.GetUserByIdInput) (*app.GetUserByIdOutput, error) {
+				panic("boom")
+			})
+
+		_, err := deps.client.GetUserById(deps.ctx, &pb.GetUserByIdRequest{Id: primitive.NewObjectID().Hex()})
+
+		assert.Equal(t, codes.Internal, status.Code(err), "should answer internal")
+		assert.Equal(t, exception.CodeInternal, errorCode(err), "should answer the error code")
+	})
+
+	t.Run("should answer not found", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockGetUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), gomock.Any()).
+			Return(nil, exception.New(exception.CodeNotFound))
+
+		_, err := deps.client.GetUserById(deps.ctx, &pb.GetUserByIdRequest{Id: "123"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err), "should answer not found")
+		assert.Equal(t, exception.CodeNotFound, errorCode(err), "should answer the error code")
+	})
+
+	t.Run("should answer the message in the requested language", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockGetUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), gomock.Any()).
+			Return(nil, exception.New(exception.CodeNotFound))
+
+		ctx := metadata.AppendToOutgoingContext(deps.ctx, rpc.MetadataAcceptLanguage, "pt-BR")
+		_, err := deps.client.GetUserById(ctx, &pb.GetUserByIdRequest{Id: "123"})
+
+		assert.Equal(t, "Entidade não encontrada", status.Convert(err).Message(), "should answer in Portuguese")
+	})
+}
+
+func TestUserServer_ListUsers(t *testing.T) {
+	t.Run("should return the page", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+		id := primitive.NewObjectID().Hex()
+		items := []app.GetUserPaginatedOutput{{UserDatabaseNoPassword: mockUser(id)}}
+
+		deps.mockGetUserPaginatedImpl.
+			EXPECT().
+			Do(gomock.Any(), &app.GetUserPaginatedInput{Page: 1, PerPage: 10, Ids: []string{id}}).
+			Return(database.NewPaginatedSlice(1, 10, &items), nil)
+
+		page, err := deps.client.ListUsers(deps.ctx, &pb.ListUsersRequest{Page: 1, PerPage: 10, Ids: []string{id}})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, int32(10), page.GetPerPage(), "should return the page size")
+		assert.Len(t, page.GetItems(), 1, "should return the items")
+		assert.Equal(t, id, page.GetItems()[0].GetId(), "should return the users")
+	})
+
+	t.Run("should answer invalid argument for a page over the limit", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		_, err := deps.client.ListUsers(deps.ctx, &pb.ListUsersRequest{Page: 1, PerPage: 101})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "should answer invalid argument")
+	})
+}
+
+func TestUserServer_UpdateUserById(t *testing.T) {
+	t.Run("should update the given fields", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+		id := primitive.NewObjectID().Hex()
+
+		deps.mockUpdateUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), id, &app.UpdateUserByIdInput{FirstName: "Italo2"}).
+			Return(&app.UpdateUserByIdOutput{UserDatabaseNoPassword: mockUser(id)}, nil)
+
+		user, err := deps.client.UpdateUserById(deps.ctx, &pb.UpdateUserByIdRequest{Id: id, FirstName: "Italo2"})
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, id, user.GetId(), "should return the user")
+	})
+}
+
+func TestUserServer_DeleteUserById(t *testing.T) {
+	t.Run("should delete the user", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockDeleteUserByIdImpl.EXPECT().Do(gomock.Any(), "123").Return(nil)
+
+		_, err := deps.client.DeleteUserById(deps.ctx, &pb.DeleteUserByIdRequest{Id: "123"})
+
+		assert.Nil(t, err, "should not return error")
+	})
+
+	t.Run("should answer internal for unexpected errors", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		deps.mockDeleteUserByIdImpl.EXPECT().Do(gomock.Any(), "123").Return(exception.New(exception.CodeDatabaseFailed))
+
+		_, err := deps.client.DeleteUserById(deps.ctx, &pb.DeleteUserByIdRequest{Id: "123"})
+
+		assert.Equal(t, codes.Internal, status.Code(err), "should answer internal")
+		assert.Equal(t, exception.CodeDatabaseFailed, errorCode(err), "should answer the error code")
+	})
+}
+
+func TestUserServer_APIKey(t *testing.T) {
+	t.Run("should refuse calls without the api key", func(t *testing.T) {
+		deps := BeforeEach_TestUserServer(t)
+
+		_, err := deps.client.GetUserById(context.TODO(), &pb.GetUserByIdRequest{Id: "123"})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), "should answer permission denied")
+	})
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/packages/validation"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+)
+
+const HeaderAdminKey = "X-Admin-Key"
+
+type AuthControllerImpl struct {
+	logger             logger.LoggerInterface
+	loginImpl          app.LoginInterface
+	unlockAccountImpl  app.UnlockAccountInterface
+	unlockUserByIdImpl app.UnlockUserByIdInterface
+}
+
+func NewAuthControllerImpl(
+	logger logger.LoggerInterface,
+	loginImpl app.LoginInterface,
+	unlockAccountImpl app.UnlockAccountInterface,
+	unlockUserByIdImpl app.UnlockUserByIdInterface,
+) *AuthControllerImpl {
+	return &AuthControllerImpl{
+		logger:             logger,
+		loginImpl:          loginImpl,
+		unlockAccountImpl:  unlockAccountImpl,
+		unlockUserByIdImpl: unlockUserByIdImpl,
+	}
+}
+
+func (ac *AuthControllerImpl) Login(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	body := &app.LoginInput{}
+
+	if err := c.BodyParser(&body); err != nil {
+		ac.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		ac.logger.WithCtx(ctx).Error(err.Error())
+		return err
+	}
+
+	output, err := ac.loginImpl.Do(ctx, &app.LoginInput{
+		Email:    body.Email,
+		Password: body.Password,
+		IP:       ratelimit.ClientIP(c),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(output)
+}
+
+func (ac *AuthControllerImpl) UnlockAccount(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	body := &app.UnlockAccountInput{}
+
+	if err := c.BodyParser(&body); err != nil {
+		ac.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		ac.logger.WithCtx(ctx).Error(err.Error())
+		return err
+	}
+
+	if err := ac.unlockAccountImpl.Do(ctx, body); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (ac *AuthControllerImpl) UnlockUserById(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id := c.Params("id")
+
+	if err := ac.unlockUserByIdImpl.Do(ctx, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// RequireAdminKey lets through requests carrying the admin key in the
+// X-Admin-Key header. With no admin key set, every request is refused.
+func RequireAdminKey(adminKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderAdminKey)
+		if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			return exception.New(exception.CodePermission)
+		}
+
+		return c.Next()
+	}
+}
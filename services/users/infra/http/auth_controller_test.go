@@ -0,0 +1,244 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/http"
+	"github.com/italoservio/braz_ecommerce/services/users/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const MOCK_ADMIN_KEY = "0123456789abcdef0123456789abcdef"
+
+type TestingDependencies_TestAuthController struct {
+	ctx                    context.Context
+	ctrl                   *gomock.Controller
+	mockLoginImpl          *mocks.MockLoginInterface
+	mockUnlockAccountImpl  *mocks.MockUnlockAccountInterface
+	mockUnlockUserByIdImpl *mocks.MockUnlockUserByIdInterface
+	authController         *http.AuthControllerImpl
+}
+
+func BeforeEach_TestAuthController(t *testing.T) *TestingDependencies_TestAuthController {
+	ctx := context.TODO()
+	ctrl := gomock.NewController(t)
+
+	mockLoggerImpl := mocks.NewMockLoggerInterface(ctrl)
+	mockLoginImpl := mocks.NewMockLoginInterface(ctrl)
+	mockUnlockAccountImpl := mocks.NewMockUnlockAccountInterface(ctrl)
+	mockUnlockUserByIdImpl := mocks.NewMockUnlockUserByIdInterface(ctrl)
+
+	mockLoggerImpl.
+		EXPECT().
+		WithCtx(gomock.Any()).
+		AnyTimes().
+		Return(&logger.Logger{})
+
+	authController := http.NewAuthControllerImpl(
+		mockLoggerImpl,
+		mockLoginImpl,
+		mockUnlockAccountImpl,
+		mockUnlockUserByIdImpl,
+	)
+
+	return &TestingDependencies_TestAuthController{
+		ctx:                    ctx,
+		ctrl:                   ctrl,
+		mockLoginImpl:          mockLoginImpl,
+		mockUnlockAccountImpl:  mockUnlockAccountImpl,
+		mockUnlockUserByIdImpl: mockUnlockUserByIdImpl,
+		authController:         authController,
+	}
+}
+
+func TestAuthController_Login(t *testing.T) {
+	deps := BeforeEach_TestAuthController(t)
+	defer deps.ctrl.Finish()
+
+	t.Run("should mount the http exception when the body is invalid", func(t *testing.T) {
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/api/v1/users/login", deps.authController.Login)
+
+		req := httptest.NewRequest("POST", "/api/v1/users/login", strings.NewReader(`{"email":"not an email"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		response, err := fbr.Test(req, -1)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		bytes, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		var httpResponse exception.HTTPException
+		json.Unmarshal(bytes, &httpResponse)
+
+		assert.Equal(t, 400, httpResponse.StatusCode, "should return expected status code")
+		assert.Equal(t, exception.CodeValidationFailed, httpResponse.ErrorCode, "should return expected error code")
+	})
+
+	t.Run("should pass the ip of the request to app", func(t *testing.T) {
+		deps.mockLoginImpl.
+			EXPECT().
+			Do(gomock.Any(), &app.LoginInput{Email: "goo@gle.com", Password: "12345678", IP: "0.0.0.0"}).
+			Times(1).
+			Return(&app.LoginOutput{DatabaseIdentifier: &database.DatabaseIdentifier{Id: "123"}}, nil)
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/api/v1/users/login", deps.authController.Login)
+
+		req := httptest.NewRequest(
+			"POST",
+			"/api/v1/users/login",
+			strings.NewReader(`{"email":"goo@gle.com","password":"12345678"}`),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		response, err := fbr.Test(req, -1)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		bytes, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		var output app.LoginOutput
+		json.Unmarshal(bytes, &output)
+
+		assert.Equal(t, 200, response.StatusCode, "should return expected status code")
+		assert.Equal(t, "123", output.Id, "should return the user id")
+	})
+
+	t.Run("should mount http exception when receiving an error from app", func(t *testing.T) {
+		deps.mockLoginImpl.
+			EXPECT().
+			Do(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil, exception.New(app.CodeAccountLocked))
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/api/v1/users/login", deps.authController.Login)
+
+		req := httptest.NewRequest(
+			"POST",
+			"/api/v1/users/login",
+			strings.NewReader(`{"email":"goo@gle.com","password":"12345678"}`),
+		)
+		req.Header.Set("Content-Type", "application/json")
+
+		response, err := fbr.Test(req, -1)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		assert.Equal(t, 423, response.StatusCode, "should return expected status code")
+	})
+}
+
+func TestAuthController_UnlockAccount(t *testing.T) {
+	deps := BeforeEach_TestAuthController(t)
+	defer deps.ctrl.Finish()
+
+	t.Run("should answer no content when the account is unlocked", func(t *testing.T) {
+		deps.mockUnlockAccountImpl.
+			EXPECT().
+			Do(gomock.Any(), &app.UnlockAccountInput{Token: "token"}).
+			Times(1).
+			Return(nil)
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/api/v1/users/unlock", deps.authController.UnlockAccount)
+
+		req := httptest.NewRequest("POST", "/api/v1/users/unlock", strings.NewReader(`{"token":"token"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		response, err := fbr.Test(req, -1)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		assert.Equal(t, 204, response.StatusCode, "should return expected status code")
+	})
+}
+
+func TestAuthController_UnlockUserById(t *testing.T) {
+	deps := BeforeEach_TestAuthController(t)
+	defer deps.ctrl.Finish()
+
+	t.Run("should mount http exception when receiving an error from app", func(t *testing.T) {
+		deps.mockUnlockUserByIdImpl.
+			EXPECT().
+			Do(gomock.Any(), "123").
+			Times(1).
+			Return(errors.New(exception.CodeNotFound))
+
+		fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+		fbr.Post("/api/v1/users/:id/unlock", deps.authController.UnlockUserById)
+
+		response, err := fbr.Test(httptest.NewRequest("POST", "/api/v1/users/123/unlock", nil), -1)
+		if err != nil {
+			t.Log(err.Error())
+			t.Fail()
+		}
+
+		assert.Equal(t, 404, response.StatusCode, "should return expected status code")
+	})
+}
+
+func TestAuthController_RequireAdminKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		adminKey string
+		header   string
+		status   int
+	}{
+		{name: "should refuse requests without the admin key", adminKey: MOCK_ADMIN_KEY, header: "", status: 403},
+		{name: "should refuse requests with a wrong admin key", adminKey: MOCK_ADMIN_KEY, header: "wrong", status: 403},
+		{name: "should refuse every request when no admin key is set", adminKey: "", header: "", status: 403},
+		{name: "should let through requests with the admin key", adminKey: MOCK_ADMIN_KEY, header: MOCK_ADMIN_KEY, status: 204},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+			fbr.Post("/", http.RequireAdminKey(tc.adminKey), func(c *fiber.Ctx) error {
+				return c.SendStatus(204)
+			})
+
+			req := httptest.NewRequest("POST", "/", nil)
+			if tc.header != "" {
+				req.Header.Set(http.HeaderAdminKey, tc.header)
+			}
+
+			response, err := fbr.Test(req, -1)
+			if err != nil {
+				t.Log(err.Error())
+				t.Fail()
+			}
+
+			assert.Equal(t, tc.status, response.StatusCode, "should return expected status code")
+		})
+	}
+}
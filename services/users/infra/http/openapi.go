@@ -0,0 +1,134 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/idempotency"
+	"github.com/italoservio/braz_ecommerce/packages/openapi"
+	"github.com/italoservio/braz_ecommerce/packages/ratelimit"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+)
+
+const (
+	RouteCreateUser       = "users.create"
+	RouteLogin            = "users.login"
+	RouteUnlockAccount    = "users.unlock"
+	RouteGetUserPaginated = "users.list"
+	RouteGetUserById      = "users.get"
+	RouteDeleteUserById   = "users.delete"
+	RouteUpdateUserById   = "users.update"
+	RouteUnlockUserById   = "users.unlockById"
+	RouteErrorsCatalog    = "errors.catalog"
+)
+
+// IdempotencyHeaders documents the header read by the idempotency middleware.
+type IdempotencyHeaders struct {
+	IdempotencyKey string `reqHeader:"Idempotency-Key" validate:"omitempty,max=255"`
+}
+
+// AdminHeaders documents the header read by RequireAdminKey.
+type AdminHeaders struct {
+	AdminKey string `reqHeader:"X-Admin-Key" validate:"required" secret:"true"`
+}
+
+// withCommonErrors adds the errors every /api route may answer, reaching the
+// database under the repository timeouts.
+func withCommonErrors(codes ...string) []string {
+	return append(
+		codes,
+		ratelimit.CodeRateLimited,
+		exception.CodeDatabaseFailed,
+		exception.CodeTimeout,
+		exception.CodeInternal,
+	)
+}
+
+// Routes describes the routes of the service by route name, for the OpenAPI
+// document.
+func Routes() map[string]openapi.Route {
+	return map[string]openapi.Route{
+		RouteCreateUser: {
+			Summary:  "Create a user",
+			Tags:     []string{"users"},
+			Headers:  IdempotencyHeaders{},
+			Body:     app.CreateUserInput{},
+			Response: app.CreateUserOutput{},
+			Status:   http.StatusCreated,
+			Errors: withCommonErrors(
+				exception.CodeValidationFailed,
+				exception.CodePermission,
+				idempotency.CodeInvalidKey,
+				idempotency.CodeKeyReused,
+				idempotency.CodeRequestInFlight,
+			),
+		},
+		RouteLogin: {
+			Summary:  "Log a user in",
+			Tags:     []string{"auth"},
+			Body:     app.LoginInput{},
+			Response: app.LoginOutput{},
+			Errors: withCommonErrors(
+				exception.CodeValidationFailed,
+				app.CodeInvalidCredentials,
+				app.CodeAccountLocked,
+				app.CodeLoginThrottled,
+			),
+		},
+		RouteUnlockAccount: {
+			Summary: "Unlock an account with the token of the unlock email",
+			Tags:    []string{"auth"},
+			Body:    app.UnlockAccountInput{},
+			Status:  http.StatusNoContent,
+			Errors:  withCommonErrors(exception.CodeValidationFailed, exception.CodeNotFound),
+		},
+		RouteGetUserPaginated: {
+			Summary:  "List users",
+			Tags:     []string{"users"},
+			Query:    app.GetUserPaginatedInput{},
+			Response: database.PaginatedSlice[app.GetUserPaginatedOutput]{},
+			Errors:   withCommonErrors(exception.CodeValidationFailed),
+		},
+		RouteGetUserById: {
+			Summary:  "Get a user",
+			Tags:     []string{"users"},
+			Query:    GetUserByIdPayload{},
+			Response: app.GetUserByIdOutput{},
+			Errors:   withCommonErrors(exception.CodeValidationFailed, exception.CodeNotFound),
+		},
+		RouteDeleteUserById: {
+			Summary: "Delete a user, answering unknown ids alike",
+			Tags:    []string{"users"},
+			Status:  http.StatusNoContent,
+			Errors:  withCommonErrors(exception.CodeValidationFailed),
+		},
+		RouteUpdateUserById: {
+			Summary:  "Update a user",
+			Tags:     []string{"users"},
+			Body:     app.UpdateUserByIdInput{},
+			Response: app.UpdateUserByIdOutput{},
+			Errors: withCommonErrors(
+				exception.CodeValidationFailed,
+				exception.CodeNotFound,
+				exception.CodePermission,
+			),
+		},
+		RouteUnlockUserById: {
+			Summary: "Unlock the account of a user, as an admin",
+			Tags:    []string{"auth"},
+			Headers: AdminHeaders{},
+			Status:  http.StatusNoContent,
+			Errors: withCommonErrors(
+				exception.CodeValidationFailed,
+				exception.CodePermission,
+				exception.CodeNotFound,
+			),
+		},
+		RouteErrorsCatalog: {
+			Summary:  "List the error codes",
+			Tags:     []string{"errors"},
+			Response: exception.Catalog{},
+		},
+	}
+}
@@ -1,8 +1,6 @@
 package http
 
 import (
-	"errors"
-
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -44,21 +42,21 @@ type CreateUserPayload struct {
 	LastName  string `json:"last_name" validate:"required,min=5,max=20"`
 	Email     string `json:"email" validate:"required,min=5,max=20"`
 	Type      string `json:"type" validate:"required,min=5,max=20"`
-	Password  string `json:"password" validate:"required,min=5,max=20"`
+	Password  string `json:"password" validate:"required,min=5,max=20" secret:"true"`
 }
 
 func (uc *UserControllerImpl) CreateUser(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	body := &app.CreateUserInput{}
 
 	if err := c.BodyParser(&body); err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
 	if err := validation.ValidateRequest(c, body); err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return err
 	}
 
 	output, err := uc.createUserImpl.Do(ctx, &app.CreateUserInput{
@@ -77,12 +75,17 @@ func (uc *UserControllerImpl) CreateUser(c *fiber.Ctx) error {
 }
 
 func (uc *UserControllerImpl) UpdateUserById(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	body := &app.UpdateUserByIdInput{}
 
 	if err := c.BodyParser(&body); err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	if err := validation.ValidateRequest(c, body); err != nil {
+		uc.logger.WithCtx(ctx).Error(err.Error())
+		return err
 	}
 
 	id := c.Params("id")
@@ -107,7 +110,7 @@ type GetUserByIdPayload struct {
 }
 
 func (uc *UserControllerImpl) GetUserById(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	id := c.Params("id")
 	queryParams := GetUserByIdPayload{}
 
@@ -115,7 +118,7 @@ func (uc *UserControllerImpl) GetUserById(c *fiber.Ctx) error {
 
 	if err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
 	user, err := uc.getUserByIdImpl.Do(ctx, &app.GetUserByIdInput{
@@ -130,7 +133,7 @@ func (uc *UserControllerImpl) GetUserById(c *fiber.Ctx) error {
 }
 
 func (uc *UserControllerImpl) DeleteUserById(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	id := c.Params("id")
 
 	err := uc.deleteUserByIdImpl.Do(ctx, id)
@@ -142,36 +145,22 @@ func (uc *UserControllerImpl) DeleteUserById(c *fiber.Ctx) error {
 	return c.SendStatus(http.StatusNoContent)
 }
 
-type GetUserPaginatedPayload struct {
-	Page    int      `query:"page" validate:"required,number,gt=0"`
-	PerPage int      `query:"per_page" validate:"required,number,gt=0,lte=100"`
-	Emails  []string `query:"email" validate:"omitempty,dive,email"`
-	Ids     []string `query:"id" validate:"omitempty,dive,mongodb"`
-	Deleted bool     `query:"deleted"`
-}
-
 func (uc *UserControllerImpl) GetUserPaginated(c *fiber.Ctx) error {
-	ctx := c.Context()
-	queryParams := GetUserPaginatedPayload{}
+	ctx := c.UserContext()
+	queryParams := app.GetUserPaginatedInput{}
 
 	err := c.QueryParser(&queryParams)
 	if err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return exception.Wrap(exception.CodeValidationFailed, err)
 	}
 
 	if err := validation.ValidateRequest(c, queryParams); err != nil {
 		uc.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeValidationFailed)
+		return err
 	}
 
-	output, err := uc.getUserPaginatedImpl.Do(ctx, &app.GetUserPaginatedInput{
-		Page:    queryParams.Page,
-		PerPage: queryParams.PerPage,
-		Emails:  queryParams.Emails,
-		Ids:     queryParams.Ids,
-		Deleted: queryParams.Deleted,
-	})
+	output, err := uc.getUserPaginatedImpl.Do(ctx, &queryParams)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/packages/metrics"
+	"github.com/italoservio/braz_ecommerce/services/users/app"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/http"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func BeforeEach_TestUserControllerInMemory(t *testing.T) *fiber.App {
+	keyring, err := encryption.NewKeyring("v1", map[string]string{"v1": "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loggerImpl := logger.NewLogger()
+	encryptionImpl := encryption.NewEncryptionImpl(loggerImpl, keyring)
+	fieldEncryptionImpl, err := encryption.NewFieldEncryptionImpl(encryptionImpl, "h7Jd9sLq2WnX4vBz8Rt6Yp3Kc5Mf1Ga0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memoryDatabase := database.NewMemoryDatabase()
+	crudRepository := database.NewMemoryCrudRepository(loggerImpl, memoryDatabase)
+	userRepository := storage.NewUserMemoryRepositoryImpl(loggerImpl, memoryDatabase)
+	usersMetrics := app.NewUsersMetrics(metrics.New())
+
+	userController := http.NewUserControllerImpl(
+		loggerImpl,
+		app.NewGetUserByIdImpl(fieldEncryptionImpl, crudRepository, userRepository),
+		app.NewDeleteUserByIdImpl(crudRepository, userRepository, usersMetrics),
+		app.NewCreateUserImpl(encryptionImpl, fieldEncryptionImpl, crudRepository, userRepository, memoryDatabase, usersMetrics),
+		app.NewGetUserPaginatedImpl(fieldEncryptionImpl, crudRepository),
+		app.NewUpdateUserByIdImpl(encryptionImpl, fieldEncryptionImpl, crudRepository, userRepository),
+	)
+
+	fbr := fiber.New(fiber.Config{ErrorHandler: exception.HttpExceptionHandler})
+	fbr.Post("/api/v1/users", userController.CreateUser)
+	fbr.Get("/api/v1/users", userController.GetUserPaginated)
+	fbr.Get("/api/v1/users/:id", userController.GetUserById)
+	fbr.Delete("/api/v1/users/:id", userController.DeleteUserById)
+	fbr.Patch("/api/v1/users/:id", userController.UpdateUserById)
+
+	return fbr
+}
+
+func requestInMemory(t *testing.T, fbr *fiber.App, method string, url string, body string) (int, []byte) {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := fbr.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response.StatusCode, bytes
+}
+
+func TestUserController_InMemory(t *testing.T) {
+	t.Run("should create, read, list, update and delete a user without database", func(t *testing.T) {
+		fbr := BeforeEach_TestUserControllerInMemory(t)
+		payload := `{
+			"first_name": "Italo",
+			"last_name": "Servio",
+			"email": "goo@gle.com",
+			"type": "customer",
+			"password": "secret"
+		}`
+
+		status, bytes := requestInMemory(t, fbr, "POST", "/api/v1/users", payload)
+		assert.Equal(t, 201, status, "should create the user")
+
+		var created app.CreateUserOutput
+		json.Unmarshal(bytes, &created)
+
+		status, _ = requestInMemory(t, fbr, "POST", "/api/v1/users", payload)
+		assert.Equal(t, 403, status, "should not create a user with the same email")
+
+		status, bytes = requestInMemory(t, fbr, "GET", fmt.Sprintf("/api/v1/users/%s", created.Id), "")
+		assert.Equal(t, 200, status, "should find the user")
+
+		var user map[string]any
+		json.Unmarshal(bytes, &user)
+		assert.Equal(t, "goo@gle.com", user["email"], "should return the created user")
+
+		status, bytes = requestInMemory(t, fbr, "GET", "/api/v1/users?page=1&per_page=10", "")
+		assert.Equal(t, 200, status, "should list the users")
+
+		var page map[string]any
+		json.Unmarshal(bytes, &page)
+		items := page["items"].([]any)
+		assert.Equal(t, 1, len(items), "should list the created user")
+		assert.NotContains(t, items[0], "password", "should not list the password")
+		assert.NotContains(t, items[0], "email_index", "should not list the email index")
+		assert.Equal(t, "Italo", items[0].(map[string]any)["first_name"], "should list the decrypted user")
+
+		status, bytes = requestInMemory(t, fbr, "GET", "/api/v1/users?page=1&per_page=10&email=GOO@gle.com", "")
+		assert.Equal(t, 200, status, "should list the users by email")
+
+		json.Unmarshal(bytes, &page)
+		assert.Equal(t, 1, len(page["items"].([]any)), "should find the user by its email index")
+
+		status, bytes = requestInMemory(t, fbr, "PATCH", fmt.Sprintf("/api/v1/users/%s", created.Id), `{"first_name": "Joao"}`)
+		assert.Equal(t, 200, status, "should update the user")
+
+		json.Unmarshal(bytes, &user)
+		assert.Equal(t, "Joao", user["first_name"], "should return the updated user")
+
+		status, _ = requestInMemory(t, fbr, "DELETE", fmt.Sprintf("/api/v1/users/%s", created.Id), "")
+		assert.Equal(t, 204, status, "should delete the user")
+
+		status, _ = requestInMemory(t, fbr, "GET", fmt.Sprintf("/api/v1/users/%s", created.Id), "")
+		assert.Equal(t, 404, status, "should not find the deleted user")
+
+		status, _ = requestInMemory(t, fbr, "GET", fmt.Sprintf("/api/v1/users/%s?deleted=true", created.Id), "")
+		assert.Equal(t, 200, status, "should find the deleted user when requested")
+	})
+}
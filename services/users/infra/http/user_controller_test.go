@@ -294,6 +294,12 @@ func TestUserController_CreateUser(t *testing.T) {
 
 		assert.Equal(t, 400, httpResponse.StatusCode, "should return expected status code")
 		assert.Equal(t, "Invalid input for one or more required attributes", httpResponse.ErrorMessage, "should return expected error message")
+		assert.Contains(t, httpResponse.Details, exception.FieldError{
+			Field:   "email",
+			Tag:     "required",
+			Message: "email is required",
+			Value:   "",
+		}, "should detail the failed fields by their json name")
 	})
 
 	t.Run("should mount http exception when receiving an error from app", func(t *testing.T) {
@@ -434,6 +440,7 @@ func TestUserController_GetUserPaginated(t *testing.T) {
 
 		assert.Equal(t, 400, httpResponse.StatusCode, "should return expected status code")
 		assert.Equal(t, "Invalid input for one or more required attributes", httpResponse.ErrorMessage, "should return expected error message")
+		assert.Equal(t, "page", httpResponse.Details[0].Field, "should detail the failed fields by their query name")
 	})
 
 	t.Run("should mount http exception when receiving an error from app", func(t *testing.T) {
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+)
+
+// LoginAttemptsMemoryStoreImpl keeps the attempts in the process, for single
+// instance deployments and tests.
+type LoginAttemptsMemoryStoreImpl struct {
+	mutex    sync.Mutex
+	attempts map[string]domain.LoginAttempts
+}
+
+func NewLoginAttemptsMemoryStoreImpl() *LoginAttemptsMemoryStoreImpl {
+	return &LoginAttemptsMemoryStoreImpl{attempts: map[string]domain.LoginAttempts{}}
+}
+
+func (ls *LoginAttemptsMemoryStoreImpl) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	attempts, ok := ls.attempts[key]
+	if !ok || !attempts.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+
+	return &attempts, nil
+}
+
+func (ls *LoginAttemptsMemoryStoreImpl) Fail(
+	ctx context.Context,
+	key string,
+	now time.Time,
+	window time.Duration,
+) (*domain.LoginAttempts, error) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	expiresAt := now.Add(window)
+
+	attempts, ok := ls.attempts[key]
+	if !ok || !attempts.ExpiresAt.After(now) {
+		attempts = domain.LoginAttempts{Key: key}
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+	if expiresAt.After(attempts.ExpiresAt) {
+		attempts.ExpiresAt = expiresAt
+	}
+
+	ls.attempts[key] = attempts
+
+	return &attempts, nil
+}
+
+func (ls *LoginAttemptsMemoryStoreImpl) Lock(ctx context.Context, key string, until time.Time, unlockToken string) error {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	attempts, ok := ls.attempts[key]
+	if !ok {
+		return nil
+	}
+
+	attempts.LockedUntil = &until
+	attempts.UnlockToken = unlockToken
+	attempts.ExpiresAt = until
+	ls.attempts[key] = attempts
+
+	return nil
+}
+
+func (ls *LoginAttemptsMemoryStoreImpl) GetByUnlockToken(ctx context.Context, unlockToken string) (*domain.LoginAttempts, error) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	now := time.Now()
+	for _, attempts := range ls.attempts {
+		if attempts.UnlockToken == unlockToken && attempts.Locked(now) {
+			return &attempts, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (ls *LoginAttemptsMemoryStoreImpl) Reset(ctx context.Context, key string) error {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	delete(ls.attempts, key)
+
+	return nil
+}
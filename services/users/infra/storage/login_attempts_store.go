@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptsStoreInterface interface {
+	// Get returns nil when the key has no failures left to count.
+	Get(ctx context.Context, key string) (*domain.LoginAttempts, error)
+	// Fail counts a failure of the key atomically, starting over when the
+	// previous failures expired, and keeps them for window.
+	Fail(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.LoginAttempts, error)
+	Lock(ctx context.Context, key string, until time.Time, unlockToken string) error
+	// GetByUnlockToken returns nil when no account is locked by the token.
+	GetByUnlockToken(ctx context.Context, unlockToken string) (*domain.LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginAttemptsStoreImpl keeps the attempts in the login_attempts collection,
+// where the TTL index on expires_at removes the expired ones.
+type LoginAttemptsStoreImpl struct {
+	logger   logger.LoggerInterface
+	database *database.Database
+}
+
+func NewLoginAttemptsStoreImpl(lg logger.LoggerInterface, db *database.Database) *LoginAttemptsStoreImpl {
+	return &LoginAttemptsStoreImpl{logger: lg, database: db}
+}
+
+func (ls *LoginAttemptsStoreImpl) collection() *mongo.Collection {
+	return ls.database.Collection(database.LoginAttemptsCollection)
+}
+
+// EnsureIndexes creates the TTL index and the index of the unlock tokens.
+func (ls *LoginAttemptsStoreImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := ls.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "unlock_token", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+
+	return err
+}
+
+func (ls *LoginAttemptsStoreImpl) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	return ls.findOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}})
+}
+
+func (ls *LoginAttemptsStoreImpl) Fail(
+	ctx context.Context,
+	key string,
+	now time.Time,
+	window time.Duration,
+) (*domain.LoginAttempts, error) {
+	expiresAt := now.Add(window)
+	alive := bson.M{"$gt": bson.A{"$expires_at", now}}
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"failures":     bson.M{"$cond": bson.A{alive, bson.M{"$add": bson.A{"$failures", 1}}, 1}},
+		"last_failure": now,
+		"expires_at":   bson.M{"$cond": bson.A{alive, bson.M{"$max": bson.A{"$expires_at", expiresAt}}, expiresAt}},
+		"locked_until": bson.M{"$cond": bson.A{alive, "$locked_until", "$$REMOVE"}},
+		"unlock_token": bson.M{"$cond": bson.A{alive, "$unlock_token", "$$REMOVE"}},
+	}}}}
+
+	var attempts domain.LoginAttempts
+
+	err := ls.collection().FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempts)
+	if err != nil {
+		ls.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	return &attempts, nil
+}
+
+func (ls *LoginAttemptsStoreImpl) Lock(ctx context.Context, key string, until time.Time, unlockToken string) error {
+	_, err := ls.collection().UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"locked_until": until,
+		"unlock_token": unlockToken,
+		"expires_at":   until,
+	}})
+	if err != nil {
+		ls.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	return nil
+}
+
+func (ls *LoginAttemptsStoreImpl) GetByUnlockToken(ctx context.Context, unlockToken string) (*domain.LoginAttempts, error) {
+	return ls.findOne(ctx, bson.M{"unlock_token": unlockToken, "locked_until": bson.M{"$gt": time.Now()}})
+}
+
+func (ls *LoginAttemptsStoreImpl) Reset(ctx context.Context, key string) error {
+	_, err := ls.collection().DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		ls.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	return nil
+}
+
+func (ls *LoginAttemptsStoreImpl) findOne(ctx context.Context, filter bson.M) (*domain.LoginAttempts, error) {
+	var attempts domain.LoginAttempts
+
+	err := ls.collection().FindOne(ctx, filter).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		ls.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	return &attempts, nil
+}
@@ -0,0 +1,110 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const MOCK_ATTEMPTS_NS = "foo.login_attempts"
+
+func BeforeEach_TestLoginAttemptsStore(mt *mtest.T) *storage.LoginAttemptsStoreImpl {
+	mockDB := &database.Database{Database: mt.Client.Database(MOCK_DB_NAME)}
+	return storage.NewLoginAttemptsStoreImpl(logger.NewLogger(), mockDB)
+}
+
+func TestLoginAttemptsStore(t *testing.T) {
+	ctx := context.TODO()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return nil when the key has no failures", func(nestedMt *mtest.T) {
+		store := BeforeEach_TestLoginAttemptsStore(nestedMt)
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(0, MOCK_ATTEMPTS_NS, mtest.FirstBatch))
+
+		attempts, err := store.Get(ctx, "ip:10.0.0.1")
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Nil(t, attempts, "should not return attempts")
+		assert.NotNil(t, filter.Lookup("expires_at", "$gt"), "should skip expired attempts")
+	})
+
+	rootMt.Run("should count a failure upserting the key", func(nestedMt *mtest.T) {
+		store := BeforeEach_TestLoginAttemptsStore(nestedMt)
+		nestedMt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{{Key: "_id", Value: "ip:10.0.0.1"}, {Key: "failures", Value: 3}}},
+		})
+
+		attempts, err := store.Fail(ctx, "ip:10.0.0.1", time.Now(), time.Minute)
+
+		command := nestedMt.GetStartedEvent().Command
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, 3, attempts.Failures, "should return the counted failures")
+		assert.True(t, command.Lookup("upsert").Boolean(), "should upsert the key")
+		assert.True(t, command.Lookup("new").Boolean(), "should return the attempts after the update")
+	})
+
+	rootMt.Run("should look locked accounts up by the unlock token", func(nestedMt *mtest.T) {
+		store := BeforeEach_TestLoginAttemptsStore(nestedMt)
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(1, MOCK_ATTEMPTS_NS, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "account:a1b2c3"},
+			{Key: "unlock_token", Value: "9f86d0"},
+		}))
+
+		attempts, err := store.GetByUnlockToken(ctx, "9f86d0")
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "account:a1b2c3", attempts.Key, "should return the locked account")
+		assert.NotNil(t, filter.Lookup("locked_until", "$gt"), "should skip expired locks")
+	})
+
+	rootMt.Run("should create the indexes", func(nestedMt *mtest.T) {
+		store := BeforeEach_TestLoginAttemptsStore(nestedMt)
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := store.EnsureIndexes(ctx)
+
+		indexes := nestedMt.GetStartedEvent().Command.Lookup("indexes").Array()
+		values, _ := indexes.Values()
+		assert.Nil(t, err, "should not return error")
+		assert.Len(t, values, 2, "should create the ttl and the unlock token indexes")
+	})
+}
+
+func TestLoginAttemptsMemoryStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should count failures and start over once they expire", func(t *testing.T) {
+		store := storage.NewLoginAttemptsMemoryStoreImpl()
+		now := time.Now()
+
+		store.Fail(ctx, "ip:10.0.0.1", now, time.Minute)
+		attempts, _ := store.Fail(ctx, "ip:10.0.0.1", now, time.Minute)
+		assert.Equal(t, 2, attempts.Failures, "should count the failures")
+
+		attempts, _ = store.Fail(ctx, "ip:10.0.0.1", now.Add(2*time.Minute), time.Minute)
+		assert.Equal(t, 1, attempts.Failures, "should start over")
+	})
+
+	t.Run("should find the lock by its token until it is reset", func(t *testing.T) {
+		store := storage.NewLoginAttemptsMemoryStoreImpl()
+		store.Fail(ctx, "account:a1b2c3", time.Now(), time.Minute)
+		store.Lock(ctx, "account:a1b2c3", time.Now().Add(time.Minute), "9f86d0")
+
+		attempts, _ := store.GetByUnlockToken(ctx, "9f86d0")
+		assert.Equal(t, "account:a1b2c3", attempts.Key, "should return the locked account")
+
+		store.Reset(ctx, "account:a1b2c3")
+		attempts, _ = store.GetByUnlockToken(ctx, "9f86d0")
+		assert.Nil(t, attempts, "should not return reset accounts")
+	})
+}
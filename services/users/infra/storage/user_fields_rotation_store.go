@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fieldsRecord struct {
+	Id                    primitive.ObjectID `bson:"_id"`
+	domain.User           `bson:",inline"`
+	domain.UserEmailIndex `bson:",inline"`
+	UpdatedAt             time.Time `bson:"updated_at"`
+}
+
+// UserFieldsRotationStoreImpl lets the encryption rotation job migrate the
+// personal fields of the users to the primary key, recomputing the blind
+// index of their email along. Users stored before field encryption, still in
+// plaintext and not indexed, are encrypted and indexed the same way.
+type UserFieldsRotationStoreImpl struct {
+	logger   logger.LoggerInterface
+	database *database.Database
+	fields   encryption.FieldEncryptionInterface
+}
+
+func NewUserFieldsRotationStoreImpl(
+	lg logger.LoggerInterface,
+	db *database.Database,
+	fe encryption.FieldEncryptionInterface,
+) *UserFieldsRotationStoreImpl {
+	return &UserFieldsRotationStoreImpl{logger: lg, database: db, fields: fe}
+}
+
+func (us *UserFieldsRotationStoreImpl) FindStale(
+	ctx context.Context,
+	keyId string,
+	after string,
+	limit int,
+) ([]string, error) {
+	stale := bson.M{
+		"$nin": bson.A{"", nil},
+		"$not": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(keyId) + ":"},
+	}
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"first_name": stale},
+			bson.M{"last_name": stale},
+			bson.M{"email": stale},
+			bson.M{"email_index": bson.M{"$exists": false}},
+			bson.M{"addresses": bson.M{"$elemMatch": bson.M{"$or": bson.A{
+				bson.M{"cep": stale},
+				bson.M{"street": stale},
+				bson.M{"neighborhood": stale},
+				bson.M{"number": stale},
+				bson.M{"complement": stale},
+			}}}},
+		},
+	}
+
+	if after != "" {
+		afterId, err := primitive.ObjectIDFromHex(after)
+		if err != nil {
+			us.logger.WithCtx(ctx).Error(err.Error())
+			return nil, exception.Wrap(exception.CodeValidationFailed, err)
+		}
+
+		filter["_id"] = bson.M{"$gt": afterId}
+	}
+
+	cursor, err := us.database.Collection(database.UsersCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": 1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	var records []database.DatabaseIdentifier
+	if err := cursor.All(ctx, &records); err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.Id)
+	}
+
+	return ids, nil
+}
+
+// Rotate decrypts the fields with any key of the keyring, or takes them as
+// they are when they were never encrypted, and writes them back encrypted
+// with the primary key. The user is only replaced when its updated_at did not
+// change since it was read.
+func (us *UserFieldsRotationStoreImpl) Rotate(ctx context.Context, id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	coll := us.database.Collection(database.UsersCollection)
+
+	var record fieldsRecord
+	err = coll.FindOne(ctx, bson.M{"_id": objectId}, options.FindOne().SetProjection(bson.M{
+		"first_name":  1,
+		"last_name":   1,
+		"email":       1,
+		"addresses":   1,
+		"email_index": 1,
+		"updated_at":  1,
+	})).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return exception.Wrap(exception.CodeNotFound, err)
+		}
+
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	fields := record.PersonalFields()
+	for _, field := range fields {
+		if *field, err = us.fields.DecryptField(ctx, *field); err != nil {
+			return err
+		}
+	}
+
+	emailIndex := us.fields.BlindIndex(record.Email)
+
+	for _, field := range fields {
+		if *field, err = us.fields.EncryptField(ctx, *field); err != nil {
+			return err
+		}
+	}
+
+	filter := bson.M{"_id": objectId, "updated_at": record.UpdatedAt}
+	if record.UpdatedAt.IsZero() {
+		filter["updated_at"] = bson.M{"$exists": false}
+	}
+
+	result, err := coll.UpdateOne(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"first_name":  record.FirstName,
+			"last_name":   record.LastName,
+			"email":       record.Email,
+			"addresses":   record.Addresses,
+			"email_index": emailIndex,
+		}},
+	)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return exception.New(exception.CodeNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package storage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func BeforeEach_TestUserFieldsRotationStore(t *testing.T, primary string) *encryption.FieldEncryptionImpl {
+	keyring, err := encryption.NewKeyring(primary, map[string]string{
+		"v1": "2zmXvZa93wneR1w1L63i9cAUzSIzPdd6",
+		"v2": "9aQwErTyUiOpAsDfGhJkLzXcVbNm1234",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldEncryption, err := encryption.NewFieldEncryptionImpl(
+		encryption.NewEncryptionImpl(logger.NewLogger(), keyring),
+		"h7Jd9sLq2WnX4vBz8Rt6Yp3Kc5Mf1Ga0",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fieldEncryption
+}
+
+func TestUserFieldsRotationStore_FindStale(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should find the users holding fields not encrypted with the key", func(nestedMt *mtest.T) {
+		mockId := primitive.NewObjectID()
+		afterId := primitive.NewObjectID()
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: mockId}},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, BeforeEach_TestUserFieldsRotationStore(t, "v2"))
+
+		ids, err := store.FindStale(ctx, "v2", afterId.Hex(), 10)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, []string{mockId.Hex()}, ids)
+
+		command := nestedMt.GetStartedEvent().Command
+		filter := command.Lookup("filter").Document()
+		assert.Equal(t, afterId, filter.Lookup("_id", "$gt").ObjectID(), "should start after the given id")
+
+		conditions, _ := filter.Lookup("$or").Array().Values()
+		assert.Len(t, conditions, 5, "should check the names, the email, its index and the addresses")
+		pattern, _ := conditions[2].Document().Lookup("email", "$not").Regex()
+		assert.Equal(t, "^v2:", pattern, "should skip the fields of the key")
+		assert.Equal(t, int64(10), command.Lookup("limit").AsInt64())
+	})
+
+	rootMt.Run("should return error when database fails", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "failed"}))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, BeforeEach_TestUserFieldsRotationStore(t, "v2"))
+
+		_, err := store.FindStale(ctx, "v2", "", 10)
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error())
+	})
+}
+
+func TestUserFieldsRotationStore_Rotate(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should re-encrypt the fields and the email index with the primary key", func(nestedMt *mtest.T) {
+		previous := BeforeEach_TestUserFieldsRotationStore(t, "v1")
+		current := BeforeEach_TestUserFieldsRotationStore(t, "v2")
+
+		firstName, _ := previous.EncryptField(ctx, "Italo")
+		street, _ := previous.EncryptField(ctx, "Rua A")
+		updatedAt := time.Now().Truncate(time.Millisecond).UTC()
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "first_name", Value: firstName},
+				{Key: "last_name", Value: "Servio"},
+				{Key: "email", Value: "Goo@gle.com"},
+				{Key: "addresses", Value: bson.A{bson.D{
+					{Key: "street", Value: street},
+					{Key: "state", Value: "SP"},
+				}}},
+				{Key: "updated_at", Value: updatedAt},
+			},
+		))
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, current)
+
+		err := store.Rotate(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err, "should not return error")
+
+		nestedMt.GetStartedEvent()
+		update := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, updatedAt, update.Lookup("q", "updated_at").Time().UTC(), "should match the read user")
+
+		set := update.Lookup("u", "$set").Document()
+		for field, expected := range map[string]string{
+			"first_name": "Italo",
+			"last_name":  "Servio",
+			"email":      "Goo@gle.com",
+		} {
+			encrypted := set.Lookup(field).StringValue()
+			assert.True(t, strings.HasPrefix(encrypted, "v2:"), "should encrypt with the primary key")
+
+			decrypted, _ := current.DecryptField(ctx, encrypted)
+			assert.Equal(t, expected, decrypted, "should keep the content")
+		}
+
+		address := set.Lookup("addresses").Array().Index(0).Value().Document()
+		assert.True(t, strings.HasPrefix(address.Lookup("street").StringValue(), "v2:"), "should encrypt the addresses")
+		assert.Equal(t, "SP", address.Lookup("state").StringValue(), "should keep the state in plaintext")
+		assert.Equal(t, current.BlindIndex("goo@gle.com"), set.Lookup("email_index").StringValue(), "should index the email")
+	})
+
+	rootMt.Run("should encrypt and index the users stored in plaintext", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "first_name", Value: "Italo"},
+				{Key: "last_name", Value: "Servio"},
+				{Key: "email", Value: "goo@gle.com"},
+			},
+		))
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		defer nestedMt.ClearMockResponses()
+
+		fieldEncryption := BeforeEach_TestUserFieldsRotationStore(t, "v1")
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, fieldEncryption)
+
+		err := store.Rotate(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err, "should not return error")
+
+		nestedMt.GetStartedEvent()
+		update := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.False(t, update.Lookup("q", "updated_at", "$exists").Boolean(), "should match the users without updated_at")
+
+		set := update.Lookup("u", "$set").Document()
+		assert.NotContains(t, set.Lookup("email").StringValue(), "goo@gle.com", "should encrypt the email")
+		assert.Equal(t, fieldEncryption.BlindIndex("goo@gle.com"), set.Lookup("email_index").StringValue(), "should index the email")
+	})
+
+	rootMt.Run("should return not found when the user changed meanwhile", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "email", Value: "goo@gle.com"}},
+		))
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, BeforeEach_TestUserFieldsRotationStore(t, "v2"))
+
+		err := store.Rotate(ctx, primitive.NewObjectID().Hex())
+
+		assert.Equal(t, exception.CodeNotFound, err.Error())
+	})
+
+	rootMt.Run("should return validation error when id is invalid", func(nestedMt *mtest.T) {
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserFieldsRotationStoreImpl(logger, mockDB, BeforeEach_TestUserFieldsRotationStore(t, "v2"))
+
+		err := store.Rotate(ctx, "123")
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error())
+	})
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+)
+
+type UserMemoryRepositoryImpl struct {
+	logger   logger.LoggerInterface
+	database *database.MemoryDatabase
+}
+
+func NewUserMemoryRepositoryImpl(lg logger.LoggerInterface, db *database.MemoryDatabase) *UserMemoryRepositoryImpl {
+	return &UserMemoryRepositoryImpl{logger: lg, database: db}
+}
+
+// EnsureIndexes declares the unique index of the email blind index, the one
+// UserRepositoryImpl.EnsureIndexes creates in MongoDB.
+func (ur *UserMemoryRepositoryImpl) EnsureIndexes(ctx context.Context) error {
+	ur.database.EnsureUnique(database.UsersCollection, "email_index")
+	return nil
+}
+
+func (ur *UserMemoryRepositoryImpl) GetByEmail(
+	ctx context.Context,
+	collection string,
+	emailIndex string,
+	structure *domain.UserDatabaseNoPassword,
+) error {
+	return ur.getByEmail(ctx, collection, emailIndex, structure)
+}
+
+func (ur *UserMemoryRepositoryImpl) GetCredentialsByEmail(
+	ctx context.Context,
+	collection string,
+	emailIndex string,
+	structure *domain.UserDatabase,
+) error {
+	return ur.getByEmail(ctx, collection, emailIndex, structure)
+}
+
+func (ur *UserMemoryRepositoryImpl) getByEmail(
+	ctx context.Context,
+	collection string,
+	emailIndex string,
+	structure any,
+) error {
+	_, err := ur.database.FindOne(collection, map[string]any{"email_index": emailIndex}, structure)
+	if err != nil {
+		ur.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/domain"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserMemoryRepository_EnsureIndexes(t *testing.T) {
+	t.Run("should refuse two users with the same email index", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		userRepository := storage.NewUserMemoryRepositoryImpl(logger.NewLogger(), memoryDatabase)
+
+		err := userRepository.EnsureIndexes(context.TODO())
+		assert.Nil(t, err, "should not return error")
+
+		_, err = memoryDatabase.Insert(database.UsersCollection, map[string]any{"email_index": "goo@gle.com"})
+		assert.Nil(t, err, "should insert the first user")
+
+		_, err = memoryDatabase.Insert(database.UsersCollection, map[string]any{"email_index": "goo@gle.com"})
+		assert.True(t, database.IsDuplicateKeyError(err), "should return duplicate key error")
+
+		_, err = memoryDatabase.Insert(database.UsersCollection, map[string]any{"email_index": ""})
+		_, err2 := memoryDatabase.Insert(database.UsersCollection, map[string]any{"email_index": ""})
+		assert.Nil(t, err, "should leave out users not indexed yet")
+		assert.Nil(t, err2, "should leave out users not indexed yet")
+	})
+}
+
+func TestUserMemoryRepository_GetByEmail(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should return the document when the email index exists", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		userRepository := storage.NewUserMemoryRepositoryImpl(logger.NewLogger(), memoryDatabase)
+
+		memoryDatabase.Insert(MOCK_COLL_NAME, map[string]any{"email_index": "goo@gle.com", "first_name": "bar"})
+
+		var result domain.UserDatabaseNoPassword
+		err := userRepository.GetByEmail(ctx, MOCK_COLL_NAME, "goo@gle.com", &result)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "bar", result.FirstName, "should return the expected first name")
+	})
+
+	t.Run("should return empty when no document is found", func(t *testing.T) {
+		userRepository := storage.NewUserMemoryRepositoryImpl(logger.NewLogger(), database.NewMemoryDatabase())
+
+		var result domain.UserDatabaseNoPassword
+		err := userRepository.GetByEmail(ctx, MOCK_COLL_NAME, "goo@gle.com", &result)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, (domain.UserDatabaseNoPassword{}), result, "should return empty result")
+	})
+
+	t.Run("should return error when failed to decode the document", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		userRepository := storage.NewUserMemoryRepositoryImpl(logger.NewLogger(), memoryDatabase)
+
+		memoryDatabase.Insert(MOCK_COLL_NAME, map[string]any{"email_index": "goo@gle.com", "first_name": 1})
+
+		var result domain.UserDatabaseNoPassword
+		err := userRepository.GetByEmail(ctx, MOCK_COLL_NAME, "goo@gle.com", &result)
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
+	})
+}
+
+func TestUserMemoryRepository_GetCredentialsByEmail(t *testing.T) {
+	t.Run("should return the document with the password", func(t *testing.T) {
+		memoryDatabase := database.NewMemoryDatabase()
+		userRepository := storage.NewUserMemoryRepositoryImpl(logger.NewLogger(), memoryDatabase)
+
+		memoryDatabase.Insert(MOCK_COLL_NAME, map[string]any{"email_index": "goo@gle.com", "password": "v1:9f86d0"})
+
+		var result domain.UserDatabase
+		err := userRepository.GetCredentialsByEmail(context.TODO(), MOCK_COLL_NAME, "goo@gle.com", &result)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "v1:9f86d0", result.Password, "should return the password")
+	})
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type passwordRecord struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	Password  string             `bson:"password"`
+	CipherKey string             `bson:"cipher_key"`
+}
+
+// UserPasswordRotationStoreImpl lets the encryption rotation job migrate the
+// user passwords to the primary key.
+type UserPasswordRotationStoreImpl struct {
+	logger   logger.LoggerInterface
+	database *database.Database
+}
+
+func NewUserPasswordRotationStoreImpl(
+	lg logger.LoggerInterface,
+	db *database.Database,
+) *UserPasswordRotationStoreImpl {
+	return &UserPasswordRotationStoreImpl{logger: lg, database: db}
+}
+
+func (us *UserPasswordRotationStoreImpl) FindStale(
+	ctx context.Context,
+	keyId string,
+	after string,
+	limit int,
+) ([]encryption.RotationRecord, error) {
+	filter := bson.M{
+		"password": bson.M{
+			"$exists": true,
+			"$not":    primitive.Regex{Pattern: "^" + regexp.QuoteMeta(keyId) + ":"},
+		},
+	}
+
+	if after != "" {
+		afterId, err := primitive.ObjectIDFromHex(after)
+		if err != nil {
+			us.logger.WithCtx(ctx).Error(err.Error())
+			return nil, exception.Wrap(exception.CodeValidationFailed, err)
+		}
+
+		filter["_id"] = bson.M{"$gt": afterId}
+	}
+
+	cursor, err := us.database.Collection(database.UsersCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": 1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"password": 1, "cipher_key": 1}),
+	)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	var records []passwordRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return nil, database.ParseToDatabaseError(err)
+	}
+
+	return toRotationRecords(records), nil
+}
+
+func (us *UserPasswordRotationStoreImpl) Replace(
+	ctx context.Context,
+	id string,
+	previous encryption.EncryptedText,
+	next encryption.EncryptedText,
+) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return exception.Wrap(exception.CodeValidationFailed, err)
+	}
+
+	result, err := us.database.Collection(database.UsersCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": objectId, "password": previous.EncryptedText},
+		bson.M{"$set": bson.M{"password": next.EncryptedText, "cipher_key": next.Salt}},
+	)
+	if err != nil {
+		us.logger.WithCtx(ctx).Error(err.Error())
+		return database.ParseToDatabaseError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return exception.New(exception.CodeNotFound)
+	}
+
+	return nil
+}
+
+func toRotationRecords(records []passwordRecord) []encryption.RotationRecord {
+	rotationRecords := make([]encryption.RotationRecord, 0, len(records))
+	for _, record := range records {
+		rotationRecords = append(rotationRecords, encryption.RotationRecord{
+			Id: record.Id.Hex(),
+			Encrypted: encryption.EncryptedText{
+				EncryptedText: record.Password,
+				Salt:          record.CipherKey,
+			},
+		})
+	}
+
+	return rotationRecords
+}
@@ -0,0 +1,123 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italoservio/braz_ecommerce/packages/database"
+	"github.com/italoservio/braz_ecommerce/packages/encryption"
+	"github.com/italoservio/braz_ecommerce/packages/exception"
+	"github.com/italoservio/braz_ecommerce/packages/logger"
+	"github.com/italoservio/braz_ecommerce/services/users/infra/storage"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUserPasswordRotationStore_FindStale(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should find the passwords not encrypted with the key after the given id", func(nestedMt *mtest.T) {
+		mockId := primitive.NewObjectID()
+		afterId := primitive.NewObjectID()
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: mockId},
+				{Key: "password", Value: "v1:abc"},
+				{Key: "cipher_key", Value: "def"},
+			},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserPasswordRotationStoreImpl(logger, mockDB)
+
+		records, err := store.FindStale(ctx, "v2", afterId.Hex(), 10)
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, []encryption.RotationRecord{{
+			Id:        mockId.Hex(),
+			Encrypted: encryption.EncryptedText{EncryptedText: "v1:abc", Salt: "def"},
+		}}, records)
+
+		command := nestedMt.GetStartedEvent().Command
+		filter := command.Lookup("filter").Document()
+		assert.Equal(t, afterId, filter.Lookup("_id", "$gt").ObjectID(), "should start after the given id")
+		pattern, _ := filter.Lookup("password", "$not").Regex()
+		assert.Equal(t, "^v2:", pattern, "should skip the passwords of the key")
+		assert.Equal(t, int64(10), command.Lookup("limit").AsInt64())
+	})
+
+	rootMt.Run("should return error when database fails", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "failed"}))
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserPasswordRotationStoreImpl(logger, mockDB)
+
+		_, err := store.FindStale(ctx, "v2", "", 10)
+
+		assert.Equal(t, exception.CodeDatabaseFailed, err.Error())
+	})
+}
+
+func TestUserPasswordRotationStore_Replace(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should replace the password when it did not change", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserPasswordRotationStoreImpl(logger, mockDB)
+
+		err := store.Replace(
+			ctx,
+			primitive.NewObjectID().Hex(),
+			encryption.EncryptedText{EncryptedText: "v1:abc", Salt: "def"},
+			encryption.EncryptedText{EncryptedText: "v2:ghi", Salt: "jkl"},
+		)
+
+		assert.Nil(t, err, "should not return error")
+
+		update := nestedMt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, "v1:abc", update.Lookup("q", "password").StringValue(), "should match the previous password")
+		assert.Equal(t, "v2:ghi", update.Lookup("u", "$set", "password").StringValue())
+		assert.Equal(t, "jkl", update.Lookup("u", "$set", "cipher_key").StringValue())
+	})
+
+	rootMt.Run("should return not found when the password changed meanwhile", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserPasswordRotationStoreImpl(logger, mockDB)
+
+		err := store.Replace(
+			ctx,
+			primitive.NewObjectID().Hex(),
+			encryption.EncryptedText{EncryptedText: "v1:abc", Salt: "def"},
+			encryption.EncryptedText{EncryptedText: "v2:ghi", Salt: "jkl"},
+		)
+
+		assert.Equal(t, exception.CodeNotFound, err.Error())
+	})
+
+	rootMt.Run("should return validation error when id is invalid", func(nestedMt *mtest.T) {
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		store := storage.NewUserPasswordRotationStoreImpl(logger, mockDB)
+
+		err := store.Replace(ctx, "123", encryption.EncryptedText{}, encryption.EncryptedText{})
+
+		assert.Equal(t, exception.CodeValidationFailed, err.Error())
+	})
+}
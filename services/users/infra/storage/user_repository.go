@@ -2,54 +2,119 @@ package storage
 
 import (
 	"context"
-	"errors"
-	"time"
+	"maps"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
-	"github.com/italoservio/braz_ecommerce/packages/exception"
 	"github.com/italoservio/braz_ecommerce/packages/logger"
 	"github.com/italoservio/braz_ecommerce/services/users/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepositoryInterface interface {
+	// GetByEmail looks the user up by the blind index of the email, since the
+	// email itself is encrypted at rest.
 	GetByEmail(
 		ctx context.Context,
 		collection string,
-		email string,
+		emailIndex string,
 		structure *domain.UserDatabaseNoPassword,
 	) error
+	// GetCredentialsByEmail is GetByEmail along with the encrypted password,
+	// for login only.
+	GetCredentialsByEmail(
+		ctx context.Context,
+		collection string,
+		emailIndex string,
+		structure *domain.UserDatabase,
+	) error
 }
 
 type UserRepositoryImpl struct {
 	logger   logger.LoggerInterface
 	database *database.Database
+	timeouts database.Timeouts
+	observer database.OperationObserver
 }
 
 func NewUserRepositoryImpl(lg logger.LoggerInterface, db *database.Database) *UserRepositoryImpl {
-	return &UserRepositoryImpl{logger: lg, database: db}
+	return &UserRepositoryImpl{logger: lg, database: db, timeouts: database.Timeouts{}}
+}
+
+// WithTimeouts returns a copy of the repository whose operations take the
+// given timeouts.
+func (cr *UserRepositoryImpl) WithTimeouts(timeouts database.Timeouts) *UserRepositoryImpl {
+	copied := *cr
+	copied.timeouts = maps.Clone(timeouts)
+	return &copied
+}
+
+// WithObserver returns a copy of the repository reporting its operations to
+// the observer.
+func (cr *UserRepositoryImpl) WithObserver(observer database.OperationObserver) *UserRepositoryImpl {
+	copied := *cr
+	copied.observer = observer
+	return &copied
+}
+
+// EnsureIndexes creates the unique index of the email blind index, so two
+// users can never share an email. Users not indexed yet, stored before field
+// encryption, are left out until the rotation job indexes them.
+func (cr *UserRepositoryImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := cr.database.Collection(database.UsersCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email_index", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email_index": bson.M{"$type": "string", "$gt": ""}}),
+	})
+
+	return err
 }
 
 func (cr *UserRepositoryImpl) GetByEmail(
 	ctx context.Context,
 	collection string,
-	email string,
+	emailIndex string,
 	structure *domain.UserDatabaseNoPassword,
 ) error {
+	return cr.getByEmail(ctx, collection, emailIndex, structure)
+}
+
+func (cr *UserRepositoryImpl) GetCredentialsByEmail(
+	ctx context.Context,
+	collection string,
+	emailIndex string,
+	structure *domain.UserDatabase,
+) error {
+	return cr.getByEmail(ctx, collection, emailIndex, structure)
+}
+
+func (cr *UserRepositoryImpl) getByEmail(
+	ctx context.Context,
+	collection string,
+	emailIndex string,
+	structure any,
+) (err error) {
 	coll := cr.database.Collection(collection)
 
-	cursor, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, span, end := cr.database.StartOperation(ctx, cr.observer, collection, database.OperationGetByEmail)
+	defer func() { end(err) }()
+
+	cursor, cancel := cr.timeouts.Context(ctx, database.OperationGetByEmail)
 	defer cancel()
 
-	err := coll.FindOne(cursor, bson.M{"email": email}).Decode(structure)
+	filter := bson.M{"email_index": emailIndex}
+	span.SetAttributes(database.FilterAttribute(filter))
+
+	err = coll.FindOne(cursor, filter).Decode(structure)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil
 		}
 
 		cr.logger.WithCtx(ctx).Error(err.Error())
-		return errors.New(exception.CodeDatabaseFailed)
+		return database.ParseToDatabaseError(err)
 	}
 
 	return nil
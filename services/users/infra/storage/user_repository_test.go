@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/italoservio/braz_ecommerce/packages/database"
 	"github.com/italoservio/braz_ecommerce/packages/exception"
@@ -83,7 +84,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 		err := deps.userRepository.GetByEmail(
 			deps.ctx,
 			MOCK_COLL_NAME,
-			"",
+			"a1b2c3",
 			&result,
 		)
 		if err != nil {
@@ -91,8 +92,11 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 			t.Fail()
 		}
 
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+
 		assert.Nil(t, err, "should not return error")
 		assert.Equal(t, "bar", result.FirstName, "should return the expected first name")
+		assert.Equal(t, "a1b2c3", filter.Lookup("email_index").StringValue(), "should filter by the email index")
 	})
 
 	rootMt.Run("should return empty when no document is found", func(nestedMt *mtest.T) {
@@ -140,4 +144,75 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 
 		assert.Equal(t, exception.CodeDatabaseFailed, err.Error(), "should return database call error")
 	})
+
+	rootMt.Run("should return timeout error when the operation takes too long", func(nestedMt *mtest.T) {
+		deps := BeforeEach_TestGetByEmail(nestedMt)
+		userRepository := deps.userRepository.WithTimeouts(database.Timeouts{database.OperationGetByEmail: time.Nanosecond})
+
+		var result domain.UserDatabaseNoPassword
+
+		err := userRepository.GetByEmail(
+			deps.ctx,
+			MOCK_COLL_NAME,
+			"",
+			&result,
+		)
+		if err == nil {
+			t.Fail()
+		}
+
+		assert.Equal(t, exception.CodeTimeout, err.Error(), "should return timeout error")
+	})
+}
+
+func TestUserRepository_GetCredentialsByEmail(t *testing.T) {
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should return the document with the password", func(nestedMt *mtest.T) {
+		deps := BeforeEach_TestGetByEmail(nestedMt)
+
+		nestedMt.AddMockResponses(mtest.CreateCursorResponse(
+			1,
+			MOCK_NS,
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "password", Value: "v1:9f86d0"},
+				{Key: "cipher_key", Value: "3a7bd3"},
+			},
+		))
+		defer nestedMt.ClearMockResponses()
+
+		var result domain.UserDatabase
+
+		err := deps.userRepository.GetCredentialsByEmail(deps.ctx, MOCK_COLL_NAME, "a1b2c3", &result)
+
+		filter := nestedMt.GetStartedEvent().Command.Lookup("filter").Document()
+
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, "v1:9f86d0", result.Password, "should return the password")
+		assert.Equal(t, "a1b2c3", filter.Lookup("email_index").StringValue(), "should filter by the email index")
+	})
+}
+
+func TestUserRepository_EnsureIndexes(t *testing.T) {
+	ctx := context.TODO()
+	logger := logger.NewLogger()
+	rootMt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	rootMt.Run("should create the unique index of the email", func(nestedMt *mtest.T) {
+		nestedMt.AddMockResponses(mtest.CreateSuccessResponse())
+		defer nestedMt.ClearMockResponses()
+
+		mockDB := &database.Database{Database: nestedMt.Client.Database(MOCK_DB_NAME)}
+		repository := storage.NewUserRepositoryImpl(logger, mockDB)
+
+		err := repository.EnsureIndexes(ctx)
+
+		index := nestedMt.GetStartedEvent().Command.Lookup("indexes").Array().Index(0).Value().Document()
+		assert.Nil(t, err, "should not return error")
+		assert.Equal(t, int32(1), index.Lookup("key", "email_index").Int32())
+		assert.True(t, index.Lookup("unique").Boolean(), "should be unique")
+		assert.Equal(t, "string", index.Lookup("partialFilterExpression", "email_index", "$type").StringValue(), "should leave out the users not indexed yet")
+	})
 }
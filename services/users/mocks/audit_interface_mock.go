@@ -0,0 +1,60 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/users/app/audit.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/users/app/audit.go -destination=services/users/mocks/audit_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	app "github.com/italoservio/braz_ecommerce/services/users/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=services/users/app/audit.go -destination=services/users/mocks/audit_interface_mock.go -package=mocks -write_generate_directive
+
+// MockAuditInterface is a mock of AuditInterface interface.
+type MockAuditInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditInterfaceMockRecorder
+}
+
+// MockAuditInterfaceMockRecorder is the mock recorder for MockAuditInterface.
+type MockAuditInterfaceMockRecorder struct {
+	mock *MockAuditInterface
+}
+
+// NewMockAuditInterface creates a new mock instance.
+func NewMockAuditInterface(ctrl *gomock.Controller) *MockAuditInterface {
+	mock := &MockAuditInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditInterface) EXPECT() *MockAuditInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockAuditInterface) Record(ctx context.Context, event app.AuditEvent, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, event}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Record", varargs...)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditInterfaceMockRecorder) Record(ctx, event any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, event}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditInterface)(nil).Record), varargs...)
+}
@@ -42,17 +42,32 @@ func (m *MockEncryptionInterface) EXPECT() *MockEncryptionInterfaceMockRecorder
 	return m.recorder
 }
 
+// Decrypt mocks base method.
+func (m *MockEncryptionInterface) Decrypt(ctx context.Context, encrypted *encryption.EncryptedText) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrypt", ctx, encrypted)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockEncryptionInterfaceMockRecorder) Decrypt(ctx, encrypted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockEncryptionInterface)(nil).Decrypt), ctx, encrypted)
+}
+
 // Encrypt mocks base method.
-func (m *MockEncryptionInterface) Encrypt(ctx context.Context, secret, text string) (*encryption.EncryptedText, error) {
+func (m *MockEncryptionInterface) Encrypt(ctx context.Context, text string) (*encryption.EncryptedText, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Encrypt", ctx, secret, text)
+	ret := m.ctrl.Call(m, "Encrypt", ctx, text)
 	ret0, _ := ret[0].(*encryption.EncryptedText)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Encrypt indicates an expected call of Encrypt.
-func (mr *MockEncryptionInterfaceMockRecorder) Encrypt(ctx, secret, text any) *gomock.Call {
+func (mr *MockEncryptionInterfaceMockRecorder) Encrypt(ctx, text any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockEncryptionInterface)(nil).Encrypt), ctx, secret, text)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockEncryptionInterface)(nil).Encrypt), ctx, text)
 }
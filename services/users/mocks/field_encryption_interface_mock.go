@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: packages/encryption/field.go
+//
+// Generated by this command:
+//
+//	mockgen -source=packages/encryption/field.go -destination=services/users/mocks/field_encryption_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=packages/encryption/field.go -destination=services/users/mocks/field_encryption_interface_mock.go -package=mocks -write_generate_directive
+
+// MockFieldEncryptionInterface is a mock of FieldEncryptionInterface interface.
+type MockFieldEncryptionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockFieldEncryptionInterfaceMockRecorder
+}
+
+// MockFieldEncryptionInterfaceMockRecorder is the mock recorder for MockFieldEncryptionInterface.
+type MockFieldEncryptionInterfaceMockRecorder struct {
+	mock *MockFieldEncryptionInterface
+}
+
+// NewMockFieldEncryptionInterface creates a new mock instance.
+func NewMockFieldEncryptionInterface(ctrl *gomock.Controller) *MockFieldEncryptionInterface {
+	mock := &MockFieldEncryptionInterface{ctrl: ctrl}
+	mock.recorder = &MockFieldEncryptionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFieldEncryptionInterface) EXPECT() *MockFieldEncryptionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BlindIndex mocks base method.
+func (m *MockFieldEncryptionInterface) BlindIndex(text string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlindIndex", text)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlindIndex indicates an expected call of BlindIndex.
+func (mr *MockFieldEncryptionInterfaceMockRecorder) BlindIndex(text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlindIndex", reflect.TypeOf((*MockFieldEncryptionInterface)(nil).BlindIndex), text)
+}
+
+// DecryptField mocks base method.
+func (m *MockFieldEncryptionInterface) DecryptField(ctx context.Context, field string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecryptField", ctx, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecryptField indicates an expected call of DecryptField.
+func (mr *MockFieldEncryptionInterfaceMockRecorder) DecryptField(ctx, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecryptField", reflect.TypeOf((*MockFieldEncryptionInterface)(nil).DecryptField), ctx, field)
+}
+
+// EncryptField mocks base method.
+func (m *MockFieldEncryptionInterface) EncryptField(ctx context.Context, text string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncryptField", ctx, text)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EncryptField indicates an expected call of EncryptField.
+func (mr *MockFieldEncryptionInterfaceMockRecorder) EncryptField(ctx, text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncryptField", reflect.TypeOf((*MockFieldEncryptionInterface)(nil).EncryptField), ctx, text)
+}
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/users/infra/storage/login_attempts_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/users/infra/storage/login_attempts_store.go -destination=services/users/mocks/login_attempts_store_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/italoservio/braz_ecommerce/services/users/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=services/users/infra/storage/login_attempts_store.go -destination=services/users/mocks/login_attempts_store_interface_mock.go -package=mocks -write_generate_directive
+
+// MockLoginAttemptsStoreInterface is a mock of LoginAttemptsStoreInterface interface.
+type MockLoginAttemptsStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptsStoreInterfaceMockRecorder
+}
+
+// MockLoginAttemptsStoreInterfaceMockRecorder is the mock recorder for MockLoginAttemptsStoreInterface.
+type MockLoginAttemptsStoreInterfaceMockRecorder struct {
+	mock *MockLoginAttemptsStoreInterface
+}
+
+// NewMockLoginAttemptsStoreInterface creates a new mock instance.
+func NewMockLoginAttemptsStoreInterface(ctrl *gomock.Controller) *MockLoginAttemptsStoreInterface {
+	mock := &MockLoginAttemptsStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptsStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptsStoreInterface) EXPECT() *MockLoginAttemptsStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Fail mocks base method.
+func (m *MockLoginAttemptsStoreInterface) Fail(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, key, now, window)
+	ret0, _ := ret[0].(*domain.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLoginAttemptsStoreInterfaceMockRecorder) Fail(ctx, key, now, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLoginAttemptsStoreInterface)(nil).Fail), ctx, key, now, window)
+}
+
+// Get mocks base method.
+func (m *MockLoginAttemptsStoreInterface) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*domain.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLoginAttemptsStoreInterfaceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLoginAttemptsStoreInterface)(nil).Get), ctx, key)
+}
+
+// GetByUnlockToken mocks base method.
+func (m *MockLoginAttemptsStoreInterface) GetByUnlockToken(ctx context.Context, unlockToken string) (*domain.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUnlockToken", ctx, unlockToken)
+	ret0, _ := ret[0].(*domain.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUnlockToken indicates an expected call of GetByUnlockToken.
+func (mr *MockLoginAttemptsStoreInterfaceMockRecorder) GetByUnlockToken(ctx, unlockToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUnlockToken", reflect.TypeOf((*MockLoginAttemptsStoreInterface)(nil).GetByUnlockToken), ctx, unlockToken)
+}
+
+// Lock mocks base method.
+func (m *MockLoginAttemptsStoreInterface) Lock(ctx context.Context, key string, until time.Time, unlockToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, until, unlockToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginAttemptsStoreInterfaceMockRecorder) Lock(ctx, key, until, unlockToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginAttemptsStoreInterface)(nil).Lock), ctx, key, until, unlockToken)
+}
+
+// Reset mocks base method.
+func (m *MockLoginAttemptsStoreInterface) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginAttemptsStoreInterfaceMockRecorder) Reset(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginAttemptsStoreInterface)(nil).Reset), ctx, key)
+}
@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/users/app/login.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/users/app/login.go -destination=services/users/mocks/login_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	app "github.com/italoservio/braz_ecommerce/services/users/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=services/users/app/login.go -destination=services/users/mocks/login_interface_mock.go -package=mocks -write_generate_directive
+
+// MockLoginInterface is a mock of LoginInterface interface.
+type MockLoginInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginInterfaceMockRecorder
+}
+
+// MockLoginInterfaceMockRecorder is the mock recorder for MockLoginInterface.
+type MockLoginInterfaceMockRecorder struct {
+	mock *MockLoginInterface
+}
+
+// NewMockLoginInterface creates a new mock instance.
+func NewMockLoginInterface(ctrl *gomock.Controller) *MockLoginInterface {
+	mock := &MockLoginInterface{ctrl: ctrl}
+	mock.recorder = &MockLoginInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginInterface) EXPECT() *MockLoginInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockLoginInterface) Do(ctx context.Context, input *app.LoginInput) (*app.LoginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, input)
+	ret0, _ := ret[0].(*app.LoginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockLoginInterfaceMockRecorder) Do(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockLoginInterface)(nil).Do), ctx, input)
+}
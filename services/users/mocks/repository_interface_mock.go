@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: packages/database/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=packages/database/repository.go -destination=services/users/mocks/repository_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	database "github.com/italoservio/braz_ecommerce/packages/database"
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=packages/database/repository.go -destination=services/users/mocks/repository_interface_mock.go -package=mocks -write_generate_directive
+
+// MockRepositoryInterface is a mock of RepositoryInterface interface.
+type MockRepositoryInterface[T any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryInterfaceMockRecorder[T]
+}
+
+// MockRepositoryInterfaceMockRecorder is the mock recorder for MockRepositoryInterface.
+type MockRepositoryInterfaceMockRecorder[T any] struct {
+	mock *MockRepositoryInterface[T]
+}
+
+// NewMockRepositoryInterface creates a new mock instance.
+func NewMockRepositoryInterface[T any](ctrl *gomock.Controller) *MockRepositoryInterface[T] {
+	mock := &MockRepositoryInterface[T]{ctrl: ctrl}
+	mock.recorder = &MockRepositoryInterfaceMockRecorder[T]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepositoryInterface[T]) EXPECT() *MockRepositoryInterfaceMockRecorder[T] {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRepositoryInterface[T]) Create(ctx context.Context, document *T) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, document)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRepositoryInterfaceMockRecorder[T]) Create(ctx, document any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepositoryInterface[T])(nil).Create), ctx, document)
+}
+
+// Find mocks base method.
+func (m *MockRepositoryInterface[T]) Find(ctx context.Context, input *database.FindInput) (*database.PaginatedSlice[T], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, input)
+	ret0, _ := ret[0].(*database.PaginatedSlice[T])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockRepositoryInterfaceMockRecorder[T]) Find(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockRepositoryInterface[T])(nil).Find), ctx, input)
+}
+
+// GetById mocks base method.
+func (m *MockRepositoryInterface[T]) GetById(ctx context.Context, id string, deleted bool) (*T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, id, deleted)
+	ret0, _ := ret[0].(*T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockRepositoryInterfaceMockRecorder[T]) GetById(ctx, id, deleted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockRepositoryInterface[T])(nil).GetById), ctx, id, deleted)
+}
+
+// SoftDelete mocks base method.
+func (m *MockRepositoryInterface[T]) SoftDelete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockRepositoryInterfaceMockRecorder[T]) SoftDelete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockRepositoryInterface[T])(nil).SoftDelete), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockRepositoryInterface[T]) Update(ctx context.Context, id string, changes *T) (*T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, changes)
+	ret0, _ := ret[0].(*T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRepositoryInterfaceMockRecorder[T]) Update(ctx, id, changes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepositoryInterface[T])(nil).Update), ctx, id, changes)
+}
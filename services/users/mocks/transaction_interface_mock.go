@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: packages/database/transaction.go
+//
+// Generated by this command:
+//
+//	mockgen -source=packages/database/transaction.go -destination=services/users/mocks/transaction_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=packages/database/transaction.go -destination=services/users/mocks/transaction_interface_mock.go -package=mocks -write_generate_directive
+
+// MockTransactionInterface is a mock of TransactionInterface interface.
+type MockTransactionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionInterfaceMockRecorder
+}
+
+// MockTransactionInterfaceMockRecorder is the mock recorder for MockTransactionInterface.
+type MockTransactionInterfaceMockRecorder struct {
+	mock *MockTransactionInterface
+}
+
+// NewMockTransactionInterface creates a new mock instance.
+func NewMockTransactionInterface(ctrl *gomock.Controller) *MockTransactionInterface {
+	mock := &MockTransactionInterface{ctrl: ctrl}
+	mock.recorder = &MockTransactionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionInterface) EXPECT() *MockTransactionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// WithTransaction mocks base method.
+func (m *MockTransactionInterface) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockTransactionInterfaceMockRecorder) WithTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockTransactionInterface)(nil).WithTransaction), ctx, fn)
+}
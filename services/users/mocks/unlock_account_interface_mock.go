@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/users/app/unlock_account.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/users/app/unlock_account.go -destination=services/users/mocks/unlock_account_interface_mock.go -package=mocks -write_generate_directive
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	app "github.com/italoservio/braz_ecommerce/services/users/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -source=services/users/app/unlock_account.go -destination=services/users/mocks/unlock_account_interface_mock.go -package=mocks -write_generate_directive
+
+// MockUnlockAccountInterface is a mock of UnlockAccountInterface interface.
+type MockUnlockAccountInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnlockAccountInterfaceMockRecorder
+}
+
+// MockUnlockAccountInterfaceMockRecorder is the mock recorder for MockUnlockAccountInterface.
+type MockUnlockAccountInterfaceMockRecorder struct {
+	mock *MockUnlockAccountInterface
+}
+
+// NewMockUnlockAccountInterface creates a new mock instance.
+func NewMockUnlockAccountInterface(ctrl *gomock.Controller) *MockUnlockAccountInterface {
+	mock := &MockUnlockAccountInterface{ctrl: ctrl}
+	mock.recorder = &MockUnlockAccountInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnlockAccountInterface) EXPECT() *MockUnlockAccountInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUnlockAccountInterface) Do(ctx context.Context, input *app.UnlockAccountInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUnlockAccountInterfaceMockRecorder) Do(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUnlockAccountInterface)(nil).Do), ctx, input)
+}
+
+// MockUnlockUserByIdInterface is a mock of UnlockUserByIdInterface interface.
+type MockUnlockUserByIdInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnlockUserByIdInterfaceMockRecorder
+}
+
+// MockUnlockUserByIdInterfaceMockRecorder is the mock recorder for MockUnlockUserByIdInterface.
+type MockUnlockUserByIdInterfaceMockRecorder struct {
+	mock *MockUnlockUserByIdInterface
+}
+
+// NewMockUnlockUserByIdInterface creates a new mock instance.
+func NewMockUnlockUserByIdInterface(ctrl *gomock.Controller) *MockUnlockUserByIdInterface {
+	mock := &MockUnlockUserByIdInterface{ctrl: ctrl}
+	mock.recorder = &MockUnlockUserByIdInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnlockUserByIdInterface) EXPECT() *MockUnlockUserByIdInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUnlockUserByIdInterface) Do(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUnlockUserByIdInterfaceMockRecorder) Do(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUnlockUserByIdInterface)(nil).Do), ctx, id)
+}
@@ -43,15 +43,29 @@ func (m *MockUserRepositoryInterface) EXPECT() *MockUserRepositoryInterfaceMockR
 }
 
 // GetByEmail mocks base method.
-func (m *MockUserRepositoryInterface) GetByEmail(ctx context.Context, collection, email string, structure *domain.UserDatabaseNoPassword) error {
+func (m *MockUserRepositoryInterface) GetByEmail(ctx context.Context, collection, emailIndex string, structure *domain.UserDatabaseNoPassword) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByEmail", ctx, collection, email, structure)
+	ret := m.ctrl.Call(m, "GetByEmail", ctx, collection, emailIndex, structure)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GetByEmail indicates an expected call of GetByEmail.
-func (mr *MockUserRepositoryInterfaceMockRecorder) GetByEmail(ctx, collection, email, structure any) *gomock.Call {
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetByEmail(ctx, collection, emailIndex, structure any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetByEmail), ctx, collection, email, structure)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetByEmail), ctx, collection, emailIndex, structure)
+}
+
+// GetCredentialsByEmail mocks base method.
+func (m *MockUserRepositoryInterface) GetCredentialsByEmail(ctx context.Context, collection, emailIndex string, structure *domain.UserDatabase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredentialsByEmail", ctx, collection, emailIndex, structure)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetCredentialsByEmail indicates an expected call of GetCredentialsByEmail.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetCredentialsByEmail(ctx, collection, emailIndex, structure any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentialsByEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetCredentialsByEmail), ctx, collection, emailIndex, structure)
 }